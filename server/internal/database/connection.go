@@ -59,10 +59,18 @@ func connectWithMigration(databaseURL string, runMigrations bool) (*gorm.DB, err
 			&models.Session{},
 			&models.SemesterDefinition{},
 			&models.SemesterOffering{},
+			&models.StudentGroup{},
+			&models.ElectiveGroup{},
+			&models.CombinedClass{},
 			&models.CourseOffering{},
 			&models.TeacherAssignment{},
 			&models.RoomAssignment{},
+			&models.TimeGrid{},
 			&models.TimeSlot{},
+			&models.SoftConstraint{},
+			&models.TeacherAvailability{},
+			&models.TeacherWorkloadLimit{},
+			&models.SessionScheduleRun{},
 			&models.ScheduleRun{},
 			&models.ScheduleBlock{},
 			&models.ScheduleEntry{},
@@ -115,20 +123,84 @@ func addIndexes(db *gorm.DB) error {
 		// Ignore if already exists
 	}
 	
-	if err := db.Exec("ALTER TABLE time_slots ADD UNIQUE INDEX uq_time_slot_day_num (day_of_week, slot_number)").Error; err != nil {
+	// Slot numbers are unique per grid; the old index spanned every grid
+	if err := db.Exec("ALTER TABLE time_slots DROP INDEX uq_time_slot_day_num").Error; err != nil {
+		// Ignore if already dropped
+	}
+
+	// Default-grid slots have no grid, and a unique index lets NULLs repeat,
+	// so slots are keyed by a generated column that is 0 for the default grid
+	if err := db.Exec("ALTER TABLE time_slots DROP INDEX uq_time_slot_grid_day_num").Error; err != nil {
+		// Ignore if already dropped
+	}
+
+	if err := db.Exec("ALTER TABLE time_slots ADD COLUMN grid_key BIGINT UNSIGNED AS (COALESCE(time_grid_id, 0)) VIRTUAL").Error; err != nil {
 		// Ignore if already exists
 	}
 
-	// Conflict prevention indexes for schedule_entries
-	if err := db.Exec("ALTER TABLE schedule_entries ADD UNIQUE INDEX uq_sched_entry_sess_day_slot_teacher (session_id, day_of_week, slot_number, teacher_id)").Error; err != nil {
+	// Default-grid slots added twice while they were not held unique keep
+	// their first row
+	if err := db.Exec("DELETE FROM time_slots WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM time_slots GROUP BY COALESCE(time_grid_id, 0), day_of_week, slot_number) AS firsts)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("ALTER TABLE time_slots ADD UNIQUE INDEX uq_time_slot_grid_key_day_num (grid_key, day_of_week, slot_number)").Error; err != nil {
+		// Ignore if already exists
+	}
+
+	// AutoMigrate does not widen an existing enum column
+	if err := db.Exec("ALTER TABLE schedule_runs MODIFY status ENUM('QUEUED','RUNNING','DRAFT','COMMITTED','CANCELLED','FAILED','SUPERSEDED') DEFAULT 'DRAFT'").Error; err != nil {
+		return err
+	}
+
+	// Conflict prevention indexes for schedule_entries. Only committed entries
+	// carry committed_session_id, so drafts, failed and cancelled runs never
+	// clash with each other or with what is committed. The entries of a
+	// combined class share one teacher and room, so only one of them per slot
+	// carries it.
+	for _, index := range []string{
+		"uq_sched_entry_sess_day_slot_teacher",
+		"uq_sched_entry_sess_day_slot_room",
+		"uq_sched_entry_sess_day_slot_teacher_comb",
+		"uq_sched_entry_sess_day_slot_room_comb",
+		"uq_sched_entry_committed_day_slot_teacher_comb",
+		"uq_sched_entry_committed_day_slot_room_comb",
+	} {
+		if err := db.Exec("ALTER TABLE schedule_entries DROP INDEX " + index).Error; err != nil {
+			// Ignore if already dropped
+		}
+	}
+
+	// Entries committed before the column existed
+	if err := db.Exec("UPDATE schedule_entries JOIN schedule_runs ON schedule_entries.schedule_run_id = schedule_runs.id SET schedule_entries.committed_session_id = schedule_entries.session_id WHERE schedule_runs.status = 'COMMITTED' AND schedule_entries.committed_session_id IS NULL AND schedule_entries.combined_class_id = 0 AND schedule_entries.deleted_at IS NULL").Error; err != nil {
+		return err
+	}
+
+	// Combined class entries committed when every group's entry carried the
+	// column keep it on the lowest entry of each slot only,
+	if err := db.Exec("UPDATE schedule_entries SET committed_session_id = NULL WHERE combined_class_id <> 0 AND committed_session_id IS NOT NULL AND id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM schedule_entries WHERE combined_class_id <> 0 AND committed_session_id IS NOT NULL GROUP BY committed_session_id, combined_class_id, day_of_week, slot_number) AS holders)").Error; err != nil {
+		return err
+	}
+
+	// and slots of combined classes committed before the column existed get one
+	if err := db.Exec("UPDATE schedule_entries SET committed_session_id = session_id WHERE id IN (SELECT id FROM (SELECT MIN(e.id) AS id FROM schedule_entries e JOIN schedule_runs r ON r.id = e.schedule_run_id WHERE e.combined_class_id <> 0 AND r.status = 'COMMITTED' AND e.deleted_at IS NULL GROUP BY e.session_id, e.combined_class_id, e.day_of_week, e.slot_number HAVING COUNT(e.committed_session_id) = 0) AS holders)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("ALTER TABLE schedule_entries ADD UNIQUE INDEX uq_sched_entry_committed_day_slot_teacher (committed_session_id, day_of_week, slot_number, teacher_id)").Error; err != nil {
 		// Ignore if already exists
 	}
 	
-	if err := db.Exec("ALTER TABLE schedule_entries ADD UNIQUE INDEX uq_sched_entry_sess_day_slot_room (session_id, day_of_week, slot_number, room_id)").Error; err != nil {
+	if err := db.Exec("ALTER TABLE schedule_entries ADD UNIQUE INDEX uq_sched_entry_committed_day_slot_room (committed_session_id, day_of_week, slot_number, room_id)").Error; err != nil {
 		// Ignore if already exists
 	}
 	
-	if err := db.Exec("ALTER TABLE schedule_entries ADD UNIQUE INDEX uq_sched_entry_run_day_slot_course (schedule_run_id, day_of_week, slot_number, course_offering_id)").Error; err != nil {
+	// Batches of one course may meet in parallel; the old index allowed one class per course and slot
+	if err := db.Exec("ALTER TABLE schedule_entries DROP INDEX uq_sched_entry_run_day_slot_course").Error; err != nil {
+		// Ignore if already dropped
+	}
+
+	if err := db.Exec("ALTER TABLE schedule_entries ADD UNIQUE INDEX uq_sched_entry_run_day_slot_course_group (schedule_run_id, day_of_week, slot_number, course_offering_id, student_group_id)").Error; err != nil {
 		// Ignore if already exists
 	}
 
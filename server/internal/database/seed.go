@@ -24,35 +24,28 @@ func SeedData(db *gorm.DB) error {
 	}
 
 	// Create default time slots (Monday to Friday, 7 slots per day)
-	timeSlots := []models.TimeSlot{
-		// Monday
-		{DayOfWeek: 1, SlotNumber: 1, StartTime: parseTime("09:00"), EndTime: parseTime("09:55")},
-		{DayOfWeek: 1, SlotNumber: 2, StartTime: parseTime("09:55"), EndTime: parseTime("10:50")},
-		{DayOfWeek: 1, SlotNumber: 3, StartTime: parseTime("10:50"), EndTime: parseTime("11:45")},
-		{DayOfWeek: 1, SlotNumber: 4, StartTime: parseTime("11:45"), EndTime: parseTime("12:40")},
-		{DayOfWeek: 1, SlotNumber: 5, StartTime: parseTime("13:50"), EndTime: parseTime("14:45")}, // After lunch break
-		{DayOfWeek: 1, SlotNumber: 6, StartTime: parseTime("14:45"), EndTime: parseTime("15:40")},
-		{DayOfWeek: 1, SlotNumber: 7, StartTime: parseTime("15:40"), EndTime: parseTime("16:35")},
+	// Labs start at slot 2 (morning) or slot 5 (afternoon)
+	daySlots := []models.TimeSlot{
+		{SlotNumber: 1, StartTime: "09:00", EndTime: "09:55"},
+		{SlotNumber: 2, StartTime: "09:55", EndTime: "10:50", IsLabStart: true},
+		{SlotNumber: 3, StartTime: "10:50", EndTime: "11:45"},
+		{SlotNumber: 4, StartTime: "11:45", EndTime: "12:40"},
+		{SlotNumber: 5, StartTime: "13:50", EndTime: "14:45", IsLabStart: true}, // After lunch break
+		{SlotNumber: 6, StartTime: "14:45", EndTime: "15:40"},
+		{SlotNumber: 7, StartTime: "15:40", EndTime: "16:35"},
 	}
 
-	// Repeat for Tuesday to Friday
-	for day := 2; day <= 5; day++ {
-		for slot := 1; slot <= 8; slot++ {
-			baseSlot := timeSlots[slot-1]
-			timeSlots = append(timeSlots, models.TimeSlot{
-				DayOfWeek:  day,
-				SlotNumber: slot,
-				StartTime:  baseSlot.StartTime,
-				EndTime:    baseSlot.EndTime,
-			})
+	var timeSlots []models.TimeSlot
+	for day := 1; day <= 5; day++ {
+		for _, slot := range daySlots {
+			slot.DayOfWeek = day
+			timeSlots = append(timeSlots, slot)
 		}
 	}
 
 	for _, ts := range timeSlots {
-		if err := db.FirstOrCreate(&ts, models.TimeSlot{
-			DayOfWeek:  ts.DayOfWeek,
-			SlotNumber: ts.SlotNumber,
-		}).Error; err != nil {
+		if err := db.Where("time_grid_id IS NULL AND day_of_week = ? AND slot_number = ?", ts.DayOfWeek, ts.SlotNumber).
+			FirstOrCreate(&ts).Error; err != nil {
 			return err
 		}
 	}
@@ -85,9 +78,4 @@ func SeedData(db *gorm.DB) error {
 	}
 
 	return nil
-}
-
-func parseTime(timeStr string) time.Time {
-	t, _ := time.Parse("15:04", timeStr)
-	return t
 }
\ No newline at end of file
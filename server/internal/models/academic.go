@@ -52,10 +52,63 @@ type SemesterOffering struct {
 	Programme        Programme        `json:"programme,omitempty" gorm:"foreignKey:ProgrammeID"`
 	Department       Department       `json:"department,omitempty" gorm:"foreignKey:DepartmentID"`
 	Session          Session          `json:"session,omitempty" gorm:"foreignKey:SessionID"`
+	StudentGroups    []StudentGroup   `json:"student_groups,omitempty" gorm:"foreignKey:SemesterOfferingID"`
 	CourseOfferings  []CourseOffering `json:"course_offerings,omitempty" gorm:"foreignKey:SemesterOfferingID"`
 	ScheduleRuns     []ScheduleRun    `json:"schedule_runs,omitempty" gorm:"foreignKey:SemesterOfferingID"`
 }
 
+// StudentGroup is a section of a semester offering, or a lab batch within a
+// section, that classes can be scheduled for on its own
+type StudentGroup struct {
+	ID                 uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	SemesterOfferingID uint           `json:"semester_offering_id" gorm:"not null;index"`
+	ParentID           *uint          `json:"parent_id"` // Section of a batch; nil for a section
+	Name               string         `json:"name" gorm:"type:varchar(50);not null"`
+	Kind               string         `json:"kind" gorm:"type:enum('SECTION','BATCH');not null"`
+	Strength           int            `json:"strength"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	SemesterOffering   SemesterOffering `json:"semester_offering,omitempty" gorm:"foreignKey:SemesterOfferingID"`
+	Parent             *StudentGroup    `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+	Batches            []StudentGroup   `json:"batches,omitempty" gorm:"foreignKey:ParentID"`
+}
+
+// ElectiveGroup is a basket of course offerings, possibly from several
+// semester offerings of a session, that students choose between. Its course
+// offerings are scheduled in the same day/slots.
+type ElectiveGroup struct {
+	ID              uint             `json:"id" gorm:"primaryKey;autoIncrement"`
+	SessionID       uint             `json:"session_id" gorm:"not null;index"`
+	Name            string           `json:"name" gorm:"type:varchar(100);not null"`
+	Description     string           `json:"description" gorm:"type:text"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt   `json:"-" gorm:"index"`
+
+	// Relationships
+	Session         Session          `json:"session,omitempty" gorm:"foreignKey:SessionID"`
+	CourseOfferings []CourseOffering `json:"course_offerings,omitempty" gorm:"foreignKey:ElectiveGroupID"`
+}
+
+// CombinedClass links course offerings of several semester offerings, e.g.
+// one subject shared by two departments, into one class taught together by
+// one teacher in one room
+type CombinedClass struct {
+	ID              uint             `json:"id" gorm:"primaryKey;autoIncrement"`
+	SessionID       uint             `json:"session_id" gorm:"not null;index"`
+	Name            string           `json:"name" gorm:"type:varchar(100);not null"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt   `json:"-" gorm:"index"`
+
+	// Relationships
+	Session         Session          `json:"session,omitempty" gorm:"foreignKey:SessionID"`
+	CourseOfferings []CourseOffering `json:"course_offerings,omitempty" gorm:"foreignKey:CombinedClassID"`
+}
+
 // CourseOffering represents a subject offered in a specific semester
 type CourseOffering struct {
 	ID                   uint                 `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -65,6 +118,9 @@ type CourseOffering struct {
 	RequiredPattern      string               `json:"required_pattern" gorm:"type:json"` // JSON array like ["2+2"] or ["3"]
 	IsLab                bool                 `json:"is_lab" gorm:"default:false"`
 	PreferredRoomID      *uint                `json:"preferred_room_id"`
+	MaxRoomSplit         int                  `json:"max_room_split" gorm:"default:1"` // Rooms the group may be split across at once when no single room seats it
+	ElectiveGroupID      *uint                `json:"elective_group_id" gorm:"index"` // Basket whose course offerings meet in the same slots
+	CombinedClassID      *uint                `json:"combined_class_id" gorm:"index"` // Class taught to this and other course offerings' groups at once
 	Notes                string               `json:"notes" gorm:"type:text"`
 	CreatedAt            time.Time            `json:"created_at"`
 	UpdatedAt            time.Time            `json:"updated_at"`
@@ -73,6 +129,7 @@ type CourseOffering struct {
 	// Relationships
 	SemesterOffering     SemesterOffering     `json:"semester_offering,omitempty" gorm:"foreignKey:SemesterOfferingID"`
 	Subject              Subject              `json:"subject,omitempty" gorm:"foreignKey:SubjectID"`
+	StudentGroups        []StudentGroup       `json:"student_groups,omitempty" gorm:"many2many:course_offering_groups"` // Sections or batches taught separately; none for the whole offering
 	PreferredRoom        *Room                `json:"preferred_room,omitempty" gorm:"foreignKey:PreferredRoomID"`
 	TeacherAssignments   []TeacherAssignment  `json:"teacher_assignments,omitempty" gorm:"foreignKey:CourseOfferingID"`
 	RoomAssignments      []RoomAssignment     `json:"room_assignments,omitempty" gorm:"foreignKey:CourseOfferingID"`
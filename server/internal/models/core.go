@@ -49,6 +49,7 @@ type Teacher struct {
 	Name         string         `json:"name" gorm:"type:varchar(255);not null"`
 	Initials     *string        `json:"initials" gorm:"type:varchar(10);uniqueIndex"`
 	Email        string         `json:"email" gorm:"type:varchar(255);uniqueIndex"`
+	Designation  string         `json:"designation" gorm:"type:varchar(100)"` // e.g. "Professor", matched by workload limits
 	DepartmentID uint           `json:"department_id" gorm:"not null"`
 	IsActive     bool           `json:"is_active" gorm:"default:true"`
 	CreatedAt    time.Time      `json:"created_at"`
@@ -59,6 +60,7 @@ type Teacher struct {
 	Department         Department          `json:"department,omitempty" gorm:"foreignKey:DepartmentID"`
 	TeacherAssignments []TeacherAssignment `json:"teacher_assignments,omitempty" gorm:"foreignKey:TeacherID"`
 	ScheduleEntries    []ScheduleEntry     `json:"schedule_entries,omitempty" gorm:"foreignKey:TeacherID"`
+	Availability       []TeacherAvailability `json:"availability,omitempty" gorm:"foreignKey:TeacherID"`
 }
 
 // SubjectType represents the type of subject (Theory, Lab, etc.)
@@ -67,6 +69,7 @@ type SubjectType struct {
 	Name                      string         `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
 	IsLab                     bool           `json:"is_lab" gorm:"default:false"`
 	DefaultConsecutivePreferred bool        `json:"default_consecutive_preferred" gorm:"default:true"`
+	RequiredFeatures          string         `json:"required_features" gorm:"type:json;default:null"` // Room features every subject of the type needs, e.g. {"pc":60}
 	CreatedAt                 time.Time      `json:"created_at"`
 	UpdatedAt                 time.Time      `json:"updated_at"`
 	DeletedAt                 gorm.DeletedAt `json:"-" gorm:"index"`
@@ -85,6 +88,7 @@ type Subject struct {
 	ProgrammeID      uint             `json:"programme_id" gorm:"not null"`
 	DepartmentID     uint             `json:"department_id" gorm:"not null"`
 	SubjectTypeID    uint             `json:"subject_type_id" gorm:"not null"`
+	RequiredFeatures string           `json:"required_features" gorm:"type:json;default:null"` // Overrides the subject type's requirements by feature name
 	IsActive         bool             `json:"is_active" gorm:"default:true"`
 	CreatedAt        time.Time        `json:"created_at"`
 	UpdatedAt        time.Time        `json:"updated_at"`
@@ -104,6 +108,7 @@ type Room struct {
 	RoomNumber   string           `json:"room_number" gorm:"type:varchar(50);not null;uniqueIndex"`
 	Capacity     int              `json:"capacity"`
 	Type         string           `json:"type" gorm:"type:enum('THEORY','LAB','OTHER');not null"`
+	Features     string           `json:"features" gorm:"type:json;default:null"` // Feature quantities, e.g. {"projector":1,"pc":60}
 	DepartmentID *uint            `json:"department_id"` // Optional owner department
 	IsActive     bool             `json:"is_active" gorm:"default:true"`
 	CreatedAt    time.Time        `json:"created_at"`
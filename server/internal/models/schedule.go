@@ -6,22 +6,106 @@ import (
 	"gorm.io/gorm"
 )
 
-// TimeSlot represents the static time slot definitions
+// TimeSlot represents the static time slot definitions. Slots without a time
+// grid make up the default grid; breaks fall wherever one slot ends before the
+// next begins.
 type TimeSlot struct {
 	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	DayOfWeek   int       `json:"day_of_week" gorm:"not null"` // 1=Monday, 5=Friday
-	SlotNumber  int       `json:"slot_number" gorm:"not null"` // 1-8
-	StartTime   time.Time `json:"start_time" gorm:"type:time;not null"`
-	EndTime     time.Time `json:"end_time" gorm:"type:time;not null"`
+	TimeGridID  *uint     `json:"time_grid_id" gorm:"index"` // Nil for the default grid
+	DayOfWeek   int       `json:"day_of_week" gorm:"not null"` // 1=Monday, 7=Sunday
+	SlotNumber  int       `json:"slot_number" gorm:"not null"` // 1-based within the day
+	StartTime   string    `json:"start_time" gorm:"type:time;not null"` // HH:MM
+	EndTime     string    `json:"end_time" gorm:"type:time;not null"`
+	IsLabStart  bool      `json:"is_lab_start" gorm:"default:false"` // Labs may start here; a day with no such slot lets labs start anywhere they fit
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// TimeGrid is a named weekly slot layout used instead of the default grid by
+// the semester offerings of its programme
+type TimeGrid struct {
+	ID          uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string         `json:"name" gorm:"type:varchar(255);not null"`
+	Description string         `json:"description" gorm:"type:text"`
+	ProgrammeID *uint          `json:"programme_id" gorm:"index"` // Programme scheduled on this grid
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Programme *Programme `json:"programme,omitempty" gorm:"foreignKey:ProgrammeID"`
+	TimeSlots []TimeSlot `json:"time_slots,omitempty" gorm:"foreignKey:TimeGridID"`
+}
+
+// SoftConstraint sets the weight of one soft constraint for the semester
+// offerings of a programme, or of a department, overriding the built-in weight.
+// Department constraints take precedence over programme ones.
+type SoftConstraint struct {
+	ID           uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Type         string         `json:"type" gorm:"type:varchar(50);not null"` // e.g. SPREAD_SUBJECT, MIN_STUDENT_GAPS
+	Weight       int            `json:"weight" gorm:"not null"` // Penalty per unit; 0 turns the constraint off
+	Params       string         `json:"params" gorm:"type:json"` // JSON of integer parameters, e.g. {"max_consecutive": 3}
+	ProgrammeID  *uint          `json:"programme_id" gorm:"index"`
+	DepartmentID *uint          `json:"department_id" gorm:"index"`
+	IsActive     bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Programme  *Programme  `json:"programme,omitempty" gorm:"foreignKey:ProgrammeID"`
+	Department *Department `json:"department,omitempty" gorm:"foreignKey:DepartmentID"`
+}
+
+// TeacherAvailability marks a span of slots on one day that a teacher cannot
+// teach, or prefers to teach, every week. A row with a session applies to that
+// session only and replaces the weekly rows wherever their slots overlap; an
+// AVAILABLE session row just lifts the weekly ones.
+type TeacherAvailability struct {
+	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	TeacherID uint           `json:"teacher_id" gorm:"not null;index"`
+	SessionID *uint          `json:"session_id" gorm:"index"` // Nil for the recurring weekly pattern
+	DayOfWeek int            `json:"day_of_week" gorm:"not null"` // 1=Monday, 7=Sunday
+	SlotStart int            `json:"slot_start" gorm:"not null"` // First slot covered
+	SlotEnd   int            `json:"slot_end" gorm:"not null"`   // Last slot covered
+	Kind      string         `json:"kind" gorm:"type:enum('UNAVAILABLE','PREFERRED','AVAILABLE');not null"`
+	Reason    string         `json:"reason" gorm:"type:varchar(255)"` // e.g. "On leave", "Research day"
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Teacher Teacher  `json:"teacher,omitempty" gorm:"foreignKey:TeacherID"`
+	Session *Session `json:"session,omitempty" gorm:"foreignKey:SessionID"`
+}
+
+// TeacherWorkloadLimit caps how much one teacher, or every teacher of a
+// designation, teaches in a session. A limit of 0 is not enforced.
+type TeacherWorkloadLimit struct {
+	ID             uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	TeacherID      *uint          `json:"teacher_id" gorm:"index"`                    // Set for one teacher, overriding their designation's row
+	Designation    string         `json:"designation" gorm:"type:varchar(100);index"` // Set for every teacher of the designation
+	MaxPerDay      int            `json:"max_per_day"`
+	MaxPerWeek     int            `json:"max_per_week"`
+	MaxConsecutive int            `json:"max_consecutive"` // Periods taught back to back, a break ending the run
+	MinFreeDays    int            `json:"min_free_days"`   // Working days of the week without a class
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Teacher *Teacher `json:"teacher,omitempty" gorm:"foreignKey:TeacherID"`
+}
+
 // ScheduleRun represents a routine generation run
 type ScheduleRun struct {
 	ID                   uint             `json:"id" gorm:"primaryKey;autoIncrement"`
 	SemesterOfferingID   uint             `json:"semester_offering_id" gorm:"not null"`
-	Status               string           `json:"status" gorm:"type:enum('DRAFT','COMMITTED','CANCELLED','FAILED');default:'DRAFT'"`
+	ParentRunID          *uint            `json:"parent_run_id"` // Set when produced by a session-wide generation
+	SourceRunID          *uint            `json:"source_run_id"` // Run this one was regenerated from, keeping some of its blocks
+	SupersededByRunID    *uint            `json:"superseded_by_run_id"` // Run committed in place of this one
+	Status               string           `json:"status" gorm:"type:enum('QUEUED','RUNNING','DRAFT','COMMITTED','CANCELLED','FAILED','SUPERSEDED');default:'DRAFT'"`
 	AlgorithmVersion     string           `json:"algorithm_version" gorm:"type:varchar(20)"`
 	GeneratedByUserID    *uint            `json:"generated_by_user_id"`
 	GeneratedAt          time.Time        `json:"generated_at"`
@@ -37,17 +121,39 @@ type ScheduleRun struct {
 	ScheduleEntries      []ScheduleEntry  `json:"schedule_entries,omitempty" gorm:"foreignKey:ScheduleRunID"`
 }
 
+// SessionScheduleRun represents a session-wide generation that solved several
+// semester offerings together; each offering still gets its own ScheduleRun
+type SessionScheduleRun struct {
+	ID               uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	SessionID        uint           `json:"session_id" gorm:"not null"`
+	Status           string         `json:"status" gorm:"type:enum('DRAFT','COMMITTED','CANCELLED','FAILED');default:'DRAFT'"`
+	AlgorithmVersion string         `json:"algorithm_version" gorm:"type:varchar(20)"`
+	Filters          string         `json:"filters" gorm:"type:json"` // JSON of the filters used to select offerings
+	GeneratedAt      time.Time      `json:"generated_at"`
+	Meta             string         `json:"meta" gorm:"type:json"` // JSON for combined stats
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Session      Session       `json:"session,omitempty" gorm:"foreignKey:SessionID"`
+	ScheduleRuns []ScheduleRun `json:"schedule_runs,omitempty" gorm:"foreignKey:ParentRunID"`
+}
+
 // ScheduleBlock represents a continuous block of time (for multi-slot classes)
 type ScheduleBlock struct {
 	ID               uint             `json:"id" gorm:"primaryKey;autoIncrement"`
 	ScheduleRunID    uint             `json:"schedule_run_id" gorm:"not null"`
 	CourseOfferingID uint             `json:"course_offering_id" gorm:"not null"`
+	StudentGroupID   uint             `json:"student_group_id" gorm:"not null;default:0"` // 0 for the whole semester offering
 	TeacherID        uint             `json:"teacher_id" gorm:"not null"`
 	RoomID           uint             `json:"room_id" gorm:"not null"`
+	SplitRoomIDs     string           `json:"split_room_ids" gorm:"type:json;default:null"` // JSON array of further rooms used when the group is split
 	DayOfWeek        int              `json:"day_of_week" gorm:"not null"`
 	SlotStart        int              `json:"slot_start" gorm:"not null"`
 	SlotLength       int              `json:"slot_length" gorm:"not null"` // 1, 2, or 3 slots
 	IsLab            bool             `json:"is_lab" gorm:"default:false"`
+	IsPinned         bool             `json:"is_pinned" gorm:"default:false"` // Fixed in place by a manual edit
 	CreatedAt        time.Time        `json:"created_at"`
 	UpdatedAt        time.Time        `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt   `json:"-" gorm:"index"`
@@ -66,12 +172,16 @@ type ScheduleEntry struct {
 	ScheduleRunID        uint             `json:"schedule_run_id" gorm:"not null"`
 	SemesterOfferingID   uint             `json:"semester_offering_id" gorm:"not null"`
 	SessionID            uint             `json:"session_id" gorm:"not null"` // Denormalized for fast global conflict checks
+	CommittedSessionID   *uint            `json:"committed_session_id"` // Set while the run is committed, on one entry per combined class slot; only these entries are held unique per session
 	CourseOfferingID     uint             `json:"course_offering_id" gorm:"not null"`
+	StudentGroupID       uint             `json:"student_group_id" gorm:"not null;default:0"` // 0 for the whole semester offering
 	TeacherID            uint             `json:"teacher_id" gorm:"not null"`
 	RoomID               uint             `json:"room_id" gorm:"not null"`
+	SplitRoomIDs         string           `json:"split_room_ids" gorm:"type:json;default:null"` // JSON array of further rooms used when the group is split
 	DayOfWeek            int              `json:"day_of_week" gorm:"not null"`
 	SlotNumber           int              `json:"slot_number" gorm:"not null"`
 	BlockID              *uint            `json:"block_id"` // Reference to parent block
+	CombinedClassID      uint             `json:"combined_class_id" gorm:"not null;default:0"` // Shares teacher, room and block with the class's other groups; 0 when not combined
 	CreatedAt            time.Time        `json:"created_at"`
 	UpdatedAt            time.Time        `json:"updated_at"`
 	DeletedAt            gorm.DeletedAt   `json:"-" gorm:"index"`
@@ -89,12 +199,21 @@ type ScheduleEntry struct {
 // ClassBlock represents a class session to be scheduled (used in algorithm)
 type ClassBlock struct {
 	SubjectID         uint `json:"subject_id"`
-	TeacherID         uint `json:"teacher_id"`
-	RoomID            uint `json:"room_id"`
+	TeacherID         uint `json:"teacher_id"` // Preferred teacher, replaced by the one chosen at placement
+	RoomID            uint `json:"room_id"`    // Preferred room, replaced by the one chosen at placement
 	DurationSlots     int  `json:"duration_slots"` // 1, 2, or 3 slots
 	IsLab             bool `json:"is_lab"`
 	SemesterOfferingID uint `json:"semester_offering_id"`
 	CourseOfferingID  uint `json:"course_offering_id"`
+	StudentGroupID    uint `json:"student_group_id,omitempty"` // Section or batch taught, 0 for the whole offering
+	TeacherCandidates []uint `json:"teacher_candidates,omitempty"` // Assigned teachers, preferred first
+	RoomCandidates    []uint `json:"room_candidates,omitempty"`    // Assigned rooms by priority
+	SplitRoomIDs      []uint `json:"split_room_ids,omitempty"`     // Further rooms used at once when the group is split
+	Electives         []ClassBlock `json:"electives,omitempty"`      // Blocks of the rest of the elective basket, placed in the same slots
+	Combined          []ClassBlock `json:"combined,omitempty"`       // Blocks of the rest of the combined class, sharing this block's teacher and rooms
+	CombinedClassID   uint         `json:"combined_class_id,omitempty"`
+	Fixed             bool         `json:"fixed,omitempty"`  // Kept at its slot from the run regenerated from; solvers leave it there
+	Pinned            bool         `json:"pinned,omitempty"` // Pinned in the run regenerated from, and so in the new run
 }
 
 // TimeSlotInfo represents timetable slot information during generation
@@ -110,9 +110,11 @@ func (r *semesterOfferingRepository) GetAll() ([]models.SemesterOffering, error)
 	err := r.db.Preload("Programme").
 		Preload("Department").
 		Preload("Session").
+		Preload("StudentGroups").
 		Preload("CourseOfferings").
 		Preload("CourseOfferings.Subject").
 		Preload("CourseOfferings.Subject.SubjectType").
+		Preload("CourseOfferings.StudentGroups").
 		Preload("CourseOfferings.TeacherAssignments").
 		Preload("CourseOfferings.TeacherAssignments.Teacher").
 		Preload("CourseOfferings.RoomAssignments").
@@ -126,9 +128,11 @@ func (r *semesterOfferingRepository) GetByID(id uint) (*models.SemesterOffering,
 	err := r.db.Preload("Programme").
 		Preload("Department").
 		Preload("Session").
+		Preload("StudentGroups").
 		Preload("CourseOfferings").
 		Preload("CourseOfferings.Subject").
 		Preload("CourseOfferings.Subject.SubjectType").
+		Preload("CourseOfferings.StudentGroups").
 		Preload("CourseOfferings.TeacherAssignments").
 		Preload("CourseOfferings.TeacherAssignments.Teacher").
 		Preload("CourseOfferings.RoomAssignments").
@@ -145,9 +149,11 @@ func (r *semesterOfferingRepository) GetBySession(sessionID uint) ([]models.Seme
 	err := r.db.Preload("Programme").
 		Preload("Department").
 		Preload("Session").
+		Preload("StudentGroups").
 		Preload("CourseOfferings").
 		Preload("CourseOfferings.Subject").
 		Preload("CourseOfferings.Subject.SubjectType").
+		Preload("CourseOfferings.StudentGroups").
 		Preload("CourseOfferings.TeacherAssignments").
 		Preload("CourseOfferings.TeacherAssignments.Teacher").
 		Preload("CourseOfferings.RoomAssignments").
@@ -169,9 +175,11 @@ func (r *semesterOfferingRepository) GetWithCourseOfferings(id uint) (*models.Se
 	err := r.db.Preload("Programme").
 		Preload("Department").
 		Preload("Session").
+		Preload("StudentGroups").
 		Preload("CourseOfferings").
 		Preload("CourseOfferings.Subject").
 		Preload("CourseOfferings.Subject.SubjectType").
+		Preload("CourseOfferings.StudentGroups").
 		Preload("CourseOfferings.TeacherAssignments").
 		Preload("CourseOfferings.TeacherAssignments.Teacher").
 		Preload("CourseOfferings.RoomAssignments").
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"icrogen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CombinedClassRepository interface for combined class operations
+type CombinedClassRepository interface {
+	Create(class *models.CombinedClass, courseOfferingIDs []uint) error
+	GetByID(id uint) (*models.CombinedClass, error)
+	GetAll() ([]models.CombinedClass, error)
+	GetBySession(sessionID uint) ([]models.CombinedClass, error)
+	Update(class *models.CombinedClass, courseOfferingIDs []uint) error
+	Delete(id uint) error
+}
+
+type combinedClassRepository struct {
+	db *gorm.DB
+}
+
+func NewCombinedClassRepository(db *gorm.DB) CombinedClassRepository {
+	return &combinedClassRepository{db: db}
+}
+
+// Create stores the class and moves the course offerings into it
+func (r *combinedClassRepository) Create(class *models.CombinedClass, courseOfferingIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("CourseOfferings").Create(class).Error; err != nil {
+			return err
+		}
+		return linkCourseOfferings(tx, "combined_class_id", class.ID, courseOfferingIDs)
+	})
+}
+
+func (r *combinedClassRepository) GetByID(id uint) (*models.CombinedClass, error) {
+	var class models.CombinedClass
+	err := withCourseOfferings(r.db).Preload("Session").First(&class, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &class, nil
+}
+
+func (r *combinedClassRepository) GetAll() ([]models.CombinedClass, error) {
+	var classes []models.CombinedClass
+	err := withCourseOfferings(r.db).Order("session_id, name").Find(&classes).Error
+	return classes, err
+}
+
+func (r *combinedClassRepository) GetBySession(sessionID uint) ([]models.CombinedClass, error) {
+	var classes []models.CombinedClass
+	err := withCourseOfferings(r.db).Where("session_id = ?", sessionID).Order("name").Find(&classes).Error
+	return classes, err
+}
+
+// Update saves the class's name and replaces its course offerings
+func (r *combinedClassRepository) Update(class *models.CombinedClass, courseOfferingIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.CombinedClass{}).
+			Where("id = ?", class.ID).
+			Update("name", class.Name).Error; err != nil {
+			return err
+		}
+		return linkCourseOfferings(tx, "combined_class_id", class.ID, courseOfferingIDs)
+	})
+}
+
+// Delete releases the class's course offerings and deletes it
+func (r *combinedClassRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := linkCourseOfferings(tx, "combined_class_id", id, nil); err != nil {
+			return err
+		}
+		return tx.Delete(&models.CombinedClass{}, id).Error
+	})
+}
@@ -19,6 +19,10 @@ type CourseOfferingRepository interface {
 	RemoveRoomAssignment(assignmentID uint) error
 	GetTeacherAssignments(courseOfferingID uint) ([]models.TeacherAssignment, error)
 	GetRoomAssignments(courseOfferingID uint) ([]models.RoomAssignment, error)
+	GetAllRoomAssignments() ([]models.RoomAssignment, error)
+	ReplaceStudentGroups(offering *models.CourseOffering, groups []models.StudentGroup) error
+	GetByCombinedClass(combinedClassID uint) ([]models.CourseOffering, error)
+	GetByElectiveGroup(electiveGroupID uint) ([]models.CourseOffering, error)
 }
 
 type courseOfferingRepository struct {
@@ -35,8 +39,10 @@ func (r *courseOfferingRepository) Create(offering *models.CourseOffering) error
 
 func (r *courseOfferingRepository) GetByID(id uint) (*models.CourseOffering, error) {
 	var offering models.CourseOffering
-	err := r.db.Preload("Subject").
+	err := r.db.Preload("SemesterOffering.Department").
+		Preload("Subject").
 		Preload("Subject.SubjectType").
+		Preload("StudentGroups").
 		Preload("TeacherAssignments").
 		Preload("TeacherAssignments.Teacher").
 		Preload("RoomAssignments").
@@ -52,6 +58,7 @@ func (r *courseOfferingRepository) GetBySemesterOffering(semesterOfferingID uint
 	var offerings []models.CourseOffering
 	err := r.db.Preload("Subject").
 		Preload("Subject.SubjectType").
+		Preload("StudentGroups").
 		Preload("TeacherAssignments").
 		Preload("TeacherAssignments.Teacher").
 		Preload("RoomAssignments").
@@ -99,4 +106,43 @@ func (r *courseOfferingRepository) GetRoomAssignments(courseOfferingID uint) ([]
 		Where("course_offering_id = ?", courseOfferingID).
 		Find(&assignments).Error
 	return assignments, err
-}
\ No newline at end of file
+}
+// GetAllRoomAssignments returns every room assignment with its room and the
+// course offering's subject and semester offering department
+func (r *courseOfferingRepository) GetAllRoomAssignments() ([]models.RoomAssignment, error) {
+	var assignments []models.RoomAssignment
+	err := r.db.Preload("Room").
+		Preload("CourseOffering.Subject").
+		Preload("CourseOffering.StudentGroups").
+		Preload("CourseOffering.SemesterOffering.Department").
+		Order("course_offering_id, priority").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// ReplaceStudentGroups sets the sections or batches a course offering is
+// taught to; none means the whole semester offering
+func (r *courseOfferingRepository) ReplaceStudentGroups(offering *models.CourseOffering, groups []models.StudentGroup) error {
+	return r.db.Model(offering).Association("StudentGroups").Replace(groups)
+}
+
+// GetByCombinedClass returns the course offerings taught together in a combined
+// class, with the groups they are taught to
+func (r *courseOfferingRepository) GetByCombinedClass(combinedClassID uint) ([]models.CourseOffering, error) {
+	var offerings []models.CourseOffering
+	err := r.db.Preload("SemesterOffering.Department").
+		Preload("StudentGroups").
+		Where("combined_class_id = ?", combinedClassID).
+		Order("id").
+		Find(&offerings).Error
+	return offerings, err
+}
+
+// GetByElectiveGroup returns the course offerings of an elective basket
+func (r *courseOfferingRepository) GetByElectiveGroup(electiveGroupID uint) ([]models.CourseOffering, error) {
+	var offerings []models.CourseOffering
+	err := r.db.Where("elective_group_id = ?", electiveGroupID).
+		Order("id").
+		Find(&offerings).Error
+	return offerings, err
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"icrogen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ElectiveGroupRepository interface for elective basket operations
+type ElectiveGroupRepository interface {
+	Create(group *models.ElectiveGroup, courseOfferingIDs []uint) error
+	GetByID(id uint) (*models.ElectiveGroup, error)
+	GetAll() ([]models.ElectiveGroup, error)
+	GetBySession(sessionID uint) ([]models.ElectiveGroup, error)
+	Update(group *models.ElectiveGroup, courseOfferingIDs []uint) error
+	Delete(id uint) error
+}
+
+type electiveGroupRepository struct {
+	db *gorm.DB
+}
+
+func NewElectiveGroupRepository(db *gorm.DB) ElectiveGroupRepository {
+	return &electiveGroupRepository{db: db}
+}
+
+// withCourseOfferings preloads the group's course offerings and what they are offered to
+func withCourseOfferings(db *gorm.DB) *gorm.DB {
+	return db.Preload("CourseOfferings", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).
+		Preload("CourseOfferings.Subject").
+		Preload("CourseOfferings.SemesterOffering").
+		Preload("CourseOfferings.StudentGroups")
+}
+
+// Create stores the group and moves the course offerings into it
+func (r *electiveGroupRepository) Create(group *models.ElectiveGroup, courseOfferingIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("CourseOfferings").Create(group).Error; err != nil {
+			return err
+		}
+		return linkCourseOfferings(tx, "elective_group_id", group.ID, courseOfferingIDs)
+	})
+}
+
+func (r *electiveGroupRepository) GetByID(id uint) (*models.ElectiveGroup, error) {
+	var group models.ElectiveGroup
+	err := withCourseOfferings(r.db).Preload("Session").First(&group, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *electiveGroupRepository) GetAll() ([]models.ElectiveGroup, error) {
+	var groups []models.ElectiveGroup
+	err := withCourseOfferings(r.db).Order("session_id, name").Find(&groups).Error
+	return groups, err
+}
+
+func (r *electiveGroupRepository) GetBySession(sessionID uint) ([]models.ElectiveGroup, error) {
+	var groups []models.ElectiveGroup
+	err := withCourseOfferings(r.db).Where("session_id = ?", sessionID).Order("name").Find(&groups).Error
+	return groups, err
+}
+
+// Update saves the group's name and description and replaces its course offerings
+func (r *electiveGroupRepository) Update(group *models.ElectiveGroup, courseOfferingIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ElectiveGroup{}).
+			Where("id = ?", group.ID).
+			Updates(map[string]interface{}{
+				"name":        group.Name,
+				"description": group.Description,
+			}).Error; err != nil {
+			return err
+		}
+		return linkCourseOfferings(tx, "elective_group_id", group.ID, courseOfferingIDs)
+	})
+}
+
+// Delete releases the group's course offerings and deletes it
+func (r *electiveGroupRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := linkCourseOfferings(tx, "elective_group_id", id, nil); err != nil {
+			return err
+		}
+		return tx.Delete(&models.ElectiveGroup{}, id).Error
+	})
+}
+
+// linkCourseOfferings makes the given course offerings, and only those, the
+// ones linked to id through column, e.g. the members of an elective group
+func linkCourseOfferings(tx *gorm.DB, column string, id uint, courseOfferingIDs []uint) error {
+	if err := tx.Model(&models.CourseOffering{}).
+		Where(column+" = ?", id).
+		Update(column, nil).Error; err != nil {
+		return err
+	}
+	if len(courseOfferingIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&models.CourseOffering{}).
+		Where("id IN ?", courseOfferingIDs).
+		Update(column, id).Error
+}
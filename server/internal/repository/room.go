@@ -60,16 +60,20 @@ func (r *roomRepository) GetByDepartmentID(departmentID uint) ([]models.Room, er
 
 func (r *roomRepository) Update(room *models.Room) error {
 	// Only update specific fields to avoid datetime issues
+	fields := map[string]interface{}{
+		"name":          room.Name,
+		"room_number":   room.RoomNumber,
+		"capacity":      room.Capacity,
+		"type":          room.Type,
+		"department_id": room.DepartmentID,
+		"is_active":     room.IsActive,
+	}
+	if room.Features != "" {
+		fields["features"] = room.Features
+	}
 	return r.db.Model(&models.Room{}).
 		Where("id = ?", room.ID).
-		Updates(map[string]interface{}{
-			"name":          room.Name,
-			"room_number":   room.RoomNumber,
-			"capacity":      room.Capacity,
-			"type":          room.Type,
-			"department_id": room.DepartmentID,
-			"is_active":     room.IsActive,
-		}).Error
+		Updates(fields).Error
 }
 
 func (r *roomRepository) Delete(id uint) error {
@@ -79,8 +83,8 @@ func (r *roomRepository) Delete(id uint) error {
 func (r *roomRepository) CheckAvailability(roomID uint, sessionID uint, dayOfWeek int, slotNumber int) (bool, error) {
 	var count int64
 	err := r.db.Model(&models.ScheduleEntry{}).
-		Where("room_id = ? AND session_id = ? AND day_of_week = ? AND slot_number = ?", 
-			roomID, sessionID, dayOfWeek, slotNumber).
+		Where("(room_id = ? OR JSON_CONTAINS(split_room_ids, CAST(? AS JSON))) AND session_id = ? AND day_of_week = ? AND slot_number = ?", 
+			roomID, roomID, sessionID, dayOfWeek, slotNumber).
 		Count(&count).Error
 	
 	if err != nil {
@@ -102,6 +106,12 @@ func (r *roomRepository) GetAvailableRooms(sessionID uint, dayOfWeek int, slotNu
 		query = query.Where("type = ?", roomType)
 	}
 	
-	err := query.Where("id NOT IN (?)", subQuery).Find(&rooms).Error
+	// Rooms a split group uses beside its main room are busy too
+	splitQuery := r.db.Model(&models.ScheduleEntry{}).
+		Select("1").
+		Where("session_id = ? AND day_of_week = ? AND slot_number = ?", sessionID, dayOfWeek, slotNumber).
+		Where("JSON_CONTAINS(split_room_ids, CAST(rooms.id AS JSON))")
+
+	err := query.Where("id NOT IN (?)", subQuery).Where("NOT EXISTS (?)", splitQuery).Find(&rooms).Error
 	return rooms, err
 }
\ No newline at end of file
@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"fmt"
 	"icrogen/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ScheduleRepository interface for schedule operations
@@ -12,17 +14,26 @@ type ScheduleRepository interface {
 	GetScheduleRunByID(id uint) (*models.ScheduleRun, error)
 	GetScheduleRunsBySemesterOffering(semesterOfferingID uint) ([]models.ScheduleRun, error)
 	UpdateScheduleRun(run *models.ScheduleRun) error
+	SaveGeneratedRuns(runs []GeneratedRun) error
 	
-	CreateScheduleBlock(block *models.ScheduleBlock) error
+	CreateSessionScheduleRun(run *models.SessionScheduleRun) error
+	GetSessionScheduleRunByID(id uint) (*models.SessionScheduleRun, error)
+	UpdateSessionScheduleRun(run *models.SessionScheduleRun) error
+
+	GetScheduleBlockByID(id uint) (*models.ScheduleBlock, error)
+	ReplaceScheduleBlocks(blocks []models.ScheduleBlock, entries []models.ScheduleEntry) error
+	SetScheduleBlockPinned(id uint, pinned bool) error
+	DeleteScheduleBlock(id uint) error
 	CreateScheduleEntry(entry *models.ScheduleEntry) error
 	CreateScheduleEntries(entries []models.ScheduleEntry) error
 	
 	GetScheduleEntriesByRun(scheduleRunID uint) ([]models.ScheduleEntry, error)
+	GetScheduleEntriesByBlock(blockID uint) ([]models.ScheduleEntry, error)
 	GetScheduleEntriesBySession(sessionID uint) ([]models.ScheduleEntry, error)
 	GetCommittedScheduleEntries(sessionID uint) ([]models.ScheduleEntry, error)
 	
 	DeleteScheduleEntriesByRun(scheduleRunID uint) error
-	CommitScheduleRun(scheduleRunID uint) error
+	CommitScheduleRun(scheduleRunID uint, validate CommitValidator) error
 	
 	CheckTeacherAvailability(teacherID uint, sessionID uint, dayOfWeek int, slotNumbers []int) (bool, error)
 	CheckRoomAvailability(roomID uint, sessionID uint, dayOfWeek int, slotNumbers []int) (bool, error)
@@ -68,8 +79,177 @@ func (r *scheduleRepository) UpdateScheduleRun(run *models.ScheduleRun) error {
 	return r.db.Save(run).Error
 }
 
-func (r *scheduleRepository) CreateScheduleBlock(block *models.ScheduleBlock) error {
-	return r.db.Create(block).Error
+// saveBatchSize is how many rows one INSERT of a generated run holds
+const saveBatchSize = 500
+
+// GeneratedRun is what a generation stores into one schedule run. Entries
+// refer to their schedule block through Block, which for a combined class may
+// be one of another run's blocks.
+type GeneratedRun struct {
+	Run     *models.ScheduleRun
+	Blocks  []*models.ScheduleBlock
+	Entries []models.ScheduleEntry
+}
+
+// SaveGeneratedRuns stores the blocks and entries of generated runs in batches
+// and saves the runs, all in one transaction. Blocks are inserted first so the
+// entries can be given their IDs; if anything fails nothing is stored.
+func (r *scheduleRepository) SaveGeneratedRuns(runs []GeneratedRun) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, run := range runs {
+			if len(run.Blocks) == 0 {
+				continue
+			}
+			if err := tx.Omit(clause.Associations).CreateInBatches(&run.Blocks, saveBatchSize).Error; err != nil {
+				return fmt.Errorf("failed to save schedule blocks of run %d: %w", run.Run.ID, err)
+			}
+		}
+
+		for _, run := range runs {
+			entries := make([]models.ScheduleEntry, len(run.Entries))
+			for i, entry := range run.Entries {
+				if entry.Block == nil {
+					return fmt.Errorf("schedule entry of run %d has no schedule block", run.Run.ID)
+				}
+				blockID := entry.Block.ID
+				entry.BlockID = &blockID
+				entry.Block = nil
+				entries[i] = entry
+			}
+			if len(entries) > 0 {
+				if err := tx.Omit(clause.Associations).CreateInBatches(&entries, saveBatchSize).Error; err != nil {
+					return fmt.Errorf("failed to save schedule entries of run %d: %w", run.Run.ID, err)
+				}
+			}
+			if err := tx.Omit(clause.Associations).Save(run.Run).Error; err != nil {
+				return fmt.Errorf("failed to update schedule run %d: %w", run.Run.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *scheduleRepository) CreateSessionScheduleRun(run *models.SessionScheduleRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *scheduleRepository) GetSessionScheduleRunByID(id uint) (*models.SessionScheduleRun, error) {
+	var run models.SessionScheduleRun
+	err := r.db.Preload("Session").
+		Preload("ScheduleRuns").
+		Preload("ScheduleRuns.SemesterOffering").
+		Preload("ScheduleRuns.SemesterOffering.Department").
+		First(&run, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *scheduleRepository) UpdateSessionScheduleRun(run *models.SessionScheduleRun) error {
+	return r.db.Save(run).Error
+}
+
+func (r *scheduleRepository) GetScheduleBlockByID(id uint) (*models.ScheduleBlock, error) {
+	var block models.ScheduleBlock
+	err := r.db.First(&block, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// lockDraftRuns locks the runs holding the blocks and their entries for the
+// rest of the transaction and checks they are all still drafts, so an edit
+// cannot land in a run being committed or cancelled
+func lockDraftRuns(tx *gorm.DB, blockIDs []uint) error {
+	var runIDs []uint
+	if err := tx.Model(&models.ScheduleBlock{}).Where("id IN ?", blockIDs).Pluck("schedule_run_id", &runIDs).Error; err != nil {
+		return err
+	}
+	var entryRunIDs []uint
+	if err := tx.Model(&models.ScheduleEntry{}).Where("block_id IN ?", blockIDs).Distinct().Pluck("schedule_run_id", &entryRunIDs).Error; err != nil {
+		return err
+	}
+	runIDs = append(runIDs, entryRunIDs...)
+	if len(runIDs) == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	var runs []models.ScheduleRun
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", runIDs).Order("id").Find(&runs).Error; err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if run.Status != "DRAFT" {
+			return fmt.Errorf("schedule run %d is %s; only draft runs can be edited", run.ID, run.Status)
+		}
+	}
+	return nil
+}
+
+// ReplaceScheduleBlocks saves the blocks' new slots, teachers and rooms and
+// replaces their entries with the given ones, all or nothing. The old entries
+// are removed for good so they cannot clash with the new ones in the unique
+// indexes.
+func (r *scheduleRepository) ReplaceScheduleBlocks(blocks []models.ScheduleBlock, entries []models.ScheduleEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		blockIDs := make([]uint, 0, len(blocks))
+		for _, block := range blocks {
+			blockIDs = append(blockIDs, block.ID)
+		}
+		if err := lockDraftRuns(tx, blockIDs); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("block_id IN ?", blockIDs).Delete(&models.ScheduleEntry{}).Error; err != nil {
+			return err
+		}
+
+		for _, block := range blocks {
+			var splitRoomIDs interface{}
+			if block.SplitRoomIDs != "" {
+				splitRoomIDs = block.SplitRoomIDs
+			}
+			if err := tx.Model(&models.ScheduleBlock{}).
+				Where("id = ?", block.ID).
+				Updates(map[string]interface{}{
+					"teacher_id":     block.TeacherID,
+					"room_id":        block.RoomID,
+					"split_room_ids": splitRoomIDs,
+					"day_of_week":    block.DayOfWeek,
+					"slot_start":     block.SlotStart,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.Create(&entries).Error
+	})
+}
+
+func (r *scheduleRepository) SetScheduleBlockPinned(id uint, pinned bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockDraftRuns(tx, []uint{id}); err != nil {
+			return err
+		}
+		return tx.Model(&models.ScheduleBlock{}).Where("id = ?", id).Update("is_pinned", pinned).Error
+	})
+}
+
+// DeleteScheduleBlock removes the block and, for good, its entries
+func (r *scheduleRepository) DeleteScheduleBlock(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockDraftRuns(tx, []uint{id}); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("block_id = ?", id).Delete(&models.ScheduleEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ScheduleBlock{}, id).Error
+	})
 }
 
 func (r *scheduleRepository) CreateScheduleEntry(entry *models.ScheduleEntry) error {
@@ -92,6 +272,14 @@ func (r *scheduleRepository) GetScheduleEntriesByRun(scheduleRunID uint) ([]mode
 	return entries, err
 }
 
+func (r *scheduleRepository) GetScheduleEntriesByBlock(blockID uint) ([]models.ScheduleEntry, error) {
+	var entries []models.ScheduleEntry
+	err := r.db.Where("block_id = ?", blockID).
+		Order("schedule_run_id, student_group_id, slot_number").
+		Find(&entries).Error
+	return entries, err
+}
+
 func (r *scheduleRepository) GetScheduleEntriesBySession(sessionID uint) ([]models.ScheduleEntry, error) {
 	var entries []models.ScheduleEntry
 	err := r.db.Preload("CourseOffering").
@@ -107,26 +295,160 @@ func (r *scheduleRepository) GetScheduleEntriesBySession(sessionID uint) ([]mode
 
 func (r *scheduleRepository) GetCommittedScheduleEntries(sessionID uint) ([]models.ScheduleEntry, error) {
 	var entries []models.ScheduleEntry
-	err := r.db.Joins("JOIN schedule_runs ON schedule_entries.schedule_run_id = schedule_runs.id").
-		Where("schedule_entries.session_id = ? AND schedule_runs.status = ?", sessionID, "COMMITTED").
-		Find(&entries).Error
+	err := committedEntries(r.db, sessionID).Find(&entries).Error
 	return entries, err
 }
 
+// committedEntries scopes a query to the entries committed in a session: those
+// holding committed_session_id, and the entries of committed runs that share a
+// combined class's teacher and room with its holder
+func committedEntries(db *gorm.DB, sessionID uint) *gorm.DB {
+	committedRuns := db.Session(&gorm.Session{NewDB: true}).Model(&models.ScheduleRun{}).
+		Select("id").
+		Where("status = ?", "COMMITTED")
+	return db.Where("committed_session_id = ? OR (session_id = ? AND combined_class_id <> 0 AND schedule_run_id IN (?))",
+		sessionID, sessionID, committedRuns)
+}
+
+// electCombinedHolders gives every combined class slot of a session's committed
+// runs one entry holding committed_session_id, so the class's shared teacher
+// and room count once in the unique indexes. Slots that already have a holder
+// keep it.
+func electCombinedHolders(tx *gorm.DB, sessionID uint) error {
+	return tx.Exec(`UPDATE schedule_entries SET committed_session_id = ? WHERE id IN (
+		SELECT id FROM (
+			SELECT MIN(e.id) AS id FROM schedule_entries e
+			JOIN schedule_runs r ON r.id = e.schedule_run_id
+			WHERE e.session_id = ? AND e.combined_class_id <> 0 AND r.status = 'COMMITTED' AND e.deleted_at IS NULL
+			GROUP BY e.combined_class_id, e.day_of_week, e.slot_number
+			HAVING COUNT(e.committed_session_id) = 0
+		) AS holders
+	)`, sessionID, sessionID).Error
+}
+
+// DeleteScheduleEntriesByRun removes the run's entries, first taking them out
+// of the committed entries so they no longer count in the unique indexes. A
+// combined class slot the run held passes to another committed group's entry.
 func (r *scheduleRepository) DeleteScheduleEntriesByRun(scheduleRunID uint) error {
-	return r.db.Where("schedule_run_id = ?", scheduleRunID).Delete(&models.ScheduleEntry{}).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var sessionIDs []uint
+		if err := tx.Model(&models.ScheduleEntry{}).
+			Where("schedule_run_id = ? AND committed_session_id IS NOT NULL", scheduleRunID).
+			Distinct().
+			Pluck("session_id", &sessionIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ScheduleEntry{}).
+			Where("schedule_run_id = ?", scheduleRunID).
+			Update("committed_session_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("schedule_run_id = ?", scheduleRunID).Delete(&models.ScheduleEntry{}).Error; err != nil {
+			return err
+		}
+		for _, sessionID := range sessionIDs {
+			if err := electCombinedHolders(tx, sessionID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (r *scheduleRepository) CommitScheduleRun(scheduleRunID uint) error {
+// CommitValidator checks a draft run's entries against the entries committed
+// in its session, not counting the runs it is about to supersede; an error
+// aborts the commit
+type CommitValidator func(run *models.ScheduleRun, entries []models.ScheduleEntry, committed []models.ScheduleEntry) error
+
+// CommitScheduleRun commits a draft run in one transaction. The session's row
+// is locked so commits in one session run one at a time, the run's entries are
+// validated against what is committed at that moment, and any run already
+// committed for the same semester offering is marked SUPERSEDED by this one.
+// The superseded entries leave the unique indexes and the run's entries enter
+// them, so the database refuses the commit if anything still clashes.
+func (r *scheduleRepository) CommitScheduleRun(scheduleRunID uint, validate CommitValidator) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Update schedule run status
+		var run models.ScheduleRun
+		if err := tx.First(&run, scheduleRunID).Error; err != nil {
+			return err
+		}
+		var offering models.SemesterOffering
+		if err := tx.First(&offering, run.SemesterOfferingID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Session{}, offering.SessionID).Error; err != nil {
+			return err
+		}
+
+		// Read the run again now that no other commit of the session can change it
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&run, scheduleRunID).Error; err != nil {
+			return err
+		}
+		if run.Status != "DRAFT" {
+			return fmt.Errorf("schedule run %d is %s; only draft schedule runs can be committed", run.ID, run.Status)
+		}
+
+		var superseded []uint
 		if err := tx.Model(&models.ScheduleRun{}).
-			Where("id = ?", scheduleRunID).
+			Where("semester_offering_id = ? AND status = ? AND id <> ?", run.SemesterOfferingID, "COMMITTED", run.ID).
+			Pluck("id", &superseded).Error; err != nil {
+			return err
+		}
+
+		committed := committedEntries(tx, offering.SessionID)
+		if len(superseded) > 0 {
+			committed = committed.Where("schedule_run_id NOT IN ?", superseded)
+		}
+		var committedEntries []models.ScheduleEntry
+		if err := committed.Find(&committedEntries).Error; err != nil {
+			return err
+		}
+		var entries []models.ScheduleEntry
+		if err := tx.Where("schedule_run_id = ?", run.ID).Find(&entries).Error; err != nil {
+			return err
+		}
+		if err := validate(&run, entries, committedEntries); err != nil {
+			return err
+		}
+
+		if len(superseded) > 0 {
+			if err := tx.Model(&models.ScheduleRun{}).
+				Where("id IN ?", superseded).
+				Updates(map[string]interface{}{
+					"status":               "SUPERSEDED",
+					"superseded_by_run_id": run.ID,
+				}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.ScheduleEntry{}).
+				Where("schedule_run_id IN ?", superseded).
+				Update("committed_session_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		// A combined class's entries share one teacher and room, so only the
+		// holder elected below enters the unique indexes for them
+		if err := tx.Model(&models.ScheduleEntry{}).
+			Where("schedule_run_id = ? AND combined_class_id = 0", run.ID).
+			Update("committed_session_id", offering.SessionID).Error; err != nil {
+			return fmt.Errorf("schedule run %d clashes with committed entries: %w", run.ID, err)
+		}
+
+		// Update schedule run status
+		result := tx.Model(&models.ScheduleRun{}).
+			Where("id = ? AND status = ?", run.ID, "DRAFT").
 			Updates(map[string]interface{}{
 				"status":       "COMMITTED",
 				"committed_at": gorm.Expr("NOW()"),
-			}).Error; err != nil {
-			return err
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("schedule run %d is no longer a draft", run.ID)
+		}
+		if err := electCombinedHolders(tx, offering.SessionID); err != nil {
+			return fmt.Errorf("schedule run %d clashes with committed entries: %w", run.ID, err)
 		}
 		return nil
 	})
@@ -151,8 +473,8 @@ func (r *scheduleRepository) CheckRoomAvailability(roomID uint, sessionID uint,
 	var count int64
 	err := r.db.Model(&models.ScheduleEntry{}).
 		Joins("JOIN schedule_runs ON schedule_entries.schedule_run_id = schedule_runs.id").
-		Where("schedule_entries.room_id = ? AND schedule_entries.session_id = ? AND schedule_entries.day_of_week = ? AND schedule_entries.slot_number IN ? AND schedule_runs.status = ?", 
-			roomID, sessionID, dayOfWeek, slotNumbers, "COMMITTED").
+		Where("(schedule_entries.room_id = ? OR JSON_CONTAINS(schedule_entries.split_room_ids, CAST(? AS JSON))) AND schedule_entries.session_id = ? AND schedule_entries.day_of_week = ? AND schedule_entries.slot_number IN ? AND schedule_runs.status = ?", 
+			roomID, roomID, sessionID, dayOfWeek, slotNumbers, "COMMITTED").
 		Count(&count).Error
 	
 	if err != nil {
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// ScheduleLockRepository takes named database advisory locks, shared by every
+// server process using the database
+type ScheduleLockRepository interface {
+	// TryLock takes the lock without waiting. acquired is false when another
+	// connection holds it; an error means the database cannot take advisory
+	// locks at all.
+	TryLock(ctx context.Context, name string) (unlock func() error, acquired bool, err error)
+}
+
+type scheduleLockRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduleLockRepository(db *gorm.DB) ScheduleLockRepository {
+	return &scheduleLockRepository{db: db}
+}
+
+// TryLock uses MySQL's GET_LOCK. The lock belongs to the connection that took
+// it, so that connection is kept out of the pool until the lock is released.
+func (r *scheduleLockRepository) TryLock(ctx context.Context, name string) (func() error, bool, error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", name).Scan(&result); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !result.Valid || result.Int64 != 1 {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", name)
+		return err
+	}
+	return unlock, true, nil
+}
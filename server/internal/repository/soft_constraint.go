@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"icrogen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SoftConstraintRepository interface for soft constraint operations
+type SoftConstraintRepository interface {
+	Create(constraint *models.SoftConstraint) error
+	GetByID(id uint) (*models.SoftConstraint, error)
+	GetAll() ([]models.SoftConstraint, error)
+	GetByProgrammeID(programmeID uint) ([]models.SoftConstraint, error)
+	GetByDepartmentID(departmentID uint) ([]models.SoftConstraint, error)
+	Update(constraint *models.SoftConstraint) error
+	Delete(id uint) error
+}
+
+type softConstraintRepository struct {
+	db *gorm.DB
+}
+
+func NewSoftConstraintRepository(db *gorm.DB) SoftConstraintRepository {
+	return &softConstraintRepository{db: db}
+}
+
+func (r *softConstraintRepository) Create(constraint *models.SoftConstraint) error {
+	return r.db.Create(constraint).Error
+}
+
+func (r *softConstraintRepository) GetByID(id uint) (*models.SoftConstraint, error) {
+	var constraint models.SoftConstraint
+	err := r.db.Preload("Programme").Preload("Department").First(&constraint, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &constraint, nil
+}
+
+func (r *softConstraintRepository) GetAll() ([]models.SoftConstraint, error) {
+	var constraints []models.SoftConstraint
+	err := r.db.Preload("Programme").Preload("Department").Order("type").Find(&constraints).Error
+	return constraints, err
+}
+
+// GetByProgrammeID returns the constraints set for a whole programme, not
+// those of its departments
+func (r *softConstraintRepository) GetByProgrammeID(programmeID uint) ([]models.SoftConstraint, error) {
+	var constraints []models.SoftConstraint
+	err := r.db.Where("programme_id = ? AND department_id IS NULL", programmeID).Order("type").Find(&constraints).Error
+	return constraints, err
+}
+
+func (r *softConstraintRepository) GetByDepartmentID(departmentID uint) ([]models.SoftConstraint, error) {
+	var constraints []models.SoftConstraint
+	err := r.db.Where("department_id = ?", departmentID).Order("type").Find(&constraints).Error
+	return constraints, err
+}
+
+func (r *softConstraintRepository) Update(constraint *models.SoftConstraint) error {
+	return r.db.Model(&models.SoftConstraint{}).
+		Where("id = ?", constraint.ID).
+		Updates(map[string]interface{}{
+			"type":          constraint.Type,
+			"weight":        constraint.Weight,
+			"params":        constraint.Params,
+			"programme_id":  constraint.ProgrammeID,
+			"department_id": constraint.DepartmentID,
+			"is_active":     constraint.IsActive,
+		}).Error
+}
+
+func (r *softConstraintRepository) Delete(id uint) error {
+	return r.db.Delete(&models.SoftConstraint{}, id).Error
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"icrogen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// StudentGroupRepository interface for section and batch operations
+type StudentGroupRepository interface {
+	Create(group *models.StudentGroup) error
+	GetByID(id uint) (*models.StudentGroup, error)
+	GetBySemesterOffering(semesterOfferingID uint) ([]models.StudentGroup, error)
+	Update(group *models.StudentGroup) error
+	Delete(id uint) error
+	CountBatches(id uint) (int64, error)
+	CountCourseOfferings(id uint) (int64, error)
+}
+
+type studentGroupRepository struct {
+	db *gorm.DB
+}
+
+func NewStudentGroupRepository(db *gorm.DB) StudentGroupRepository {
+	return &studentGroupRepository{db: db}
+}
+
+func (r *studentGroupRepository) Create(group *models.StudentGroup) error {
+	return r.db.Create(group).Error
+}
+
+func (r *studentGroupRepository) GetByID(id uint) (*models.StudentGroup, error) {
+	var group models.StudentGroup
+	err := r.db.Preload("Batches").First(&group, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// GetBySemesterOffering returns the offering's sections with their batches
+func (r *studentGroupRepository) GetBySemesterOffering(semesterOfferingID uint) ([]models.StudentGroup, error) {
+	var groups []models.StudentGroup
+	err := r.db.Preload("Batches", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name")
+	}).
+		Where("semester_offering_id = ? AND parent_id IS NULL", semesterOfferingID).
+		Order("name").
+		Find(&groups).Error
+	return groups, err
+}
+
+func (r *studentGroupRepository) Update(group *models.StudentGroup) error {
+	return r.db.Model(&models.StudentGroup{}).
+		Where("id = ?", group.ID).
+		Updates(map[string]interface{}{
+			"name":     group.Name,
+			"strength": group.Strength,
+		}).Error
+}
+
+func (r *studentGroupRepository) Delete(id uint) error {
+	return r.db.Delete(&models.StudentGroup{}, id).Error
+}
+
+// CountBatches counts the batches of a section
+func (r *studentGroupRepository) CountBatches(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.StudentGroup{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// CountCourseOfferings counts the course offerings taught to a group
+func (r *studentGroupRepository) CountCourseOfferings(id uint) (int64, error) {
+	var count int64
+	err := r.db.Table("course_offering_groups").
+		Joins("JOIN course_offerings ON course_offerings.id = course_offering_groups.course_offering_id").
+		Where("course_offering_groups.student_group_id = ? AND course_offerings.deleted_at IS NULL", id).
+		Count(&count).Error
+	return count, err
+}
@@ -70,18 +70,22 @@ func (r *subjectRepository) GetAll() ([]models.Subject, error) {
 
 func (r *subjectRepository) Update(subject *models.Subject) error {
 	// Only update specific fields to avoid datetime issues
+	fields := map[string]interface{}{
+		"code":                subject.Code,
+		"name":                subject.Name,
+		"credit":              subject.Credit,
+		"class_load_per_week": subject.ClassLoadPerWeek,
+		"programme_id":        subject.ProgrammeID,
+		"department_id":       subject.DepartmentID,
+		"subject_type_id":     subject.SubjectTypeID,
+		"is_active":           subject.IsActive,
+	}
+	if subject.RequiredFeatures != "" {
+		fields["required_features"] = subject.RequiredFeatures
+	}
 	return r.db.Model(&models.Subject{}).
 		Where("id = ?", subject.ID).
-		Updates(map[string]interface{}{
-			"code":                subject.Code,
-			"name":                subject.Name,
-			"credit":              subject.Credit,
-			"class_load_per_week": subject.ClassLoadPerWeek,
-			"programme_id":        subject.ProgrammeID,
-			"department_id":       subject.DepartmentID,
-			"subject_type_id":     subject.SubjectTypeID,
-			"is_active":           subject.IsActive,
-		}).Error
+		Updates(fields).Error
 }
 
 func (r *subjectRepository) Delete(id uint) error {
@@ -125,7 +129,11 @@ func (r *subjectTypeRepository) GetAll() ([]models.SubjectType, error) {
 }
 
 func (r *subjectTypeRepository) Update(subjectType *models.SubjectType) error {
-	return r.db.Save(subjectType).Error
+	db := r.db
+	if subjectType.RequiredFeatures == "" {
+		db = db.Omit("required_features")
+	}
+	return db.Save(subjectType).Error
 }
 
 func (r *subjectTypeRepository) Delete(id uint) error {
@@ -62,6 +62,7 @@ func (r *teacherRepository) Update(teacher *models.Teacher) error {
 	updates := map[string]interface{}{
 		"name":          teacher.Name,
 		"email":         teacher.Email,
+		"designation":   teacher.Designation,
 		"department_id": teacher.DepartmentID,
 		"is_active":     teacher.IsActive,
 	}
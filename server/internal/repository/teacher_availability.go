@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"icrogen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TeacherAvailabilityRepository interface for teacher availability operations
+type TeacherAvailabilityRepository interface {
+	Create(availability *models.TeacherAvailability) error
+	GetByID(id uint) (*models.TeacherAvailability, error)
+	GetByTeacherID(teacherID uint) ([]models.TeacherAvailability, error)
+	GetForSession(sessionID uint) ([]models.TeacherAvailability, error)
+	Update(availability *models.TeacherAvailability) error
+	Delete(id uint) error
+}
+
+type teacherAvailabilityRepository struct {
+	db *gorm.DB
+}
+
+func NewTeacherAvailabilityRepository(db *gorm.DB) TeacherAvailabilityRepository {
+	return &teacherAvailabilityRepository{db: db}
+}
+
+func (r *teacherAvailabilityRepository) Create(availability *models.TeacherAvailability) error {
+	return r.db.Create(availability).Error
+}
+
+func (r *teacherAvailabilityRepository) GetByID(id uint) (*models.TeacherAvailability, error) {
+	var availability models.TeacherAvailability
+	err := r.db.Preload("Session").First(&availability, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &availability, nil
+}
+
+// GetByTeacherID returns a teacher's weekly rows and the overrides of every session
+func (r *teacherAvailabilityRepository) GetByTeacherID(teacherID uint) ([]models.TeacherAvailability, error) {
+	var availability []models.TeacherAvailability
+	err := r.db.Preload("Session").
+		Where("teacher_id = ?", teacherID).
+		Order("session_id, day_of_week, slot_start").
+		Find(&availability).Error
+	return availability, err
+}
+
+// GetForSession returns every teacher's weekly rows and the overrides of one
+// session, weekly rows first
+func (r *teacherAvailabilityRepository) GetForSession(sessionID uint) ([]models.TeacherAvailability, error) {
+	var availability []models.TeacherAvailability
+	err := r.db.Where("session_id IS NULL OR session_id = ?", sessionID).
+		Order("session_id IS NOT NULL, teacher_id, day_of_week, slot_start").
+		Find(&availability).Error
+	return availability, err
+}
+
+func (r *teacherAvailabilityRepository) Update(availability *models.TeacherAvailability) error {
+	return r.db.Model(&models.TeacherAvailability{}).
+		Where("id = ?", availability.ID).
+		Updates(map[string]interface{}{
+			"session_id":  availability.SessionID,
+			"day_of_week": availability.DayOfWeek,
+			"slot_start":  availability.SlotStart,
+			"slot_end":    availability.SlotEnd,
+			"kind":        availability.Kind,
+			"reason":      availability.Reason,
+		}).Error
+}
+
+func (r *teacherAvailabilityRepository) Delete(id uint) error {
+	return r.db.Delete(&models.TeacherAvailability{}, id).Error
+}
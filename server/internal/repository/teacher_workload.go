@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"icrogen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TeacherWorkloadRepository interface for teacher workload limit operations
+type TeacherWorkloadRepository interface {
+	Create(limit *models.TeacherWorkloadLimit) error
+	GetByID(id uint) (*models.TeacherWorkloadLimit, error)
+	GetAll() ([]models.TeacherWorkloadLimit, error)
+	Update(limit *models.TeacherWorkloadLimit) error
+	Delete(id uint) error
+}
+
+type teacherWorkloadRepository struct {
+	db *gorm.DB
+}
+
+func NewTeacherWorkloadRepository(db *gorm.DB) TeacherWorkloadRepository {
+	return &teacherWorkloadRepository{db: db}
+}
+
+func (r *teacherWorkloadRepository) Create(limit *models.TeacherWorkloadLimit) error {
+	return r.db.Create(limit).Error
+}
+
+func (r *teacherWorkloadRepository) GetByID(id uint) (*models.TeacherWorkloadLimit, error) {
+	var limit models.TeacherWorkloadLimit
+	err := r.db.Preload("Teacher").First(&limit, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// GetAll returns the designation rows first, then the rows of single teachers
+func (r *teacherWorkloadRepository) GetAll() ([]models.TeacherWorkloadLimit, error) {
+	var limits []models.TeacherWorkloadLimit
+	err := r.db.Preload("Teacher").
+		Order("teacher_id IS NOT NULL, designation, teacher_id").
+		Find(&limits).Error
+	return limits, err
+}
+
+func (r *teacherWorkloadRepository) Update(limit *models.TeacherWorkloadLimit) error {
+	return r.db.Model(&models.TeacherWorkloadLimit{}).
+		Where("id = ?", limit.ID).
+		Updates(map[string]interface{}{
+			"teacher_id":      limit.TeacherID,
+			"designation":     limit.Designation,
+			"max_per_day":     limit.MaxPerDay,
+			"max_per_week":    limit.MaxPerWeek,
+			"max_consecutive": limit.MaxConsecutive,
+			"min_free_days":   limit.MinFreeDays,
+		}).Error
+}
+
+func (r *teacherWorkloadRepository) Delete(id uint) error {
+	return r.db.Delete(&models.TeacherWorkloadLimit{}, id).Error
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"icrogen/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TimeGridRepository interface for time grid and time slot operations
+type TimeGridRepository interface {
+	Create(grid *models.TimeGrid) error
+	GetByID(id uint) (*models.TimeGrid, error)
+	GetAll() ([]models.TimeGrid, error)
+	GetByProgrammeID(programmeID uint) (*models.TimeGrid, error)
+	Update(grid *models.TimeGrid) error
+	Delete(id uint) error
+	GetDefaultTimeSlots() ([]models.TimeSlot, error)
+}
+
+type timeGridRepository struct {
+	db *gorm.DB
+}
+
+func NewTimeGridRepository(db *gorm.DB) TimeGridRepository {
+	return &timeGridRepository{db: db}
+}
+
+func (r *timeGridRepository) Create(grid *models.TimeGrid) error {
+	return r.db.Create(grid).Error
+}
+
+func (r *timeGridRepository) GetByID(id uint) (*models.TimeGrid, error) {
+	var grid models.TimeGrid
+	err := r.db.Preload("Programme").
+		Preload("TimeSlots", func(db *gorm.DB) *gorm.DB {
+			return db.Order("day_of_week, slot_number")
+		}).
+		First(&grid, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &grid, nil
+}
+
+func (r *timeGridRepository) GetAll() ([]models.TimeGrid, error) {
+	var grids []models.TimeGrid
+	err := r.db.Preload("Programme").
+		Preload("TimeSlots", func(db *gorm.DB) *gorm.DB {
+			return db.Order("day_of_week, slot_number")
+		}).
+		Find(&grids).Error
+	return grids, err
+}
+
+func (r *timeGridRepository) GetByProgrammeID(programmeID uint) (*models.TimeGrid, error) {
+	var grid models.TimeGrid
+	err := r.db.Preload("TimeSlots", func(db *gorm.DB) *gorm.DB {
+		return db.Order("day_of_week, slot_number")
+	}).
+		Where("programme_id = ? AND is_active = ?", programmeID, true).
+		First(&grid).Error
+	if err != nil {
+		return nil, err
+	}
+	return &grid, nil
+}
+
+// Update saves the grid's fields and replaces all of its slots
+func (r *timeGridRepository) Update(grid *models.TimeGrid) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TimeGrid{}).
+			Where("id = ?", grid.ID).
+			Updates(map[string]interface{}{
+				"name":         grid.Name,
+				"description":  grid.Description,
+				"programme_id": grid.ProgrammeID,
+				"is_active":    grid.IsActive,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("time_grid_id = ?", grid.ID).Delete(&models.TimeSlot{}).Error; err != nil {
+			return err
+		}
+
+		for i := range grid.TimeSlots {
+			grid.TimeSlots[i].ID = 0
+			grid.TimeSlots[i].TimeGridID = &grid.ID
+		}
+		if len(grid.TimeSlots) > 0 {
+			return tx.Create(&grid.TimeSlots).Error
+		}
+		return nil
+	})
+}
+
+func (r *timeGridRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("time_grid_id = ?", id).Delete(&models.TimeSlot{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.TimeGrid{}, id).Error
+	})
+}
+
+// GetDefaultTimeSlots returns the slots that belong to no grid
+func (r *timeGridRepository) GetDefaultTimeSlots() ([]models.TimeSlot, error) {
+	var slots []models.TimeSlot
+	err := r.db.Where("time_grid_id IS NULL").Order("day_of_week, slot_number").Find(&slots).Error
+	return slots, err
+}
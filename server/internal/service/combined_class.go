@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"icrogen/internal/repository"
+	"strings"
+)
+
+// CombinedClassService interface for combined class business logic
+type CombinedClassService interface {
+	CreateCombinedClass(class *models.CombinedClass, courseOfferingIDs []uint) error
+	GetCombinedClassByID(id uint) (*models.CombinedClass, error)
+	GetAllCombinedClasses() ([]models.CombinedClass, error)
+	GetCombinedClassesBySession(sessionID uint) ([]models.CombinedClass, error)
+	UpdateCombinedClass(class *models.CombinedClass, courseOfferingIDs []uint) error
+	DeleteCombinedClass(id uint) error
+}
+
+type combinedClassService struct {
+	combinedClassRepo  repository.CombinedClassRepository
+	courseOfferingRepo repository.CourseOfferingRepository
+	sessionRepo        repository.SessionRepository
+}
+
+// NewCombinedClassService creates a new combined class service
+func NewCombinedClassService(
+	combinedClassRepo repository.CombinedClassRepository,
+	courseOfferingRepo repository.CourseOfferingRepository,
+	sessionRepo repository.SessionRepository,
+) CombinedClassService {
+	return &combinedClassService{
+		combinedClassRepo:  combinedClassRepo,
+		courseOfferingRepo: courseOfferingRepo,
+		sessionRepo:        sessionRepo,
+	}
+}
+
+func (s *combinedClassService) CreateCombinedClass(class *models.CombinedClass, courseOfferingIDs []uint) error {
+	if err := s.validateCombinedClass(class, courseOfferingIDs); err != nil {
+		return err
+	}
+	return s.combinedClassRepo.Create(class, courseOfferingIDs)
+}
+
+func (s *combinedClassService) GetCombinedClassByID(id uint) (*models.CombinedClass, error) {
+	if id == 0 {
+		return nil, errors.New("invalid combined class ID")
+	}
+	return s.combinedClassRepo.GetByID(id)
+}
+
+func (s *combinedClassService) GetAllCombinedClasses() ([]models.CombinedClass, error) {
+	return s.combinedClassRepo.GetAll()
+}
+
+func (s *combinedClassService) GetCombinedClassesBySession(sessionID uint) ([]models.CombinedClass, error) {
+	if sessionID == 0 {
+		return nil, errors.New("invalid session ID")
+	}
+	return s.combinedClassRepo.GetBySession(sessionID)
+}
+
+// UpdateCombinedClass renames the class and replaces its course offerings; its
+// session is fixed
+func (s *combinedClassService) UpdateCombinedClass(class *models.CombinedClass, courseOfferingIDs []uint) error {
+	if class.ID == 0 {
+		return errors.New("combined class ID is required for update")
+	}
+	existing, err := s.combinedClassRepo.GetByID(class.ID)
+	if err != nil {
+		return errors.New("combined class not found")
+	}
+	class.SessionID = existing.SessionID
+
+	if err := s.validateCombinedClass(class, courseOfferingIDs); err != nil {
+		return err
+	}
+	return s.combinedClassRepo.Update(class, courseOfferingIDs)
+}
+
+func (s *combinedClassService) DeleteCombinedClass(id uint) error {
+	if id == 0 {
+		return errors.New("invalid combined class ID")
+	}
+	return s.combinedClassRepo.Delete(id)
+}
+
+// validateCombinedClass checks that the course offerings can be taught as one
+// class: at least two, each of a different semester offering of the class's
+// session, in no elective group or other combined class, and lined up as
+// checkOfferingsAlign requires
+func (s *combinedClassService) validateCombinedClass(class *models.CombinedClass, courseOfferingIDs []uint) error {
+	class.Name = strings.TrimSpace(class.Name)
+	if class.Name == "" {
+		return errors.New("combined class name is required")
+	}
+	if _, err := s.sessionRepo.GetByID(class.SessionID); err != nil {
+		return errors.New("invalid session ID")
+	}
+	if len(courseOfferingIDs) < 2 {
+		return errors.New("a combined class needs at least two course offerings")
+	}
+
+	var offerings []models.CourseOffering
+	listed := make(map[uint]bool)
+	semesterOfferings := make(map[uint]uint)
+	for _, id := range courseOfferingIDs {
+		if listed[id] {
+			return fmt.Errorf("course offering %d is listed twice", id)
+		}
+		listed[id] = true
+
+		offering, err := s.courseOfferingRepo.GetByID(id)
+		if err != nil {
+			return fmt.Errorf("course offering %d not found", id)
+		}
+		if offering.SemesterOffering.SessionID != class.SessionID {
+			return fmt.Errorf("course offering %d is not offered in the class's session", id)
+		}
+		if other, exists := semesterOfferings[offering.SemesterOfferingID]; exists {
+			return fmt.Errorf("course offerings %d and %d belong to the same semester offering", other, id)
+		}
+		semesterOfferings[offering.SemesterOfferingID] = id
+		if offering.CombinedClassID != nil && *offering.CombinedClassID != class.ID {
+			return fmt.Errorf("course offering %d already belongs to combined class %d", id, *offering.CombinedClassID)
+		}
+		if offering.ElectiveGroupID != nil {
+			return fmt.Errorf("course offering %d belongs to elective group %d", id, *offering.ElectiveGroupID)
+		}
+		offerings = append(offerings, *offering)
+	}
+
+	return checkOfferingsAlign(offerings, "a combined class")
+}
@@ -1,8 +1,8 @@
 package service
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
 	"icrogen/internal/models"
 	"icrogen/internal/repository"
 )
@@ -23,6 +23,8 @@ type CourseOfferingService interface {
 	RemoveRoom(courseOfferingID uint, roomID uint) error
 	GetTeacherAssignments(courseOfferingID uint) ([]models.TeacherAssignment, error)
 	GetRoomAssignments(courseOfferingID uint) ([]models.RoomAssignment, error)
+	GetUnderCapacityRoomAssignments(semesterOfferingID uint) ([]RoomCapacityIssue, error)
+	SetStudentGroups(courseOfferingID uint, groupIDs []uint) (*models.CourseOffering, error)
 }
 
 type courseOfferingService struct {
@@ -30,6 +32,7 @@ type courseOfferingService struct {
 	subjectRepo        repository.SubjectRepository
 	teacherRepo        repository.TeacherRepository
 	roomRepo           repository.RoomRepository
+	studentGroupRepo   repository.StudentGroupRepository
 }
 
 func NewCourseOfferingService(
@@ -37,12 +40,14 @@ func NewCourseOfferingService(
 	subjectRepo repository.SubjectRepository,
 	teacherRepo repository.TeacherRepository,
 	roomRepo repository.RoomRepository,
+	studentGroupRepo repository.StudentGroupRepository,
 ) CourseOfferingService {
 	return &courseOfferingService{
 		courseOfferingRepo: courseOfferingRepo,
 		subjectRepo:        subjectRepo,
 		teacherRepo:        teacherRepo,
 		roomRepo:           roomRepo,
+		studentGroupRepo:   studentGroupRepo,
 	}
 }
 
@@ -57,6 +62,9 @@ func (s *courseOfferingService) CreateCourseOffering(offering *models.CourseOffe
 	if offering.WeeklyRequiredSlots <= 0 {
 		return errors.New("weekly required slots must be positive")
 	}
+	if err := validateRoomSplit(offering); err != nil {
+		return err
+	}
 
 	// Get subject to check if it's a lab
 	subject, err := s.subjectRepo.GetByID(offering.SubjectID)
@@ -64,41 +72,19 @@ func (s *courseOfferingService) CreateCourseOffering(offering *models.CourseOffe
 		return errors.New("invalid subject ID")
 	}
 
-	// Check if subject type is lab
-	if subject.SubjectType.IsLab {
-		offering.IsLab = true
-		// Labs typically need 3-hour consecutive slots
-		if offering.RequiredPattern == "" {
-			offering.RequiredPattern = `["3"]`
-		}
-	} else {
-		offering.IsLab = false
-		// For theory classes, set default pattern if not provided
-		if offering.RequiredPattern == "" {
-			offering.RequiredPattern = `["1"]`
-		}
-	}
-	
-	// Validate and format RequiredPattern as JSON
-	if offering.RequiredPattern != "" {
-		// Check if it's already valid JSON
-		var pattern []string
-		err := json.Unmarshal([]byte(offering.RequiredPattern), &pattern)
-		if err != nil {
-			// If not valid JSON, try to convert it to JSON array
-			// Support formats like "2+2" or "3"
-			patternArray := []string{offering.RequiredPattern}
-			jsonBytes, _ := json.Marshal(patternArray)
-			offering.RequiredPattern = string(jsonBytes)
-		}
+	if err := setRequiredPattern(offering, subject); err != nil {
+		return err
 	}
 
 	// Validate preferred room if provided
 	if offering.PreferredRoomID != nil {
-		_, err := s.roomRepo.GetByID(*offering.PreferredRoomID)
+		room, err := s.roomRepo.GetByID(*offering.PreferredRoomID)
 		if err != nil {
 			return errors.New("invalid preferred room ID")
 		}
+		if err := roomSuitsSubject(room, subject); err != nil {
+			return err
+		}
 	}
 
 	return s.courseOfferingRepo.Create(offering)
@@ -115,14 +101,27 @@ func (s *courseOfferingService) CreateCourseOfferingWithTeachers(offering *model
 		// Get subject to determine room type needed
 		subject, _ := s.subjectRepo.GetByID(offering.SubjectID)
 		
+		// Reload to learn the group size from the semester offering's department
+		created, err := s.courseOfferingRepo.GetByID(offering.ID)
+		if err != nil {
+			return fmt.Errorf("failed to reload course offering: %w", err)
+		}
+
 		// Determine required room type
 		roomType := "THEORY"
 		if subject.SubjectType.IsLab {
 			roomType = "LAB"
 		}
 		
-		// Get all available rooms of the required type
-		rooms, err := s.roomRepo.GetByType(roomType)
+		// Get all available rooms of the required type that have the features the
+		// subject needs and seat the group
+		candidates, err := s.roomRepo.GetByType(roomType)
+		var rooms []models.Room
+		for _, room := range candidates {
+			if roomSuitsSubject(&room, subject) == nil && roomSeatsGroup(&room, created, courseGroupSize(created)) == nil {
+				rooms = append(rooms, room)
+			}
+		}
 		if err == nil && len(rooms) > 0 {
 			// First try to find a room without department (free room)
 			for _, room := range rooms {
@@ -204,10 +203,42 @@ func (s *courseOfferingService) UpdateCourseOffering(offering *models.CourseOffe
 	if offering.WeeklyRequiredSlots <= 0 {
 		return errors.New("weekly required slots must be positive")
 	}
+	if err := validateRoomSplit(offering); err != nil {
+		return err
+	}
+
+	subject, err := s.subjectRepo.GetByID(offering.SubjectID)
+	if err != nil {
+		return errors.New("invalid subject ID")
+	}
+	if err := setRequiredPattern(offering, subject); err != nil {
+		return err
+	}
 
 	return s.courseOfferingRepo.Update(offering)
 }
 
+// setRequiredPattern takes the offering's lab flag from its subject's type and
+// validates its required pattern, or gives it the default alternatives when it
+// has none, e.g. ["3"] for a weekly 3-slot lab or ["2+2","2+1+1","1+1+1+1"]
+// for a 4-credit theory course. The pattern is stored in its normalised JSON
+// form, so "2+2" becomes ["2+2"].
+func setRequiredPattern(offering *models.CourseOffering, subject *models.Subject) error {
+	offering.IsLab = subject.SubjectType.IsLab
+	if offering.RequiredPattern == "" {
+		offering.RequiredPattern = EncodeRequiredPattern(
+			defaultPatternAlternatives(offering.WeeklyRequiredSlots, subject.Credit, offering.IsLab))
+	}
+
+	// Every alternative must add up to the weekly required slots
+	if err := ValidateRequiredPattern(offering.RequiredPattern, offering.WeeklyRequiredSlots, offering.IsLab); err != nil {
+		return fmt.Errorf("invalid required pattern: %w", err)
+	}
+	alternatives, _ := ParseRequiredPattern(offering.RequiredPattern)
+	offering.RequiredPattern = EncodeRequiredPattern(alternatives)
+	return nil
+}
+
 func (s *courseOfferingService) DeleteCourseOffering(id uint) error {
 	if id == 0 {
 		return errors.New("invalid course offering ID")
@@ -306,6 +337,15 @@ func (s *courseOfferingService) AssignRoom(assignment *models.RoomAssignment) er
 		return errors.New("theory subjects cannot use lab rooms")
 	}
 
+	// The room must have the features the subject needs and seat the group,
+	// unless the group may be split across rooms
+	if err := roomSuitsSubject(room, &courseOffering.Subject); err != nil {
+		return err
+	}
+	if err := roomSeatsGroup(room, courseOffering, s.classSize(courseOffering)); err != nil {
+		return err
+	}
+
 	return s.courseOfferingRepo.AssignRoom(assignment)
 }
 
@@ -348,4 +388,99 @@ func (s *courseOfferingService) GetRoomAssignments(courseOfferingID uint) ([]mod
 		return nil, errors.New("invalid course offering ID")
 	}
 	return s.courseOfferingRepo.GetRoomAssignments(courseOfferingID)
-}
\ No newline at end of file
+}
+// GetUnderCapacityRoomAssignments lists the room assignments whose room seats
+// fewer students than the group, for one semester offering or, given 0, all
+func (s *courseOfferingService) GetUnderCapacityRoomAssignments(semesterOfferingID uint) ([]RoomCapacityIssue, error) {
+	assignments, err := s.courseOfferingRepo.GetAllRoomAssignments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room assignments: %w", err)
+	}
+
+	issues := []RoomCapacityIssue{}
+	for _, assignment := range assignments {
+		offering := assignment.CourseOffering
+		if semesterOfferingID != 0 && offering.SemesterOfferingID != semesterOfferingID {
+			continue
+		}
+		size := s.classSize(&offering)
+		if assignment.Room.Capacity == 0 || size == 0 || assignment.Room.Capacity >= size {
+			continue
+		}
+		issues = append(issues, RoomCapacityIssue{
+			RoomAssignmentID:   assignment.ID,
+			CourseOfferingID:   offering.ID,
+			SemesterOfferingID: offering.SemesterOfferingID,
+			SubjectCode:        offering.Subject.Code,
+			RoomID:             assignment.RoomID,
+			RoomNumber:         assignment.Room.RoomNumber,
+			Capacity:           assignment.Room.Capacity,
+			GroupSize:          size,
+			MaxRoomSplit:       offering.MaxRoomSplit,
+		})
+	}
+	return issues, nil
+}
+
+// classSize is the number of students a course offering's classes seat: its
+// largest group's, or for a combined class the sum over its course offerings
+func (s *courseOfferingService) classSize(offering *models.CourseOffering) int {
+	if offering.CombinedClassID == nil {
+		return courseGroupSize(offering)
+	}
+	members, err := s.courseOfferingRepo.GetByCombinedClass(*offering.CombinedClassID)
+	if err != nil {
+		return courseGroupSize(offering)
+	}
+	size := 0
+	for i := range members {
+		size += courseGroupSize(&members[i])
+	}
+	return size
+}
+
+// SetStudentGroups sets the sections or batches a course offering is taught
+// to, each getting its own blocks; no groups means the whole semester
+// offering. A section cannot be listed together with one of its own batches.
+func (s *courseOfferingService) SetStudentGroups(courseOfferingID uint, groupIDs []uint) (*models.CourseOffering, error) {
+	offering, err := s.courseOfferingRepo.GetByID(courseOfferingID)
+	if err != nil {
+		return nil, errors.New("course offering not found")
+	}
+
+	groups := make([]models.StudentGroup, 0, len(groupIDs))
+	listed := make(map[uint]bool)
+	for _, groupID := range groupIDs {
+		if listed[groupID] {
+			return nil, fmt.Errorf("group %d is listed twice", groupID)
+		}
+		listed[groupID] = true
+
+		group, err := s.studentGroupRepo.GetByID(groupID)
+		if err != nil || group.SemesterOfferingID != offering.SemesterOfferingID {
+			return nil, fmt.Errorf("group %d does not belong to the course offering's semester offering", groupID)
+		}
+		groups = append(groups, *group)
+	}
+	for _, group := range groups {
+		if group.ParentID != nil && listed[*group.ParentID] {
+			return nil, fmt.Errorf("batch %s is already taught as part of its section", group.Name)
+		}
+	}
+
+	if err := s.courseOfferingRepo.ReplaceStudentGroups(offering, groups); err != nil {
+		return nil, fmt.Errorf("failed to set student groups: %w", err)
+	}
+	return s.courseOfferingRepo.GetByID(courseOfferingID)
+}
+
+// validateRoomSplit defaults the room split to a single room and caps it
+func validateRoomSplit(offering *models.CourseOffering) error {
+	if offering.MaxRoomSplit == 0 {
+		offering.MaxRoomSplit = 1
+	}
+	if offering.MaxRoomSplit < 1 || offering.MaxRoomSplit > maxRoomSplit {
+		return fmt.Errorf("max room split must be between 1 and %d", maxRoomSplit)
+	}
+	return nil
+}
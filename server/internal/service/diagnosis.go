@@ -0,0 +1,461 @@
+package service
+
+import (
+	"fmt"
+	"icrogen/internal/models"
+	"sort"
+	"strings"
+)
+
+// Causes that can stop a block from starting at a day/slot
+const (
+	BlockedGroupBusy   = "GROUP_BUSY"          // The students already have a class
+	BlockedTeacherBusy = "TEACHER_BUSY"        // Every candidate teacher is busy
+	BlockedTeacherOff  = "TEACHER_UNAVAILABLE" // Every candidate teacher has marked the slot unavailable
+	BlockedTeacherLoad = "TEACHER_WORKLOAD"    // The slot would take every candidate teacher past a workload limit
+	BlockedRoomTaken   = "ROOM_TAKEN"          // Every candidate room is taken
+	BlockedNoFeatures  = "MISSING_FEATURES"    // No candidate room has the features the subject needs
+	BlockedRoomSmall   = "ROOM_TOO_SMALL"      // No candidate room seats the group and it may not be split
+	BlockedDailyLimit  = "DAILY_LIMIT"         // The course already has its slots for the day
+	BlockedLabWindow   = "LAB_WINDOW"          // Labs may not start at this slot
+	BlockedOutsideGrid = "OUTSIDE_GRID"        // The block would run past the day's end or across a break
+)
+
+// maxSuggestedSlots caps the slots suggested for an unplaced block
+const maxSuggestedSlots = 5
+
+// blockedReasons is every cause with how suggestions describe it, in the
+// order they are summarised; a new cause must be listed here
+var blockedReasons = []struct {
+	reason string
+	text   string
+}{
+	{BlockedGroupBusy, "students already in class"},
+	{BlockedTeacherBusy, "teacher busy"},
+	{BlockedTeacherOff, "teacher unavailable"},
+	{BlockedTeacherLoad, "teacher workload limit reached"},
+	{BlockedRoomTaken, "room taken"},
+	{BlockedNoFeatures, "no room with the required features"},
+	{BlockedRoomSmall, "no room large enough"},
+	{BlockedDailyLimit, "per-day limit reached"},
+	{BlockedLabWindow, "outside the lab windows"},
+	{BlockedOutsideGrid, "does not fit the day's slots"},
+}
+
+// SlotConflict is one cause stopping a block from using a day/slot. Resource
+// clashes name the placement holding the resource.
+type SlotConflict struct {
+	Reason                   string `json:"reason"`
+	ResourceID               uint   `json:"resource_id,omitempty"` // Semester offering, teacher or room, by reason
+	HeldBySemesterOfferingID uint   `json:"held_by_semester_offering_id,omitempty"`
+	HeldByCourseOfferingID   uint   `json:"held_by_course_offering_id,omitempty"`
+	HeldByCommittedRun       bool   `json:"held_by_committed_run"` // Cannot be moved by regenerating this run
+	Message                  string `json:"message"`
+
+	holder string // Identifies the placement, so one placement counts as one move
+}
+
+// SlotDiagnosis explains what stops a block from starting at one day/slot,
+// using the teacher and room that would need the fewest other placements moved
+type SlotDiagnosis struct {
+	DayOfWeek   int            `json:"day_of_week"`
+	SlotStart   int            `json:"slot_start"`
+	SlotLength  int            `json:"slot_length"`
+	TeacherID   uint           `json:"teacher_id,omitempty"`
+	RoomID      uint           `json:"room_id,omitempty"`
+	MovesNeeded int            `json:"moves_needed"` // Placements to move to free the slot; -1 when no move can
+	Conflicts   []SlotConflict `json:"conflicts"`
+
+	committedMoves int
+}
+
+// slotHolder is a placement holding a resource at some slot
+type slotHolder struct {
+	semesterOfferingID uint
+	courseOfferingID   uint
+	committed          bool
+	key                string // Same for every slot of one placement
+}
+
+type holderSlot struct {
+	id   uint
+	day  int
+	slot int
+}
+
+type groupSlot struct {
+	group groupKey
+	day   int
+	slot  int
+}
+
+// slotHolders indexes who holds each group, teacher and room slot, both in the
+// timetables being built and in the committed entries of the session
+type slotHolders struct {
+	groups   map[groupSlot]slotHolder
+	teachers map[holderSlot]slotHolder
+	rooms    map[holderSlot]slotHolder
+	tree     *groupTree
+}
+
+func (s *routineGenerationService) newSlotHolders(state *generationState) *slotHolders {
+	holders := &slotHolders{
+		groups:   make(map[groupSlot]slotHolder),
+		teachers: make(map[holderSlot]slotHolder),
+		rooms:    make(map[holderSlot]slotHolder),
+		tree:     state.groups,
+	}
+
+	for _, entries := range state.index.committedGroups {
+		for _, entry := range entries {
+			holders.addEntry(entry, true)
+		}
+	}
+
+	for _, p := range s.collectPlacements(state) {
+		key := fmt.Sprintf("run-%d-%d-%d-%d-%d", p.block.SemesterOfferingID, p.block.StudentGroupID, p.block.CourseOfferingID, p.day, p.slot)
+		// Every part of an elective block is held by the one placement
+		for _, part := range blockParts(p.block) {
+			holder := slotHolder{part.SemesterOfferingID, part.CourseOfferingID, false, key}
+			for i := 0; i < part.DurationSlots; i++ {
+				holders.groups[groupSlot{blockGroup(part), p.day, p.slot + i}] = holder
+				holders.teachers[holderSlot{part.TeacherID, p.day, p.slot + i}] = holder
+				for _, roomID := range blockRooms(part) {
+					holders.rooms[holderSlot{roomID, p.day, p.slot + i}] = holder
+				}
+			}
+		}
+	}
+
+	return holders
+}
+
+// addEntry records the group, teacher and rooms a stored entry holds. The
+// entries of one schedule block are held by one placement.
+func (h *slotHolders) addEntry(entry *models.ScheduleEntry, committed bool) {
+	h.addEntryAs(entry, committed, entryHolderKey(entry))
+}
+
+// addEntryAs records an entry as held by the placement with the given key
+func (h *slotHolders) addEntryAs(entry *models.ScheduleEntry, committed bool, key string) {
+	holder := slotHolder{entry.SemesterOfferingID, entry.CourseOfferingID, committed, key}
+	group := groupKey{entry.SemesterOfferingID, entry.StudentGroupID}
+	h.groups[groupSlot{group, entry.DayOfWeek, entry.SlotNumber}] = holder
+	h.teachers[holderSlot{entry.TeacherID, entry.DayOfWeek, entry.SlotNumber}] = holder
+	for _, roomID := range append([]uint{entry.RoomID}, parseRoomIDs(entry.SplitRoomIDs)...) {
+		h.rooms[holderSlot{roomID, entry.DayOfWeek, entry.SlotNumber}] = holder
+	}
+}
+
+// entryHolderKey identifies the placement an entry belongs to: its block, or
+// the entry itself when it has none
+func entryHolderKey(entry *models.ScheduleEntry) string {
+	if entry.BlockID != nil {
+		return fmt.Sprintf("block-%d", *entry.BlockID)
+	}
+	return fmt.Sprintf("entry-%d", entry.ID)
+}
+
+// conflicts lists the holders of a resource over a span, once per placement
+func (h *slotHolders) conflicts(held map[holderSlot]slotHolder, reason string, resourceID uint, day int, startSlot int, length int) []SlotConflict {
+	var result []SlotConflict
+	seen := make(map[string]bool)
+	for i := 0; i < length; i++ {
+		holder, exists := held[holderSlot{resourceID, day, startSlot + i}]
+		if !exists || seen[holder.key] {
+			continue
+		}
+		seen[holder.key] = true
+		result = append(result, newSlotConflict(reason, resourceID, holder))
+	}
+	return result
+}
+
+// groupConflicts lists the holders of the group's slots over a span, and of
+// the slots of every group sharing students with it, once per placement
+func (h *slotHolders) groupConflicts(group groupKey, day int, startSlot int, length int) []SlotConflict {
+	var result []SlotConflict
+	seen := make(map[string]bool)
+	for _, related := range h.tree.related(group) {
+		for i := 0; i < length; i++ {
+			holder, exists := h.groups[groupSlot{related, day, startSlot + i}]
+			if !exists || seen[holder.key] {
+				continue
+			}
+			seen[holder.key] = true
+			result = append(result, newSlotConflict(BlockedGroupBusy, group.semesterOfferingID, holder))
+		}
+	}
+	return result
+}
+
+func newSlotConflict(reason string, resourceID uint, holder slotHolder) SlotConflict {
+	conflict := SlotConflict{
+		Reason:                   reason,
+		ResourceID:               resourceID,
+		HeldBySemesterOfferingID: holder.semesterOfferingID,
+		HeldByCourseOfferingID:   holder.courseOfferingID,
+		HeldByCommittedRun:       holder.committed,
+		holder:                   holder.key,
+	}
+
+	by := fmt.Sprintf("course offering %d of semester offering %d", holder.courseOfferingID, holder.semesterOfferingID)
+	if holder.committed {
+		by += " (committed)"
+	}
+	switch reason {
+	case BlockedGroupBusy:
+		conflict.Message = "students already in class with " + by
+	case BlockedTeacherBusy:
+		conflict.Message = fmt.Sprintf("teacher %d busy with %s", resourceID, by)
+	case BlockedRoomTaken:
+		conflict.Message = fmt.Sprintf("room %d taken by %s", resourceID, by)
+	case BlockedDailyLimit:
+		conflict.Message = fmt.Sprintf("per-day limit of %d slots already used by another block of this course", theoryMaxSlotsPerDay)
+	}
+	return conflict
+}
+
+// courseSlotsOnDay counts the slots the block's course offerings already use on
+// a day, in their own groups' timetables. Of an elective block or combined
+// class the busiest course offering counts.
+func courseSlotsOnDay(block models.ClassBlock, day int, state *generationState) int {
+	busiest := 0
+	for _, part := range blockParts(block) {
+		grid := state.grids[part.SemesterOfferingID]
+		timetable := state.timetables[blockGroup(part)]
+		if grid == nil || timetable == nil {
+			continue
+		}
+		count := 0
+		for _, slot := range grid.slots(day) {
+			slotInfo, exists := timetable[day][slot]
+			if !exists || !slotInfo.IsBooked || slotInfo.Block == nil {
+				continue
+			}
+			for _, booked := range blockParts(*slotInfo.Block) {
+				if booked.CourseOfferingID == part.CourseOfferingID {
+					count++
+					break
+				}
+			}
+		}
+		if count > busiest {
+			busiest = count
+		}
+	}
+	return busiest
+}
+
+// diagnoseBlock explains, for every day/slot of its grid, what stops the block
+// from starting there. Slots that only clash with this run's placements come
+// first, fewest moves first, and slots no move can free come last. An
+// elective block or combined class is diagnosed for its lowest course
+// offering, against the strength of every group a combined class seats.
+func (s *routineGenerationService) diagnoseBlock(block models.ClassBlock, state *generationState, holders *slotHolders) []SlotDiagnosis {
+	grid := state.grids[block.SemesterOfferingID]
+	timetable := state.timetables[blockGroup(block)]
+
+	teachers := candidatesOrSelf(block.TeacherCandidates, block.TeacherID)
+	assigned := candidatesOrSelf(block.RoomCandidates, block.RoomID)
+	var rooms []uint
+	for _, roomID := range append(append([]uint(nil), assigned...), state.rooms.fallback[block.IsLab]...) {
+		if !containsUint(rooms, roomID) && state.rooms.suits(block.CourseOfferingID, roomID) &&
+			(state.rooms.seats(block, roomID) || state.rooms.canSplit(block.CourseOfferingID, 1)) {
+			rooms = append(rooms, roomID)
+		}
+	}
+
+	// Without a suitable room no slot can be freed; say what the assigned rooms lack
+	var missing []SlotConflict
+	if len(rooms) == 0 {
+		for _, roomID := range assigned {
+			if roomID == 0 {
+				continue
+			}
+			if lacks := state.rooms.missing(block.CourseOfferingID, roomID); len(lacks) > 0 {
+				missing = append(missing, SlotConflict{
+					Reason:     BlockedNoFeatures,
+					ResourceID: roomID,
+					Message:    fmt.Sprintf("room %d lacks %s", roomID, strings.Join(lacks, ", ")),
+				})
+				continue
+			}
+			missing = append(missing, SlotConflict{
+				Reason:     BlockedRoomSmall,
+				ResourceID: roomID,
+				Message: fmt.Sprintf("room %d seats %d but the group has %d students",
+					roomID, state.rooms.capacity[roomID], state.rooms.blockSize(block)),
+			})
+		}
+		if len(missing) == 0 {
+			missing = []SlotConflict{{Reason: BlockedNoFeatures, Message: "no active room has the features and seats the course needs"}}
+		}
+	}
+
+	var diagnoses []SlotDiagnosis
+	for _, day := range grid.days {
+		for _, slot := range grid.slots(day) {
+			diagnosis := SlotDiagnosis{DayOfWeek: day, SlotStart: slot, SlotLength: block.DurationSlots}
+
+			switch {
+			case !grid.fits(day, slot, block.DurationSlots):
+				diagnosis.MovesNeeded = -1
+				diagnosis.Conflicts = []SlotConflict{{Reason: BlockedOutsideGrid, Message: "the block would run past the day's last slot or across a break"}}
+			case block.IsLab && !grid.allowsLabStart(day, slot):
+				diagnosis.MovesNeeded = -1
+				diagnosis.Conflicts = []SlotConflict{{Reason: BlockedLabWindow, Message: "labs may only start in the grid's lab windows"}}
+			case len(rooms) == 0:
+				diagnosis.MovesNeeded = -1
+				diagnosis.Conflicts = missing
+			default:
+				s.diagnoseSlot(&diagnosis, block, teachers, rooms, grid, timetable, state, holders)
+			}
+
+			diagnoses = append(diagnoses, diagnosis)
+		}
+	}
+
+	sort.SliceStable(diagnoses, func(i, j int) bool {
+		a, b := diagnoses[i], diagnoses[j]
+		if (a.MovesNeeded < 0) != (b.MovesNeeded < 0) {
+			return b.MovesNeeded < 0
+		}
+		if a.committedMoves != b.committedMoves {
+			return a.committedMoves < b.committedMoves
+		}
+		return a.MovesNeeded < b.MovesNeeded
+	})
+
+	return diagnoses
+}
+
+// diagnoseSlot fills in the resource and per-day conflicts of a slot the block
+// fits in, choosing the teacher and room that clash with the fewest placements
+func (s *routineGenerationService) diagnoseSlot(diagnosis *SlotDiagnosis, block models.ClassBlock, teachers []uint, rooms []uint, grid *timeGrid, timetable models.Timetable, state *generationState, holders *slotHolders) {
+	day, slot, length := diagnosis.DayOfWeek, diagnosis.SlotStart, block.DurationSlots
+
+	base := holders.groupConflicts(blockGroup(block), day, slot, length)
+
+	if !block.IsLab && courseSlotsOnDay(block, day, state)+length > theoryMaxSlotsPerDay {
+		seen := make(map[string]bool)
+		for _, part := range blockParts(block) {
+			partGrid := state.grids[part.SemesterOfferingID]
+			if partGrid == nil {
+				continue
+			}
+			for _, daySlot := range partGrid.slots(day) {
+				holder, exists := holders.groups[groupSlot{blockGroup(part), day, daySlot}]
+				if exists && !holder.committed && holder.courseOfferingID == part.CourseOfferingID && !seen[holder.key] {
+					seen[holder.key] = true
+					base = append(base, newSlotConflict(BlockedDailyLimit, part.CourseOfferingID, holder))
+				}
+			}
+		}
+	}
+
+	best := -1
+	var unavailable []SlotConflict
+	for _, teacherID := range teachers {
+		if state.availability.blocks(teacherID, day, slot, length) {
+			unavailable = append(unavailable, SlotConflict{
+				Reason:     BlockedTeacherOff,
+				ResourceID: teacherID,
+				Message:    fmt.Sprintf("teacher %d is marked unavailable", teacherID),
+			})
+			continue
+		}
+		if !state.workload.allows(teacherID, state.index.teachers[teacherID], grid, day, slot, length) {
+			unavailable = append(unavailable, SlotConflict{
+				Reason:     BlockedTeacherLoad,
+				ResourceID: teacherID,
+				Message:    fmt.Sprintf("teacher %d would exceed their workload limits", teacherID),
+			})
+			continue
+		}
+		teacherConflicts := holders.conflicts(holders.teachers, BlockedTeacherBusy, teacherID, day, slot, length)
+		for _, roomID := range rooms {
+			conflicts := append(append(append([]SlotConflict(nil), base...), teacherConflicts...),
+				holders.conflicts(holders.rooms, BlockedRoomTaken, roomID, day, slot, length)...)
+			moves, committed := countMoves(conflicts)
+			if best < 0 || committed < diagnosis.committedMoves || (committed == diagnosis.committedMoves && moves < best) {
+				best = moves
+				diagnosis.TeacherID = teacherID
+				diagnosis.RoomID = roomID
+				diagnosis.MovesNeeded = moves
+				diagnosis.committedMoves = committed
+				diagnosis.Conflicts = conflicts
+			}
+		}
+	}
+
+	// No move frees a slot every candidate teacher is unavailable or too
+	// loaded for
+	if best < 0 {
+		diagnosis.MovesNeeded = -1
+		diagnosis.Conflicts = append(base, unavailable...)
+	}
+
+	if diagnosis.Conflicts == nil {
+		diagnosis.Conflicts = []SlotConflict{}
+	}
+}
+
+// countMoves counts the distinct placements behind a set of conflicts, and
+// how many of them belong to committed runs
+func countMoves(conflicts []SlotConflict) (int, int) {
+	seen := make(map[string]bool)
+	moves, committed := 0, 0
+	for _, conflict := range conflicts {
+		if conflict.holder == "" || seen[conflict.holder] {
+			continue
+		}
+		seen[conflict.holder] = true
+		moves++
+		if conflict.HeldByCommittedRun {
+			committed++
+		}
+	}
+	return moves, committed
+}
+
+// suggestPlacement turns the diagnosis of an unplaced block into the slots
+// that need the fewest moves and a count of what blocks the candidate slots
+func suggestPlacement(block models.ClassBlock, diagnoses []SlotDiagnosis) PlacementSuggestion {
+	suggestion := PlacementSuggestion{
+		Block:           block,
+		SuggestedSlots:  []TimeSlot{},
+		ConflictReasons: []string{},
+		Diagnosis:       diagnoses,
+	}
+
+	for _, diagnosis := range diagnoses {
+		if len(suggestion.SuggestedSlots) == maxSuggestedSlots || diagnosis.MovesNeeded < 0 {
+			break
+		}
+		suggestion.SuggestedSlots = append(suggestion.SuggestedSlots, TimeSlot{
+			DayOfWeek:  diagnosis.DayOfWeek,
+			SlotStart:  diagnosis.SlotStart,
+			SlotLength: diagnosis.SlotLength,
+		})
+	}
+
+	counts := make(map[string]int)
+	for _, diagnosis := range diagnoses {
+		reasons := make(map[string]bool)
+		for _, conflict := range diagnosis.Conflicts {
+			reasons[conflict.Reason] = true
+		}
+		for reason := range reasons {
+			counts[reason]++
+		}
+	}
+	for _, blocked := range blockedReasons {
+		if counts[blocked.reason] > 0 {
+			suggestion.ConflictReasons = append(suggestion.ConflictReasons,
+				fmt.Sprintf("%s in %d of %d slots", blocked.text, counts[blocked.reason], len(diagnoses)))
+		}
+	}
+
+	return suggestion
+}
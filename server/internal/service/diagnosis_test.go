@@ -0,0 +1,201 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"icrogen/internal/models"
+)
+
+// diagnosisFixture sets up semester offering 1 of session 1 with 60 students,
+// course offering 10 taught by teacher 5 in room 20, and committed entries of
+// semester offering 2: teacher 5 on Monday's first slot and room 20 on
+// Tuesday's third. Teacher 5 may teach one period a day.
+func diagnosisFixture(roomCapacity int) (*routineGenerationService, *generationState, *slotHolders) {
+	offering := models.SemesterOffering{ID: 1, SessionID: 1, Department: models.Department{Strength: 60}}
+	offering.CourseOfferings = []models.CourseOffering{{ID: 10, SemesterOfferingID: 1, MaxRoomSplit: 1, SemesterOffering: offering}}
+	offerings := []models.SemesterOffering{offering}
+	teachers := []models.Teacher{{ID: 5, Name: "Teacher 5"}, {ID: 6, Name: "Teacher 6"}}
+	rooms := []models.Room{{ID: 20, Capacity: roomCapacity, Type: "THEORY", IsActive: true}}
+
+	service := newFakeGenerationService(newFakeScheduleRepo(), offerings, teachers, rooms).(*routineGenerationService)
+	teacherID := uint(5)
+	workload := newTeacherWorkload(teachers, []models.TeacherWorkloadLimit{{TeacherID: &teacherID, MaxPerDay: 1}})
+	committed := []models.ScheduleEntry{
+		{ID: 1, SemesterOfferingID: 2, SessionID: 1, CourseOfferingID: 30, TeacherID: 5, RoomID: 21, DayOfWeek: 1, SlotNumber: 1},
+		{ID: 2, SemesterOfferingID: 2, SessionID: 1, CourseOfferingID: 31, TeacherID: 6, RoomID: 20, DayOfWeek: 2, SlotNumber: 3},
+	}
+
+	state := service.newGenerationState(1,
+		map[uint]*timeGrid{1: defaultTimeGrid()},
+		map[uint]*softConstraintSet{1: defaultSoftConstraints()},
+		nil, workload, committed, newGroupTree(offerings), service.loadRoomCatalog(offerings))
+	return service, state, service.newSlotHolders(state)
+}
+
+func diagnosisAt(t *testing.T, diagnoses []SlotDiagnosis, day int, slot int) SlotDiagnosis {
+	t.Helper()
+	for _, diagnosis := range diagnoses {
+		if diagnosis.DayOfWeek == day && diagnosis.SlotStart == slot {
+			return diagnosis
+		}
+	}
+	t.Fatalf("no diagnosis for day %d slot %d", day, slot)
+	return SlotDiagnosis{}
+}
+
+func conflictReasons(diagnosis SlotDiagnosis) []string {
+	reasons := []string{}
+	for _, conflict := range diagnosis.Conflicts {
+		reasons = append(reasons, conflict.Reason)
+	}
+	return reasons
+}
+
+func TestDiagnoseBlock(t *testing.T) {
+	service, state, holders := diagnosisFixture(70)
+	theory := models.ClassBlock{SemesterOfferingID: 1, CourseOfferingID: 10, TeacherID: 5, RoomID: 20, DurationSlots: 1}
+	lab := models.ClassBlock{SemesterOfferingID: 1, CourseOfferingID: 10, TeacherID: 6, RoomID: 20, DurationSlots: 3, IsLab: true}
+
+	tests := []struct {
+		name        string
+		block       models.ClassBlock
+		day, slot   int
+		wantMoves   int
+		wantReasons []string
+	}{
+		{name: "free slot", block: theory, day: 3, slot: 1, wantMoves: 0, wantReasons: []string{}},
+		{name: "teacher busy in a committed run", block: theory, day: 1, slot: 1, wantMoves: 1, wantReasons: []string{BlockedTeacherBusy}},
+		{name: "teacher at the day's limit", block: theory, day: 1, slot: 2, wantMoves: -1, wantReasons: []string{BlockedTeacherLoad}},
+		{name: "room taken in a committed run", block: theory, day: 2, slot: 3, wantMoves: 1, wantReasons: []string{BlockedRoomTaken}},
+		{name: "lab outside the lab windows", block: lab, day: 3, slot: 1, wantMoves: -1, wantReasons: []string{BlockedLabWindow}},
+		{name: "lab across lunch", block: lab, day: 3, slot: 3, wantMoves: -1, wantReasons: []string{BlockedOutsideGrid}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnosis := diagnosisAt(t, service.diagnoseBlock(tt.block, state, holders), tt.day, tt.slot)
+			if diagnosis.MovesNeeded != tt.wantMoves {
+				t.Errorf("moves needed = %d, want %d", diagnosis.MovesNeeded, tt.wantMoves)
+			}
+			if got := conflictReasons(diagnosis); !reflect.DeepEqual(got, tt.wantReasons) {
+				t.Errorf("conflicts = %v, want %v", got, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestDiagnoseBlockOrder(t *testing.T) {
+	service, state, holders := diagnosisFixture(70)
+	block := models.ClassBlock{SemesterOfferingID: 1, CourseOfferingID: 10, TeacherID: 5, RoomID: 20, DurationSlots: 1}
+
+	diagnoses := service.diagnoseBlock(block, state, holders)
+	if len(diagnoses) != 35 {
+		t.Fatalf("%d diagnoses, want one per slot of the week", len(diagnoses))
+	}
+	// Free slots first, then those held by committed runs, then those no move frees
+	for i, diagnosis := range diagnoses {
+		want := 0
+		switch {
+		case i >= 29:
+			want = -1
+		case i >= 27:
+			want = 1
+		}
+		if diagnosis.MovesNeeded != want {
+			t.Errorf("diagnosis %d (day %d slot %d) needs %d moves, want %d", i, diagnosis.DayOfWeek, diagnosis.SlotStart, diagnosis.MovesNeeded, want)
+		}
+	}
+	if first := diagnoses[0]; first.DayOfWeek != 2 || first.SlotStart != 1 || first.TeacherID != 5 || first.RoomID != 20 {
+		t.Errorf("best slot is %+v, want Tuesday's first with teacher 5 in room 20", first)
+	}
+
+	busy := diagnosisAt(t, diagnoses, 1, 1).Conflicts[0]
+	if busy.ResourceID != 5 || !busy.HeldByCommittedRun || busy.HeldBySemesterOfferingID != 2 || busy.HeldByCourseOfferingID != 30 {
+		t.Errorf("teacher conflict %+v, want teacher 5 held by committed course offering 30 of semester offering 2", busy)
+	}
+}
+
+func TestDiagnoseBlockWithoutRoom(t *testing.T) {
+	service, state, holders := diagnosisFixture(30)
+	block := models.ClassBlock{SemesterOfferingID: 1, CourseOfferingID: 10, TeacherID: 5, RoomID: 20, DurationSlots: 1}
+
+	for _, diagnosis := range service.diagnoseBlock(block, state, holders) {
+		if diagnosis.MovesNeeded != -1 || !reflect.DeepEqual(conflictReasons(diagnosis), []string{BlockedRoomSmall}) {
+			t.Fatalf("day %d slot %d: %d moves for %v, want no move for %s", diagnosis.DayOfWeek, diagnosis.SlotStart,
+				diagnosis.MovesNeeded, conflictReasons(diagnosis), BlockedRoomSmall)
+		}
+	}
+}
+
+func TestSuggestPlacement(t *testing.T) {
+	free := func(day, slot int) SlotDiagnosis {
+		return SlotDiagnosis{DayOfWeek: day, SlotStart: slot, SlotLength: 1, Conflicts: []SlotConflict{}}
+	}
+	blocked := func(day, slot int, moves int, reasons ...string) SlotDiagnosis {
+		diagnosis := SlotDiagnosis{DayOfWeek: day, SlotStart: slot, SlotLength: 1, MovesNeeded: moves}
+		for _, reason := range reasons {
+			diagnosis.Conflicts = append(diagnosis.Conflicts, SlotConflict{Reason: reason})
+		}
+		return diagnosis
+	}
+
+	tests := []struct {
+		name        string
+		diagnoses   []SlotDiagnosis
+		wantSlots   int
+		wantReasons []string
+	}{
+		{
+			name:      "capped at the best five",
+			diagnoses: []SlotDiagnosis{free(1, 1), free(1, 2), free(1, 3), free(1, 4), free(1, 5), free(1, 6)},
+			wantSlots: 5, wantReasons: []string{},
+		},
+		{
+			name: "stops at slots no move frees",
+			diagnoses: []SlotDiagnosis{
+				blocked(1, 1, 1, BlockedRoomTaken, BlockedTeacherBusy),
+				blocked(1, 2, -1, BlockedTeacherLoad),
+				blocked(1, 3, 1, BlockedGroupBusy),
+			},
+			wantSlots: 1,
+			wantReasons: []string{
+				"students already in class in 1 of 3 slots",
+				"teacher busy in 1 of 3 slots",
+				"teacher workload limit reached in 1 of 3 slots",
+				"room taken in 1 of 3 slots",
+			},
+		},
+		{
+			name:      "each cause counted once per slot",
+			diagnoses: []SlotDiagnosis{blocked(1, 1, -1, BlockedOutsideGrid, BlockedOutsideGrid)},
+			wantSlots: 0, wantReasons: []string{"does not fit the day's slots in 1 of 1 slots"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestion := suggestPlacement(models.ClassBlock{}, tt.diagnoses)
+			if len(suggestion.SuggestedSlots) != tt.wantSlots {
+				t.Errorf("%d suggested slots, want %d", len(suggestion.SuggestedSlots), tt.wantSlots)
+			}
+			if !reflect.DeepEqual(suggestion.ConflictReasons, tt.wantReasons) {
+				t.Errorf("conflict reasons = %q, want %q", suggestion.ConflictReasons, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestEveryBlockedReasonIsSummarised(t *testing.T) {
+	reasons := []string{
+		BlockedGroupBusy, BlockedTeacherBusy, BlockedTeacherOff, BlockedTeacherLoad, BlockedRoomTaken,
+		BlockedNoFeatures, BlockedRoomSmall, BlockedDailyLimit, BlockedLabWindow, BlockedOutsideGrid,
+	}
+	for _, reason := range reasons {
+		summarised := false
+		for _, blocked := range blockedReasons {
+			summarised = summarised || (blocked.reason == reason && blocked.text != "")
+		}
+		if !summarised {
+			t.Errorf("%s has no text in blockedReasons", reason)
+		}
+	}
+}
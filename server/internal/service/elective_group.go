@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"icrogen/internal/repository"
+	"strings"
+)
+
+// ElectiveGroupService interface for elective basket business logic
+type ElectiveGroupService interface {
+	CreateElectiveGroup(group *models.ElectiveGroup, courseOfferingIDs []uint) error
+	GetElectiveGroupByID(id uint) (*models.ElectiveGroup, error)
+	GetAllElectiveGroups() ([]models.ElectiveGroup, error)
+	GetElectiveGroupsBySession(sessionID uint) ([]models.ElectiveGroup, error)
+	UpdateElectiveGroup(group *models.ElectiveGroup, courseOfferingIDs []uint) error
+	DeleteElectiveGroup(id uint) error
+}
+
+type electiveGroupService struct {
+	electiveGroupRepo  repository.ElectiveGroupRepository
+	courseOfferingRepo repository.CourseOfferingRepository
+	sessionRepo        repository.SessionRepository
+}
+
+// NewElectiveGroupService creates a new elective group service
+func NewElectiveGroupService(
+	electiveGroupRepo repository.ElectiveGroupRepository,
+	courseOfferingRepo repository.CourseOfferingRepository,
+	sessionRepo repository.SessionRepository,
+) ElectiveGroupService {
+	return &electiveGroupService{
+		electiveGroupRepo:  electiveGroupRepo,
+		courseOfferingRepo: courseOfferingRepo,
+		sessionRepo:        sessionRepo,
+	}
+}
+
+func (s *electiveGroupService) CreateElectiveGroup(group *models.ElectiveGroup, courseOfferingIDs []uint) error {
+	if err := s.validateElectiveGroup(group, courseOfferingIDs); err != nil {
+		return err
+	}
+	return s.electiveGroupRepo.Create(group, courseOfferingIDs)
+}
+
+func (s *electiveGroupService) GetElectiveGroupByID(id uint) (*models.ElectiveGroup, error) {
+	if id == 0 {
+		return nil, errors.New("invalid elective group ID")
+	}
+	return s.electiveGroupRepo.GetByID(id)
+}
+
+func (s *electiveGroupService) GetAllElectiveGroups() ([]models.ElectiveGroup, error) {
+	return s.electiveGroupRepo.GetAll()
+}
+
+func (s *electiveGroupService) GetElectiveGroupsBySession(sessionID uint) ([]models.ElectiveGroup, error) {
+	if sessionID == 0 {
+		return nil, errors.New("invalid session ID")
+	}
+	return s.electiveGroupRepo.GetBySession(sessionID)
+}
+
+// UpdateElectiveGroup renames the group and replaces its course offerings; its
+// session is fixed
+func (s *electiveGroupService) UpdateElectiveGroup(group *models.ElectiveGroup, courseOfferingIDs []uint) error {
+	if group.ID == 0 {
+		return errors.New("elective group ID is required for update")
+	}
+	existing, err := s.electiveGroupRepo.GetByID(group.ID)
+	if err != nil {
+		return errors.New("elective group not found")
+	}
+	group.SessionID = existing.SessionID
+
+	if err := s.validateElectiveGroup(group, courseOfferingIDs); err != nil {
+		return err
+	}
+	return s.electiveGroupRepo.Update(group, courseOfferingIDs)
+}
+
+func (s *electiveGroupService) DeleteElectiveGroup(id uint) error {
+	if id == 0 {
+		return errors.New("invalid elective group ID")
+	}
+	return s.electiveGroupRepo.Delete(id)
+}
+
+// validateElectiveGroup checks that the course offerings can meet together:
+// at least two, all in the group's session and no other basket or combined
+// class, and lined up as checkOfferingsAlign requires
+func (s *electiveGroupService) validateElectiveGroup(group *models.ElectiveGroup, courseOfferingIDs []uint) error {
+	group.Name = strings.TrimSpace(group.Name)
+	if group.Name == "" {
+		return errors.New("elective group name is required")
+	}
+	if _, err := s.sessionRepo.GetByID(group.SessionID); err != nil {
+		return errors.New("invalid session ID")
+	}
+	if len(courseOfferingIDs) < 2 {
+		return errors.New("an elective group needs at least two course offerings")
+	}
+
+	var offerings []models.CourseOffering
+	listed := make(map[uint]bool)
+	for _, id := range courseOfferingIDs {
+		if listed[id] {
+			return fmt.Errorf("course offering %d is listed twice", id)
+		}
+		listed[id] = true
+
+		offering, err := s.courseOfferingRepo.GetByID(id)
+		if err != nil {
+			return fmt.Errorf("course offering %d not found", id)
+		}
+		if offering.SemesterOffering.SessionID != group.SessionID {
+			return fmt.Errorf("course offering %d is not offered in the group's session", id)
+		}
+		if offering.ElectiveGroupID != nil && *offering.ElectiveGroupID != group.ID {
+			return fmt.Errorf("course offering %d already belongs to elective group %d", id, *offering.ElectiveGroupID)
+		}
+		if offering.CombinedClassID != nil {
+			return fmt.Errorf("course offering %d is taught in combined class %d", id, *offering.CombinedClassID)
+		}
+		offerings = append(offerings, *offering)
+	}
+
+	return checkOfferingsAlign(offerings, "an elective group")
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"icrogen/internal/models"
+	"icrogen/internal/repository"
+)
+
+// The fakes below embed the repository interfaces they stand in for and
+// implement only the methods routine generation calls; anything else panics.
+
+type fakeScheduleRepo struct {
+	repository.ScheduleRepository
+
+	mu          sync.Mutex
+	nextID      uint
+	runs        map[uint]*models.ScheduleRun
+	committed   []models.ScheduleEntry
+	sessionRuns map[uint]*models.SessionScheduleRun
+
+	generateGate *testGate // Holds a generation once it has its run, before it loads committed entries
+	commitGate   *testGate // Holds a commit before it checks the run
+}
+
+func newFakeScheduleRepo() *fakeScheduleRepo {
+	return &fakeScheduleRepo{
+		runs:        make(map[uint]*models.ScheduleRun),
+		sessionRuns: make(map[uint]*models.SessionScheduleRun),
+	}
+}
+
+// testGate holds the first caller to reach it until the test releases it, so
+// a test can run another request while that one is in progress
+type testGate struct {
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newTestGate() *testGate {
+	return &testGate{entered: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (g *testGate) wait() {
+	if g == nil {
+		return
+	}
+	first := false
+	g.once.Do(func() {
+		first = true
+		close(g.entered)
+	})
+	if first {
+		<-g.release
+	}
+}
+
+func (r *fakeScheduleRepo) id() uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	return r.nextID
+}
+
+func (r *fakeScheduleRepo) CreateScheduleRun(run *models.ScheduleRun) error {
+	run.ID = r.id()
+	r.mu.Lock()
+	r.runs[run.ID] = run
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *fakeScheduleRepo) GetScheduleRunByID(id uint) (*models.ScheduleRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("schedule run %d: %w", id, gorm.ErrRecordNotFound)
+	}
+	copied := *run
+	return &copied, nil
+}
+
+func (r *fakeScheduleRepo) UpdateScheduleRun(run *models.ScheduleRun) error {
+	return nil
+}
+
+func (r *fakeScheduleRepo) GetScheduleRunsBySemesterOffering(semesterOfferingID uint) ([]models.ScheduleRun, error) {
+	return nil, nil
+}
+
+func (r *fakeScheduleRepo) SaveGeneratedRuns(runs []repository.GeneratedRun) error {
+	for _, run := range runs {
+		for _, block := range run.Blocks {
+			block.ID = r.id()
+		}
+		r.mu.Lock()
+		run.Run.ScheduleEntries = run.Entries
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+func (r *fakeScheduleRepo) CreateSessionScheduleRun(run *models.SessionScheduleRun) error {
+	run.ID = r.id()
+	r.mu.Lock()
+	r.sessionRuns[run.ID] = run
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *fakeScheduleRepo) GetSessionScheduleRunByID(id uint) (*models.SessionScheduleRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.sessionRuns[id]
+	if !ok {
+		return nil, fmt.Errorf("session schedule run %d not found", id)
+	}
+	return run, nil
+}
+
+func (r *fakeScheduleRepo) UpdateSessionScheduleRun(run *models.SessionScheduleRun) error {
+	return nil
+}
+
+func (r *fakeScheduleRepo) GetCommittedScheduleEntries(sessionID uint) ([]models.ScheduleEntry, error) {
+	r.generateGate.wait()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.ScheduleEntry(nil), r.committed...), nil
+}
+
+// CommitScheduleRun checks the draft against the committed entries and
+// commits it, one commit at a time like the session row lock
+func (r *fakeScheduleRepo) CommitScheduleRun(scheduleRunID uint, validate repository.CommitValidator) error {
+	r.commitGate.wait()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[scheduleRunID]
+	if !ok {
+		return fmt.Errorf("schedule run %d not found", scheduleRunID)
+	}
+	if run.Status != "DRAFT" {
+		return fmt.Errorf("schedule run %d is %s", run.ID, run.Status)
+	}
+	if err := validate(run, run.ScheduleEntries, r.committed); err != nil {
+		return err
+	}
+	run.Status = "COMMITTED"
+	r.committed = append(r.committed, run.ScheduleEntries...)
+	return nil
+}
+
+type fakeSemesterOfferingRepo struct {
+	repository.SemesterOfferingRepository
+	offerings []models.SemesterOffering
+}
+
+func (r *fakeSemesterOfferingRepo) GetBySession(sessionID uint) ([]models.SemesterOffering, error) {
+	return r.offerings, nil
+}
+
+func (r *fakeSemesterOfferingRepo) GetByID(id uint) (*models.SemesterOffering, error) {
+	return r.GetWithCourseOfferings(id)
+}
+
+func (r *fakeSemesterOfferingRepo) GetWithCourseOfferings(id uint) (*models.SemesterOffering, error) {
+	for i := range r.offerings {
+		if r.offerings[i].ID == id {
+			offering := r.offerings[i]
+			return &offering, nil
+		}
+	}
+	return nil, fmt.Errorf("semester offering %d not found", id)
+}
+
+type fakeTeacherRepo struct {
+	repository.TeacherRepository
+	teachers []models.Teacher
+}
+
+func (r *fakeTeacherRepo) GetActive() ([]models.Teacher, error) {
+	return r.teachers, nil
+}
+
+type fakeRoomRepo struct {
+	repository.RoomRepository
+	rooms []models.Room
+}
+
+func (r *fakeRoomRepo) GetAll() ([]models.Room, error) {
+	return r.rooms, nil
+}
+
+type fakeTimeGridRepo struct {
+	repository.TimeGridRepository
+}
+
+func (r *fakeTimeGridRepo) GetDefaultTimeSlots() ([]models.TimeSlot, error) {
+	return nil, nil
+}
+
+func (r *fakeTimeGridRepo) GetByProgrammeID(programmeID uint) (*models.TimeGrid, error) {
+	return nil, nil
+}
+
+type fakeSoftConstraintRepo struct {
+	repository.SoftConstraintRepository
+}
+
+func (r *fakeSoftConstraintRepo) GetByProgrammeID(programmeID uint) ([]models.SoftConstraint, error) {
+	return nil, nil
+}
+
+func (r *fakeSoftConstraintRepo) GetByDepartmentID(departmentID uint) ([]models.SoftConstraint, error) {
+	return nil, nil
+}
+
+type fakeAvailabilityRepo struct {
+	repository.TeacherAvailabilityRepository
+}
+
+func (r *fakeAvailabilityRepo) GetForSession(sessionID uint) ([]models.TeacherAvailability, error) {
+	return nil, nil
+}
+
+type fakeWorkloadRepo struct {
+	repository.TeacherWorkloadRepository
+	limits []models.TeacherWorkloadLimit
+}
+
+func (r *fakeWorkloadRepo) GetAll() ([]models.TeacherWorkloadLimit, error) {
+	return r.limits, nil
+}
+
+// fakeLockRepo hands out named locks held in memory, the way GET_LOCK does
+// across connections. While err is set every TryLock fails with it.
+type fakeLockRepo struct {
+	mu    sync.Mutex
+	held  map[string]bool
+	err   error
+	calls int
+}
+
+func newFakeLockRepo() *fakeLockRepo {
+	return &fakeLockRepo{held: make(map[string]bool)}
+}
+
+func (r *fakeLockRepo) TryLock(ctx context.Context, name string) (func() error, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.err != nil {
+		return nil, false, r.err
+	}
+	if r.held[name] {
+		return nil, false, nil
+	}
+	r.held[name] = true
+	return func() error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.held, name)
+		return nil
+	}, true, nil
+}
+
+func (r *fakeLockRepo) isHeld(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.held[name]
+}
+
+func (r *fakeLockRepo) setErr(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Background generation limits
+const (
+	maxRunningJobs = 2         // Generations solving at once; later ones stay QUEUED
+	jobRetention   = time.Hour // How long a finished job's progress is kept
+)
+
+// GenerationProgress is how far a background generation has got. Counts are
+// in blocks as reports count them, every part of an elective block or
+// combined class on its own.
+type GenerationProgress struct {
+	Pass         int   `json:"pass"`          // Pattern alternatives tried so far, from 1
+	TotalBlocks  int   `json:"total_blocks"`  // Blocks of the current pass
+	PlacedBlocks int   `json:"placed_blocks"` // Blocks on the timetable right now
+	BestPlaced   int   `json:"best_placed"`   // Most blocks on the timetable at once in this pass
+	Nodes        int   `json:"nodes"`
+	Backtracks   int   `json:"backtracks"`
+	Iterations   int   `json:"iterations,omitempty"`
+	BestScore    int   `json:"best_score"` // Local search: best soft-constraint score gained over its starting timetable
+	ElapsedMs    int64 `json:"elapsed_ms"`
+}
+
+// GenerationJob is a routine generation running in the background. A job is
+// known by the ID of the schedule run it fills.
+type GenerationJob struct {
+	ID                 uint               `json:"id"`
+	ScheduleRunID      uint               `json:"schedule_run_id"`
+	SemesterOfferingID uint               `json:"semester_offering_id"`
+	Status             string             `json:"status"`   // Status of the schedule run
+	Finished           bool               `json:"finished"` // No longer queued or running
+	Progress           GenerationProgress `json:"progress"`
+	Error              string             `json:"error,omitempty"`
+	CreatedAt          time.Time          `json:"created_at"`
+	FinishedAt         *time.Time         `json:"finished_at,omitempty"`
+}
+
+// generationJob is the live state of a job, shared between the goroutine
+// running it and the requests reading it
+type generationJob struct {
+	mu       sync.Mutex
+	job      GenerationJob
+	cancel   context.CancelFunc
+	progress *jobProgress
+}
+
+func (j *generationJob) snapshot() *GenerationJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job := j.job
+	job.Progress = j.progress.snapshot()
+	return &job
+}
+
+func (j *generationJob) setStatus(status string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.job.Status = status
+}
+
+func (j *generationJob) finish(status string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	j.job.Status = status
+	j.job.Finished = true
+	j.job.FinishedAt = &now
+	if err != nil {
+		j.job.Error = err.Error()
+	}
+}
+
+func (j *generationJob) finished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.job.Finished
+}
+
+// generationJobs holds the jobs of this process, by schedule run ID
+type generationJobs struct {
+	mu    sync.Mutex
+	jobs  map[uint]*generationJob
+	slots chan struct{}
+}
+
+func newGenerationJobs() *generationJobs {
+	return &generationJobs{
+		jobs:  make(map[uint]*generationJob),
+		slots: make(chan struct{}, maxRunningJobs),
+	}
+}
+
+// add registers a job, forgetting jobs finished longer ago than jobRetention
+func (g *generationJobs) add(job *generationJob) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for id, other := range g.jobs {
+		if finishedAt := other.snapshot().FinishedAt; finishedAt != nil && time.Since(*finishedAt) > jobRetention {
+			delete(g.jobs, id)
+		}
+	}
+	g.jobs[job.job.ID] = job
+}
+
+func (g *generationJobs) get(id uint) *generationJob {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.jobs[id]
+}
+
+// StartGenerationJob queues a generation for one semester offering and
+// returns at once. The run is created QUEUED, becomes RUNNING when a solver
+// slot is free, and ends like a synchronous generation. The offering stays
+// locked until the job finishes.
+func (s *routineGenerationService) StartGenerationJob(semesterOfferingID uint, opts GenerationOptions) (*GenerationJob, error) {
+	locks, err := s.offeringLock(semesterOfferingID)
+	if err != nil {
+		return nil, err
+	}
+	scheduleRun, semesterOffering, solver, err := s.createScheduleRun(semesterOfferingID, opts, nil, "QUEUED")
+	if err != nil {
+		locks.release()
+		return nil, err
+	}
+	locks.setRun(scheduleRun.ID, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &generationJob{
+		job: GenerationJob{
+			ID:                 scheduleRun.ID,
+			ScheduleRunID:      scheduleRun.ID,
+			SemesterOfferingID: semesterOfferingID,
+			Status:             scheduleRun.Status,
+			CreatedAt:          time.Now(),
+		},
+		cancel:   cancel,
+		progress: &jobProgress{},
+	}
+	s.jobs.add(job)
+	logrus.Infof("Queued routine generation job %d for semester offering ID: %d", scheduleRun.ID, semesterOfferingID)
+
+	go func() {
+		defer locks.release()
+		s.runGenerationJob(ctx, job, scheduleRun, semesterOffering, solver, opts)
+	}()
+	return job.snapshot(), nil
+}
+
+// runGenerationJob waits for a solver slot, then fills the job's run
+func (s *routineGenerationService) runGenerationJob(ctx context.Context, job *generationJob, scheduleRun *models.ScheduleRun, semesterOffering *models.SemesterOffering, solver Solver, opts GenerationOptions) {
+	defer job.cancel()
+
+	select {
+	case s.jobs.slots <- struct{}{}:
+		defer func() { <-s.jobs.slots }()
+	case <-ctx.Done():
+		s.markRunCancelled(scheduleRun, GenerationReport{})
+		job.finish(scheduleRun.Status, nil)
+		return
+	}
+
+	scheduleRun.Status = "RUNNING"
+	if err := s.scheduleRepo.UpdateScheduleRun(scheduleRun); err != nil {
+		logrus.Errorf("Failed to mark schedule run %d running: %v", scheduleRun.ID, err)
+	}
+	job.setStatus(scheduleRun.Status)
+	job.progress.start()
+
+	result, err := s.fillScheduleRun(withProgress(ctx, job.progress), scheduleRun, semesterOffering, solver, opts, nil)
+	switch {
+	case err == nil:
+		job.finish(result.Status, nil)
+	case ctx.Err() != nil:
+		if scheduleRun.Status == "RUNNING" {
+			s.markRunCancelled(scheduleRun, GenerationReport{})
+		}
+		job.finish(scheduleRun.Status, nil)
+	default:
+		job.finish(scheduleRun.Status, err)
+	}
+	logrus.Infof("Routine generation job %d finished: %s", scheduleRun.ID, job.snapshot().Status)
+}
+
+// markRunFailed records why a generation stopped before its run was saved,
+// and returns that cause
+func (s *routineGenerationService) markRunFailed(scheduleRun *models.ScheduleRun, cause error) error {
+	meta, _ := json.Marshal(map[string]string{"error": cause.Error()})
+	scheduleRun.Meta = string(meta)
+	scheduleRun.Status = "FAILED"
+
+	if err := s.scheduleRepo.UpdateScheduleRun(scheduleRun); err != nil {
+		logrus.Errorf("Failed to mark schedule run %d failed: %v", scheduleRun.ID, err)
+	}
+	return cause
+}
+
+// GetGenerationJob returns a job's status and progress. Jobs finished too long
+// ago, or started by an earlier process, are reported from their run alone; a
+// run such a job left QUEUED or RUNNING was interrupted and will not finish.
+func (s *routineGenerationService) GetGenerationJob(id uint) (*GenerationJob, error) {
+	if job := s.jobs.get(id); job != nil {
+		return job.snapshot(), nil
+	}
+
+	run, err := s.scheduleRepo.GetScheduleRunByID(id)
+	if err != nil {
+		return nil, errors.New("generation job not found")
+	}
+	job := &GenerationJob{
+		ID:                 run.ID,
+		ScheduleRunID:      run.ID,
+		SemesterOfferingID: run.SemesterOfferingID,
+		Status:             run.Status,
+		Finished:           true,
+		CreatedAt:          run.CreatedAt,
+	}
+	if run.Status == "QUEUED" || run.Status == "RUNNING" {
+		job.Error = "the job was interrupted by a server restart"
+	}
+	return job, nil
+}
+
+// CancelGenerationJob stops a queued or running job. The solver stops at its
+// next check and the run is marked CANCELLED without saving any entries.
+func (s *routineGenerationService) CancelGenerationJob(id uint) (*GenerationJob, error) {
+	job := s.jobs.get(id)
+	if job == nil {
+		return nil, fmt.Errorf("generation job %d is not running", id)
+	}
+	if job.finished() {
+		return nil, fmt.Errorf("generation job %d has already finished", id)
+	}
+	job.cancel()
+	return job.snapshot(), nil
+}
+
+// jobProgress collects the progress of a job's solver. Its methods may be
+// called on nil, for generations that nobody is watching.
+type jobProgress struct {
+	mu       sync.Mutex
+	started  time.Time
+	current  GenerationProgress
+	previous SearchStats // Search of the passes already finished
+	pass     SearchStats // Search of the current pass so far
+}
+
+func (p *jobProgress) start() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started = time.Now()
+}
+
+// startPass begins a pass over a fresh timetable
+func (p *jobProgress) startPass(totalBlocks int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.previous.add(p.pass)
+	p.pass = SearchStats{}
+	p.current.Pass++
+	p.current.TotalBlocks = totalBlocks
+	p.current.PlacedBlocks = 0
+	p.current.BestPlaced = 0
+}
+
+// placed counts blocks put on (or, negative, taken off) the timetable
+func (p *jobProgress) placed(blocks int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current.PlacedBlocks += blocks
+	if p.current.PlacedBlocks > p.current.BestPlaced {
+		p.current.BestPlaced = p.current.PlacedBlocks
+	}
+}
+
+// searched records the current pass's search so far
+func (p *jobProgress) searched(stats SearchStats) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pass = stats
+}
+
+func (p *jobProgress) scored(best int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if best > p.current.BestScore {
+		p.current.BestScore = best
+	}
+}
+
+func (p *jobProgress) snapshot() GenerationProgress {
+	if p == nil {
+		return GenerationProgress{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	progress := p.current
+	progress.Nodes = p.previous.Nodes + p.pass.Nodes
+	progress.Backtracks = p.previous.Backtracks + p.pass.Backtracks
+	progress.Iterations = p.previous.Iterations + p.pass.Iterations
+	if !p.started.IsZero() {
+		progress.ElapsedMs = time.Since(p.started).Milliseconds()
+	}
+	return progress
+}
+
+type progressKey struct{}
+
+// withProgress has generations run under ctx report to p
+func withProgress(ctx context.Context, p *jobProgress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// progressFrom returns what a generation under ctx reports to, or nil
+func progressFrom(ctx context.Context) *jobProgress {
+	p, _ := ctx.Value(progressKey{}).(*jobProgress)
+	return p
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"fmt"
+	"icrogen/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxGridSlots is the highest slot number a grid may use, bounded by slotBitset
+const maxGridSlots = 63
+
+// timeGrid is the weekly layout of teaching slots a semester offering is
+// scheduled on: which days have classes, their slots, where the breaks fall
+// and where labs may start
+type timeGrid struct {
+	days   []int // Ascending
+	layout map[int]*gridDay
+}
+
+type gridDay struct {
+	slots      []int // Ascending
+	hasSlot    map[int]bool
+	breakAfter map[int]bool // No block may run on from these slots into the next
+	labStarts  map[int]bool // Empty when labs may start at any slot
+	mainBreak  int          // Slot before the day's longest break (lunch), 0 when the day has none
+}
+
+// defaultTimeGrid is the grid used when no time slots are configured:
+// Monday to Friday, slots 1-4 before lunch and 5-7 after, labs starting at
+// slot 2 or 5
+func defaultTimeGrid() *timeGrid {
+	var slots []models.TimeSlot
+	times := [][2]string{
+		{"09:00", "09:55"}, {"09:55", "10:50"}, {"10:50", "11:45"}, {"11:45", "12:40"},
+		{"13:50", "14:45"}, {"14:45", "15:40"}, {"15:40", "16:35"},
+	}
+	for day := 1; day <= 5; day++ {
+		for i, t := range times {
+			slotNumber := i + 1
+			slots = append(slots, models.TimeSlot{
+				DayOfWeek:  day,
+				SlotNumber: slotNumber,
+				StartTime:  t[0],
+				EndTime:    t[1],
+				IsLabStart: slotNumber == 2 || slotNumber == 5,
+			})
+		}
+	}
+	grid, _ := buildTimeGrid(slots, false)
+	return grid
+}
+
+// newTimeGrid builds the grid for a set of stored time slots, skipping (and
+// logging) any slot that is out of range or overlaps the one before it
+func newTimeGrid(slots []models.TimeSlot) *timeGrid {
+	grid, _ := buildTimeGrid(slots, false)
+	return grid
+}
+
+// ValidateTimeSlots checks a grid's slots before they are stored: days 1-7,
+// slot numbers 1-63 and unique per day, and start times before end times
+// without overlapping the previous slot
+func ValidateTimeSlots(slots []models.TimeSlot) error {
+	if len(slots) == 0 {
+		return fmt.Errorf("a time grid needs at least one slot")
+	}
+	_, err := buildTimeGrid(slots, true)
+	return err
+}
+
+// buildTimeGrid lays out the slots by day. In strict mode the first invalid
+// slot is an error; otherwise invalid slots are logged and left out.
+func buildTimeGrid(slots []models.TimeSlot, strict bool) (*timeGrid, error) {
+	grid := &timeGrid{layout: make(map[int]*gridDay)}
+
+	reject := func(slot models.TimeSlot, reason string) error {
+		err := fmt.Errorf("slot %d on day %d: %s", slot.SlotNumber, slot.DayOfWeek, reason)
+		if !strict {
+			logrus.Warnf("Ignoring time slot: %v", err)
+		}
+		return err
+	}
+
+	byDay := make(map[int][]models.TimeSlot)
+	for _, slot := range slots {
+		if slot.DayOfWeek < 1 || slot.DayOfWeek >= maxGridDays {
+			if err := reject(slot, "day of week must be 1-7"); strict {
+				return nil, err
+			}
+			continue
+		}
+		if slot.SlotNumber < 1 || slot.SlotNumber > maxGridSlots {
+			if err := reject(slot, fmt.Sprintf("slot number must be 1-%d", maxGridSlots)); strict {
+				return nil, err
+			}
+			continue
+		}
+		byDay[slot.DayOfWeek] = append(byDay[slot.DayOfWeek], slot)
+	}
+
+	for day, daySlots := range byDay {
+		sort.Slice(daySlots, func(i, j int) bool { return daySlots[i].SlotNumber < daySlots[j].SlotNumber })
+
+		layout := &gridDay{
+			hasSlot:    make(map[int]bool),
+			breakAfter: make(map[int]bool),
+			labStarts:  make(map[int]bool),
+		}
+		var previous *models.TimeSlot
+		var previousEnd int
+		longestBreak := 0
+
+		for i := range daySlots {
+			slot := daySlots[i]
+			start, startErr := parseClock(slot.StartTime)
+			end, endErr := parseClock(slot.EndTime)
+			var reason string
+			switch {
+			case startErr != nil:
+				reason = startErr.Error()
+			case endErr != nil:
+				reason = endErr.Error()
+			case end <= start:
+				reason = "ends before it starts"
+			case previous != nil && previous.SlotNumber == slot.SlotNumber:
+				reason = "is defined twice"
+			case previous != nil && start < previousEnd:
+				reason = fmt.Sprintf("starts before slot %d ends", previous.SlotNumber)
+			}
+			if reason != "" {
+				if err := reject(slot, reason); strict {
+					return nil, err
+				}
+				continue
+			}
+
+			if previous != nil {
+				// A break is any gap in time or in slot numbering
+				gap := start - previousEnd
+				if gap > 0 || slot.SlotNumber != previous.SlotNumber+1 {
+					layout.breakAfter[previous.SlotNumber] = true
+					if gap > longestBreak {
+						longestBreak = gap
+						layout.mainBreak = previous.SlotNumber
+					}
+				}
+			}
+
+			layout.slots = append(layout.slots, slot.SlotNumber)
+			layout.hasSlot[slot.SlotNumber] = true
+			if slot.IsLabStart {
+				layout.labStarts[slot.SlotNumber] = true
+			}
+			previous = &daySlots[i]
+			previousEnd = end
+		}
+
+		if len(layout.slots) > 0 {
+			grid.layout[day] = layout
+			grid.days = append(grid.days, day)
+		}
+	}
+
+	sort.Ints(grid.days)
+	return grid, nil
+}
+
+// parseClock reads "HH:MM" or "HH:MM:SS" as minutes past midnight
+func parseClock(value string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours < 0 || hours > 23 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return hours*60 + minutes, nil
+}
+
+// slots returns the slot numbers of a day, nil when the day has no classes
+func (g *timeGrid) slots(day int) []int {
+	if layout, exists := g.layout[day]; exists {
+		return layout.slots
+	}
+	return nil
+}
+
+// fits reports whether length slots starting at startSlot all exist on the day
+// without running across a break
+func (g *timeGrid) fits(day int, startSlot int, length int) bool {
+	layout, exists := g.layout[day]
+	if !exists {
+		return false
+	}
+	for i := 0; i < length; i++ {
+		slot := startSlot + i
+		if !layout.hasSlot[slot] {
+			return false
+		}
+		if i < length-1 && layout.breakAfter[slot] {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsLabStart reports whether a lab may start at the slot
+func (g *timeGrid) allowsLabStart(day int, slot int) bool {
+	layout, exists := g.layout[day]
+	if !exists {
+		return false
+	}
+	return len(layout.labStarts) == 0 || layout.labStarts[slot]
+}
+
+// startSlots lists the slots of a day where the block could start
+func (g *timeGrid) startSlots(day int, block models.ClassBlock) []int {
+	var starts []int
+	for _, slot := range g.slots(day) {
+		if block.IsLab && !g.allowsLabStart(day, slot) {
+			continue
+		}
+		if g.fits(day, slot, block.DurationSlots) {
+			starts = append(starts, slot)
+		}
+	}
+	return starts
+}
+
+// afterMainBreak reports whether the slot falls after the day's lunch break
+func (g *timeGrid) afterMainBreak(day int, slot int) bool {
+	layout, exists := g.layout[day]
+	return exists && layout.mainBreak > 0 && slot > layout.mainBreak
+}
+
+// endsSession reports whether a break or the end of the day follows the slot
+func (g *timeGrid) endsSession(day int, slot int) bool {
+	layout, exists := g.layout[day]
+	return exists && (layout.breakAfter[slot] || g.lastSlot(day, slot))
+}
+
+// lastSlot reports whether the slot is the day's last
+func (g *timeGrid) lastSlot(day int, slot int) bool {
+	slots := g.slots(day)
+	return len(slots) > 0 && slots[len(slots)-1] == slot
+}
+
+// lastDay returns the last teaching day of the week
+func (g *timeGrid) lastDay() int {
+	if len(g.days) == 0 {
+		return 0
+	}
+	return g.days[len(g.days)-1]
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"icrogen/internal/models"
+)
+
+func testSlot(day, number int, start, end string) models.TimeSlot {
+	return models.TimeSlot{DayOfWeek: day, SlotNumber: number, StartTime: start, EndTime: end}
+}
+
+func TestValidateTimeSlots(t *testing.T) {
+	tests := []struct {
+		name    string
+		slots   []models.TimeSlot
+		wantErr bool
+	}{
+		{name: "morning and afternoon", slots: []models.TimeSlot{
+			testSlot(1, 1, "09:00", "10:00"), testSlot(1, 2, "10:00", "11:00"), testSlot(1, 3, "12:00:00", "13:00:00"),
+		}},
+		{name: "no slots", wantErr: true},
+		{name: "day out of range", slots: []models.TimeSlot{testSlot(8, 1, "09:00", "10:00")}, wantErr: true},
+		{name: "slot number out of range", slots: []models.TimeSlot{testSlot(1, 64, "09:00", "10:00")}, wantErr: true},
+		{name: "invalid time", slots: []models.TimeSlot{testSlot(1, 1, "9am", "10:00")}, wantErr: true},
+		{name: "ends before it starts", slots: []models.TimeSlot{testSlot(1, 1, "10:00", "09:00")}, wantErr: true},
+		{name: "defined twice", slots: []models.TimeSlot{
+			testSlot(1, 1, "09:00", "10:00"), testSlot(1, 1, "10:00", "11:00"),
+		}, wantErr: true},
+		{name: "overlaps the previous slot", slots: []models.TimeSlot{
+			testSlot(1, 1, "09:00", "10:00"), testSlot(1, 2, "09:30", "10:30"),
+		}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTimeSlots(tt.slots)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTimeSlots error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTimeGridSkipsInvalidSlots(t *testing.T) {
+	grid := newTimeGrid([]models.TimeSlot{
+		testSlot(2, 2, "10:00", "11:00"),
+		testSlot(2, 1, "09:00", "10:00"),
+		testSlot(2, 3, "10:30", "11:30"), // Overlaps slot 2
+		testSlot(9, 1, "09:00", "10:00"),
+	})
+	if !reflect.DeepEqual(grid.days, []int{2}) {
+		t.Errorf("days = %v, want [2]", grid.days)
+	}
+	if got := grid.slots(2); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("slots(2) = %v, want [1 2]", got)
+	}
+}
+
+func TestTimeGridFits(t *testing.T) {
+	grid := defaultTimeGrid()
+	tests := []struct {
+		name   string
+		day    int
+		start  int
+		length int
+		want   bool
+	}{
+		{name: "morning pair", day: 1, start: 1, length: 2, want: true},
+		{name: "whole morning", day: 1, start: 1, length: 4, want: true},
+		{name: "afternoon lab", day: 5, start: 5, length: 3, want: true},
+		{name: "across lunch", day: 1, start: 4, length: 2},
+		{name: "past the end of the day", day: 1, start: 7, length: 2},
+		{name: "no classes on the day", day: 6, start: 1, length: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grid.fits(tt.day, tt.start, tt.length); got != tt.want {
+				t.Errorf("fits(%d, %d, %d) = %v, want %v", tt.day, tt.start, tt.length, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeGridStartSlots(t *testing.T) {
+	grid := defaultTimeGrid()
+	anywhere := newTimeGrid([]models.TimeSlot{
+		testSlot(1, 1, "09:00", "10:00"), testSlot(1, 2, "10:00", "11:00"), testSlot(1, 3, "11:00", "12:00"),
+	})
+	tests := []struct {
+		name  string
+		grid  *timeGrid
+		block models.ClassBlock
+		want  []int
+	}{
+		{name: "theory pair", grid: grid, block: models.ClassBlock{DurationSlots: 2}, want: []int{1, 2, 3, 5, 6}},
+		{name: "lab at lab starts only", grid: grid, block: models.ClassBlock{DurationSlots: 3, IsLab: true}, want: []int{2, 5}},
+		{name: "lab anywhere without lab starts", grid: anywhere, block: models.ClassBlock{DurationSlots: 2, IsLab: true}, want: []int{1, 2}},
+		{name: "block longer than any session", grid: grid, block: models.ClassBlock{DurationSlots: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.grid.startSlots(1, tt.block); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("startSlots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeGridBreaks(t *testing.T) {
+	grid := defaultTimeGrid()
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{name: "slot 4 ends the morning", got: grid.endsSession(1, 4), want: true},
+		{name: "slot 7 ends the day", got: grid.endsSession(1, 7), want: true},
+		{name: "slot 3 runs on", got: grid.endsSession(1, 3)},
+		{name: "slot 5 is after lunch", got: grid.afterMainBreak(1, 5), want: true},
+		{name: "slot 4 is before lunch", got: grid.afterMainBreak(1, 4)},
+		{name: "friday is the last day", got: grid.lastDay() == 5, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
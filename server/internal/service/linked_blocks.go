@@ -0,0 +1,432 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"sort"
+)
+
+// Ways course offerings are linked so the generator places their blocks together
+const (
+	LinkElective = "elective group" // Students choose one; each course has its own teacher and room
+	LinkCombined = "combined class" // Taught together by one teacher in one room
+)
+
+// courseLink returns the elective group or combined class a course offering
+// belongs to, or nil
+func courseLink(offering models.CourseOffering, kind string) *uint {
+	if kind == LinkCombined {
+		return offering.CombinedClassID
+	}
+	return offering.ElectiveGroupID
+}
+
+// checkOfferingsAlign checks that linked course offerings produce blocks that
+// can be placed together: all lab or all theory, with the same weekly slots
+// and at least one required pattern in common
+func checkOfferingsAlign(offerings []models.CourseOffering, what string) error {
+	first := offerings[0]
+	alternatives := make([][][]int, 0, len(offerings))
+	for _, offering := range offerings {
+		if offering.IsLab != first.IsLab {
+			return fmt.Errorf("%s cannot mix lab and theory course offerings", what)
+		}
+		if offering.WeeklyRequiredSlots != first.WeeklyRequiredSlots {
+			return fmt.Errorf("course offering %d needs %d weekly slots but course offering %d needs %d",
+				offering.ID, offering.WeeklyRequiredSlots, first.ID, first.WeeklyRequiredSlots)
+		}
+		patterns, _ := patternAlternatives(offering)
+		alternatives = append(alternatives, patterns)
+	}
+	if len(sharedAlternatives(alternatives)) == 0 {
+		return errors.New("the course offerings have no required pattern in common")
+	}
+	return nil
+}
+
+// sharedAlternatives returns the pattern alternatives every list has, in the
+// order of the first
+func sharedAlternatives(lists [][][]int) [][]int {
+	if len(lists) == 0 {
+		return nil
+	}
+	var shared [][]int
+	for _, candidate := range lists[0] {
+		everywhere := true
+		for _, other := range lists[1:] {
+			found := false
+			for _, alternative := range other {
+				if formatPattern(alternative) == formatPattern(candidate) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				everywhere = false
+				break
+			}
+		}
+		if everywhere {
+			shared = append(shared, candidate)
+		}
+	}
+	return shared
+}
+
+// linkedPlans returns the plans of each elective group or combined class with
+// at least two course offerings being generated, by its ID, lowest course
+// offering first
+func linkedPlans(plans []*coursePlan, kind string) map[uint][]*coursePlan {
+	links := make(map[uint][]*coursePlan)
+	for _, plan := range plans {
+		if id := courseLink(plan.offering, kind); id != nil {
+			links[*id] = append(links[*id], plan)
+		}
+	}
+	for id, members := range links {
+		if len(members) < 2 {
+			delete(links, id)
+			continue
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].offering.ID < members[j].offering.ID })
+	}
+	return links
+}
+
+// alignLinkedPlans narrows the course offerings of each elective group and
+// combined class to the pattern alternatives they share, so their blocks line
+// up and they move on to the next alternative together. It fails when the
+// course offerings of a link have no alternative in common or are taught to
+// different numbers of sections or batches, since their blocks could not meet
+// at once.
+func alignLinkedPlans(plans []*coursePlan) error {
+	for _, kind := range []string{LinkElective, LinkCombined} {
+		links := linkedPlans(plans, kind)
+		ids := make([]uint, 0, len(links))
+		for id := range links {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for _, id := range ids {
+			members := links[id]
+			lead := members[0].offering
+			lists := make([][][]int, 0, len(members))
+			for _, plan := range members {
+				if groups, leadGroups := len(courseGroupIDs(plan.offering)), len(courseGroupIDs(lead)); groups != leadGroups {
+					return fmt.Errorf("%s %d cannot meet at once: course offering %d is taught to %d groups but course offering %d to %d",
+						kind, id, plan.offering.ID, groups, lead.ID, leadGroups)
+				}
+				lists = append(lists, plan.alternatives)
+			}
+			shared := sharedAlternatives(lists)
+			if len(shared) == 0 {
+				return fmt.Errorf("%s %d cannot meet at once: its course offerings have no required pattern in common", kind, id)
+			}
+			for _, plan := range members {
+				plan.alternatives = shared
+			}
+		}
+	}
+	return nil
+}
+
+// checkCombinedClasses refuses to generate a combined class without every
+// course offering it seats, since placing some of them alone would split the
+// class from its other groups
+func (s *routineGenerationService) checkCombinedClasses(offerings []models.SemesterOffering) error {
+	generating := make(map[uint]bool)
+	var classIDs []uint
+	for _, semesterOffering := range offerings {
+		for _, offering := range semesterOffering.CourseOfferings {
+			generating[offering.ID] = true
+			if offering.CombinedClassID != nil && !containsUint(classIDs, *offering.CombinedClassID) {
+				classIDs = append(classIDs, *offering.CombinedClassID)
+			}
+		}
+	}
+	sort.Slice(classIDs, func(i, j int) bool { return classIDs[i] < classIDs[j] })
+
+	for _, id := range classIDs {
+		members, err := s.courseOfferingRepo.GetByCombinedClass(id)
+		if err != nil {
+			return fmt.Errorf("failed to get course offerings of combined class %d: %w", id, err)
+		}
+		for _, member := range members {
+			if !generating[member.ID] {
+				return fmt.Errorf("combined class %d also seats course offering %d of semester offering %d; generate its semester offerings together in one session run",
+					id, member.ID, member.SemesterOfferingID)
+			}
+		}
+	}
+	return nil
+}
+
+// linkSlot is where a committed block of an elective group meets
+type linkSlot struct {
+	day    int
+	slot   int
+	length int
+}
+
+// loadElectiveSlots returns, by course offering, the slots its elective group
+// already meets in when other course offerings of the group are committed and
+// not being generated. The course offering's blocks must go in those slots so
+// the basket still meets at once.
+func (s *routineGenerationService) loadElectiveSlots(offerings []models.SemesterOffering, committedEntries []models.ScheduleEntry) (map[uint][]linkSlot, error) {
+	generating := make(map[uint]bool)
+	members := make(map[uint][]uint)
+	for _, semesterOffering := range offerings {
+		for _, offering := range semesterOffering.CourseOfferings {
+			generating[offering.ID] = true
+			if offering.ElectiveGroupID != nil {
+				members[*offering.ElectiveGroupID] = append(members[*offering.ElectiveGroupID], offering.ID)
+			}
+		}
+	}
+
+	slots := make(map[uint][]linkSlot)
+	for groupID, courseOfferingIDs := range members {
+		group, err := s.courseOfferingRepo.GetByElectiveGroup(groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get course offerings of elective group %d: %w", groupID, err)
+		}
+		var partners []uint
+		for _, offering := range group {
+			if !generating[offering.ID] {
+				partners = append(partners, offering.ID)
+			}
+		}
+		committed := committedBlockSlots(committedEntries, partners)
+		if len(committed) == 0 {
+			continue
+		}
+		for _, id := range courseOfferingIDs {
+			slots[id] = committed
+		}
+	}
+	return slots, nil
+}
+
+// committedBlockSlots lists, by day and slot, the slots the committed blocks
+// of the given course offerings take, each once
+func committedBlockSlots(entries []models.ScheduleEntry, courseOfferingIDs []uint) []linkSlot {
+	if len(courseOfferingIDs) == 0 {
+		return nil
+	}
+	spans := make(map[uint]*linkSlot)
+	for _, entry := range entries {
+		if entry.BlockID == nil || !containsUint(courseOfferingIDs, entry.CourseOfferingID) {
+			continue
+		}
+		span, exists := spans[*entry.BlockID]
+		if !exists {
+			spans[*entry.BlockID] = &linkSlot{entry.DayOfWeek, entry.SlotNumber, 1}
+			continue
+		}
+		end := span.slot + span.length
+		if entry.SlotNumber < span.slot {
+			span.slot = entry.SlotNumber
+		}
+		if entry.SlotNumber >= end {
+			end = entry.SlotNumber + 1
+		}
+		span.length = end - span.slot
+	}
+
+	var slots []linkSlot
+	seen := make(map[linkSlot]bool)
+	for _, span := range spans {
+		if !seen[*span] {
+			seen[*span] = true
+			slots = append(slots, *span)
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		if slots[i].day != slots[j].day {
+			return slots[i].day < slots[j].day
+		}
+		return slots[i].slot < slots[j].slot
+	})
+	return slots
+}
+
+// alignToElectiveSlots narrows the pattern alternatives of course offerings
+// whose elective group already meets to the one matching its committed blocks
+func alignToElectiveSlots(plans []*coursePlan, slots map[uint][]linkSlot) {
+	for _, plan := range plans {
+		committed, ok := slots[plan.offering.ID]
+		if !ok {
+			continue
+		}
+		lengths := make([]int, len(committed))
+		for i, slot := range committed {
+			lengths[i] = slot.length
+		}
+		want := formatPattern(sortedLengths(lengths))
+		for _, alternative := range plan.alternatives {
+			if formatPattern(sortedLengths(alternative)) == want {
+				plan.alternatives = [][]int{alternative}
+				plan.current = 0
+				break
+			}
+		}
+	}
+}
+
+// sortedLengths returns block lengths longest first
+func sortedLengths(lengths []int) []int {
+	sorted := append([]int(nil), lengths...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	return sorted
+}
+
+// placeAtElectiveSlots places the blocks of course offerings whose elective
+// group already meets into the group's committed slots, each slot used once
+// per course offering. Blocks that fit none of them are left unplaced rather
+// than split from their group.
+func (s *routineGenerationService) placeAtElectiveSlots(blocks []models.ClassBlock, slots map[uint][]linkSlot, state *generationState) ([]models.ClassBlock, []models.ClassBlock, []models.ClassBlock) {
+	if len(slots) == 0 {
+		return blocks, nil, nil
+	}
+
+	used := make(map[uint]map[linkSlot]bool)
+	var remaining, placed, unplaced []models.ClassBlock
+	for _, block := range blocks {
+		parts := blockParts(block)
+		var committed []linkSlot
+		for _, part := range parts {
+			if committed = slots[part.CourseOfferingID]; committed != nil {
+				break
+			}
+		}
+		if committed == nil {
+			remaining = append(remaining, block)
+			continue
+		}
+
+		fitted := false
+		for _, slot := range committed {
+			if slot.length != block.DurationSlots || used[block.CourseOfferingID][slot] {
+				continue
+			}
+			if !containsInt(state.grids[block.SemesterOfferingID].startSlots(slot.day, block), slot.slot) {
+				continue
+			}
+			chosen, ok := s.chooseResources(block, slot.day, slot.slot, state)
+			if !ok {
+				continue
+			}
+			chosen.Fixed = true
+			s.placeBlock(chosen, slot.day, slot.slot, state)
+			for _, part := range parts {
+				if used[part.CourseOfferingID] == nil {
+					used[part.CourseOfferingID] = make(map[linkSlot]bool)
+				}
+				used[part.CourseOfferingID][slot] = true
+			}
+			placed = append(placed, chosen)
+			fitted = true
+			break
+		}
+		if !fitted {
+			unplaced = append(unplaced, block)
+		}
+	}
+	return remaining, placed, unplaced
+}
+
+// bundleLinked folds the blocks of each elective group and combined class into
+// the blocks of its lowest course offering, the n-th block of every other
+// course offering riding along with the n-th block of the first, so the solver
+// places them together. alignLinkedPlans has made their blocks line up.
+func bundleLinked(blocks []models.ClassBlock, plans []*coursePlan) []models.ClassBlock {
+	byCourse := make(map[uint][]int)
+	for i, block := range blocks {
+		byCourse[block.CourseOfferingID] = append(byCourse[block.CourseOfferingID], i)
+	}
+
+	bundled := make(map[int]bool)
+	for _, kind := range []string{LinkElective, LinkCombined} {
+		links := linkedPlans(plans, kind)
+		ids := make([]uint, 0, len(links))
+		for id := range links {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for _, id := range ids {
+			members := links[id]
+			lead := byCourse[members[0].offering.ID]
+			for n, index := range lead {
+				for _, plan := range members[1:] {
+					other := byCourse[plan.offering.ID][n]
+					if kind == LinkCombined {
+						blocks[index].CombinedClassID = id
+						blocks[other].CombinedClassID = id
+						blocks[index].Combined = append(blocks[index].Combined, blocks[other])
+					} else {
+						blocks[index].Electives = append(blocks[index].Electives, blocks[other])
+					}
+					bundled[other] = true
+				}
+			}
+		}
+	}
+
+	result := make([]models.ClassBlock, 0, len(blocks)-len(bundled))
+	for i, block := range blocks {
+		if !bundled[i] {
+			result = append(result, block)
+		}
+	}
+	return result
+}
+
+// blockParts returns the block followed by the blocks of the rest of its
+// elective group or combined class; for an ordinary block, just the block.
+// Parts of a combined class take the block's teacher and rooms, and its
+// candidates for them.
+func blockParts(block models.ClassBlock) []models.ClassBlock {
+	if len(block.Electives) == 0 && len(block.Combined) == 0 {
+		return []models.ClassBlock{block}
+	}
+	lead := block
+	lead.Electives = nil
+	lead.Combined = nil
+	parts := append([]models.ClassBlock{lead}, block.Electives...)
+	for _, part := range block.Combined {
+		part.TeacherID = block.TeacherID
+		part.RoomID = block.RoomID
+		part.SplitRoomIDs = block.SplitRoomIDs
+		part.TeacherCandidates = block.TeacherCandidates
+		part.RoomCandidates = block.RoomCandidates
+		part.Pinned = block.Pinned
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// blockGroups lists the groups a block is taught to, once each
+func blockGroups(block models.ClassBlock) []groupKey {
+	var groups []groupKey
+	seen := make(map[groupKey]bool)
+	for _, part := range blockParts(block) {
+		if key := blockGroup(part); !seen[key] {
+			seen[key] = true
+			groups = append(groups, key)
+		}
+	}
+	return groups
+}
+
+// countParts counts blocks the way reports do, every part of an elective
+// block or combined class on its own
+func countParts(blocks []models.ClassBlock) int {
+	count := 0
+	for _, block := range blocks {
+		count += len(blockParts(block))
+	}
+	return count
+}
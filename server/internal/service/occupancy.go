@@ -0,0 +1,226 @@
+package service
+
+import (
+	"icrogen/internal/models"
+	"sort"
+)
+
+// Resource kinds that can block a slot during generation
+const (
+	ResourceStudentGroup = "STUDENT_GROUP"
+	ResourceTeacher      = "TEACHER"
+	ResourceRoom         = "ROOM"
+)
+
+// BlockedSlot records a day/slot that a committed schedule entry holds for a
+// resource used by one of the offering's class blocks
+type BlockedSlot struct {
+	SemesterOfferingID       uint   `json:"semester_offering_id"` // Offering whose blocks cannot use this slot
+	DayOfWeek                int    `json:"day_of_week"`
+	SlotNumber               int    `json:"slot_number"`
+	Resource                 string `json:"resource"` // STUDENT_GROUP, TEACHER or ROOM
+	ResourceID               uint   `json:"resource_id"`
+	HeldBySemesterOfferingID uint   `json:"held_by_semester_offering_id"`
+	HeldByCourseOfferingID   uint   `json:"held_by_course_offering_id"`
+}
+
+// maxGridDays is the number of days a slotBitset can hold (index 0 is unused)
+const maxGridDays = 8
+
+// slotBitset marks occupied slots of one resource, one word per day with bit n
+// standing for slot n
+type slotBitset [maxGridDays]uint64
+
+// spanMask returns the bits for length consecutive slots starting at startSlot
+func spanMask(startSlot int, length int) uint64 {
+	return ((uint64(1) << uint(length)) - 1) << uint(startSlot)
+}
+
+func (b *slotBitset) set(day int, startSlot int, length int) {
+	b[day] |= spanMask(startSlot, length)
+}
+
+func (b *slotBitset) clear(day int, startSlot int, length int) {
+	b[day] &^= spanMask(startSlot, length)
+}
+
+func (b *slotBitset) overlaps(day int, startSlot int, length int) bool {
+	return b != nil && b[day]&spanMask(startSlot, length) != 0
+}
+
+// occupancyIndex is the in-memory view of which day/slots every student group,
+// teacher and room already uses. It is loaded once per run from the committed
+// entries of the session and kept current by placeBlock and removeBlock, so
+// placement checks never go back to the database.
+type occupancyIndex struct {
+	groups   map[groupKey]*slotBitset // keyed by whole offering, section or batch
+	teachers map[uint]*slotBitset
+	rooms    map[uint]*slotBitset
+	tree     *groupTree // Which groups share students
+
+	// committed entries by resource, only used to say who holds a slot
+	committedGroups   map[uint][]*models.ScheduleEntry
+	committedTeachers map[uint][]*models.ScheduleEntry
+	committedRooms    map[uint][]*models.ScheduleEntry
+}
+
+func newOccupancyIndex(entries []models.ScheduleEntry, tree *groupTree) *occupancyIndex {
+	index := &occupancyIndex{
+		groups:            make(map[groupKey]*slotBitset),
+		teachers:          make(map[uint]*slotBitset),
+		rooms:             make(map[uint]*slotBitset),
+		tree:              tree,
+		committedGroups:   make(map[uint][]*models.ScheduleEntry),
+		committedTeachers: make(map[uint][]*models.ScheduleEntry),
+		committedRooms:    make(map[uint][]*models.ScheduleEntry),
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if entry.DayOfWeek <= 0 || entry.DayOfWeek >= maxGridDays {
+			continue
+		}
+		bitsetFor(index.groups, groupKey{entry.SemesterOfferingID, entry.StudentGroupID}).set(entry.DayOfWeek, entry.SlotNumber, 1)
+		bitsetFor(index.teachers, entry.TeacherID).set(entry.DayOfWeek, entry.SlotNumber, 1)
+		index.committedGroups[entry.SemesterOfferingID] = append(index.committedGroups[entry.SemesterOfferingID], entry)
+		index.committedTeachers[entry.TeacherID] = append(index.committedTeachers[entry.TeacherID], entry)
+		for _, roomID := range append([]uint{entry.RoomID}, parseRoomIDs(entry.SplitRoomIDs)...) {
+			bitsetFor(index.rooms, roomID).set(entry.DayOfWeek, entry.SlotNumber, 1)
+			index.committedRooms[roomID] = append(index.committedRooms[roomID], entry)
+		}
+	}
+
+	return index
+}
+
+func bitsetFor[K comparable](m map[K]*slotBitset, id K) *slotBitset {
+	bits, exists := m[id]
+	if !exists {
+		bits = &slotBitset{}
+		m[id] = bits
+	}
+	return bits
+}
+
+// reserve marks the block's group, teacher and rooms as busy for its span
+func (x *occupancyIndex) reserve(block models.ClassBlock, day int, startSlot int) {
+	bitsetFor(x.groups, blockGroup(block)).set(day, startSlot, block.DurationSlots)
+	x.reserveResources(block, day, startSlot)
+}
+
+// release undoes reserve
+func (x *occupancyIndex) release(block models.ClassBlock, day int, startSlot int) {
+	bitsetFor(x.groups, blockGroup(block)).clear(day, startSlot, block.DurationSlots)
+	x.releaseResources(block, day, startSlot)
+}
+
+// reserveResources marks only the block's teacher and rooms as busy
+func (x *occupancyIndex) reserveResources(block models.ClassBlock, day int, startSlot int) {
+	bitsetFor(x.teachers, block.TeacherID).set(day, startSlot, block.DurationSlots)
+	for _, roomID := range blockRooms(block) {
+		bitsetFor(x.rooms, roomID).set(day, startSlot, block.DurationSlots)
+	}
+}
+
+// releaseResources undoes reserveResources
+func (x *occupancyIndex) releaseResources(block models.ClassBlock, day int, startSlot int) {
+	bitsetFor(x.teachers, block.TeacherID).clear(day, startSlot, block.DurationSlots)
+	for _, roomID := range blockRooms(block) {
+		bitsetFor(x.rooms, roomID).clear(day, startSlot, block.DurationSlots)
+	}
+}
+
+// clash returns the kind and ID of the first resource of the block that is
+// already busy somewhere in the span, or an empty kind when the span is free.
+// The block's group is busy when it, a group containing it or a group within
+// it has a class.
+func (x *occupancyIndex) clash(block models.ClassBlock, day int, startSlot int) (string, uint) {
+	if x.groupBusy(blockGroup(block), day, startSlot, block.DurationSlots) {
+		return ResourceStudentGroup, block.SemesterOfferingID
+	}
+	if x.teachers[block.TeacherID].overlaps(day, startSlot, block.DurationSlots) {
+		return ResourceTeacher, block.TeacherID
+	}
+	for _, roomID := range blockRooms(block) {
+		if x.rooms[roomID].overlaps(day, startSlot, block.DurationSlots) {
+			return ResourceRoom, roomID
+		}
+	}
+	return "", 0
+}
+
+// groupBusy reports whether any group sharing students with the group has a
+// class somewhere in the span
+func (x *occupancyIndex) groupBusy(key groupKey, day int, startSlot int, length int) bool {
+	for _, related := range x.tree.related(key) {
+		if x.groups[related].overlaps(day, startSlot, length) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedSlots lists every committed slot held by a resource that one of the
+// blocks needs, once per offering, resource and slot
+func (x *occupancyIndex) blockedSlots(blocks []models.ClassBlock) []BlockedSlot {
+	result := []BlockedSlot{}
+	seen := make(map[BlockedSlot]bool)
+
+	add := func(semesterOfferingID uint, resource string, resourceID uint, entries []*models.ScheduleEntry) {
+		for _, entry := range entries {
+			blocked := BlockedSlot{
+				SemesterOfferingID:       semesterOfferingID,
+				DayOfWeek:                entry.DayOfWeek,
+				SlotNumber:               entry.SlotNumber,
+				Resource:                 resource,
+				ResourceID:               resourceID,
+				HeldBySemesterOfferingID: entry.SemesterOfferingID,
+				HeldByCourseOfferingID:   entry.CourseOfferingID,
+			}
+			if !seen[blocked] {
+				seen[blocked] = true
+				result = append(result, blocked)
+			}
+		}
+	}
+
+	for _, parent := range blocks {
+		for _, block := range blockParts(parent) {
+			add(block.SemesterOfferingID, ResourceStudentGroup, block.SemesterOfferingID, x.committedGroups[block.SemesterOfferingID])
+			for _, teacherID := range candidatesOrSelf(block.TeacherCandidates, block.TeacherID) {
+				add(block.SemesterOfferingID, ResourceTeacher, teacherID, x.committedTeachers[teacherID])
+			}
+			for _, roomID := range candidatesOrSelf(block.RoomCandidates, block.RoomID) {
+				add(block.SemesterOfferingID, ResourceRoom, roomID, x.committedRooms[roomID])
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.SemesterOfferingID != b.SemesterOfferingID {
+			return a.SemesterOfferingID < b.SemesterOfferingID
+		}
+		if a.DayOfWeek != b.DayOfWeek {
+			return a.DayOfWeek < b.DayOfWeek
+		}
+		if a.SlotNumber != b.SlotNumber {
+			return a.SlotNumber < b.SlotNumber
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.ResourceID < b.ResourceID
+	})
+
+	return result
+}
+
+// candidatesOrSelf returns the candidate IDs of a block, or just the chosen one
+// when the block has no candidate list
+func candidatesOrSelf(candidates []uint, chosen uint) []uint {
+	if len(candidates) == 0 {
+		return []uint{chosen}
+	}
+	return candidates
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"testing"
+
+	"icrogen/internal/models"
+)
+
+func TestSlotBitset(t *testing.T) {
+	var bits slotBitset
+	bits.set(1, 2, 3)
+	bits.set(5, 63, 1)
+
+	tests := []struct {
+		name   string
+		day    int
+		start  int
+		length int
+		want   bool
+	}{
+		{name: "inside the span", day: 1, start: 3, length: 1, want: true},
+		{name: "overlapping its end", day: 1, start: 4, length: 2, want: true},
+		{name: "just before", day: 1, start: 1, length: 1},
+		{name: "just after", day: 1, start: 5, length: 2},
+		{name: "another day", day: 2, start: 2, length: 3},
+		{name: "highest slot", day: 5, start: 63, length: 1, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bits.overlaps(tt.day, tt.start, tt.length); got != tt.want {
+				t.Errorf("overlaps(%d, %d, %d) = %v, want %v", tt.day, tt.start, tt.length, got, tt.want)
+			}
+		})
+	}
+
+	bits.clear(1, 3, 1)
+	if bits.overlaps(1, 3, 1) || !bits.overlaps(1, 2, 1) || !bits.overlaps(1, 4, 1) {
+		t.Errorf("clear(1, 3, 1) left day 1 as %b, want slots 2 and 4", bits[1])
+	}
+	var none *slotBitset
+	if none.overlaps(1, 1, 1) {
+		t.Errorf("a resource with nothing booked overlaps")
+	}
+}
+
+// occupancyFixture is semester offering 1 with sections 1 and 3 and batch 2
+// of section 1, with committed entries of batch 2 on Monday's first slot,
+// teacher 5 on Tuesday's second and split room 22 on Wednesday's third
+func occupancyFixture() *occupancyIndex {
+	section := uint(1)
+	offerings := []models.SemesterOffering{{ID: 1, StudentGroups: []models.StudentGroup{
+		{ID: 1, Kind: "SECTION"},
+		{ID: 2, Kind: "BATCH", ParentID: &section},
+		{ID: 3, Kind: "SECTION"},
+	}}}
+	committed := []models.ScheduleEntry{
+		{SemesterOfferingID: 1, StudentGroupID: 2, CourseOfferingID: 10, TeacherID: 4, RoomID: 20, DayOfWeek: 1, SlotNumber: 1},
+		{SemesterOfferingID: 2, CourseOfferingID: 30, TeacherID: 5, RoomID: 21, DayOfWeek: 2, SlotNumber: 2},
+		{SemesterOfferingID: 2, CourseOfferingID: 31, TeacherID: 6, RoomID: 23, SplitRoomIDs: "[22]", DayOfWeek: 3, SlotNumber: 3},
+		{SemesterOfferingID: 2, CourseOfferingID: 32, TeacherID: 7, RoomID: 24, DayOfWeek: 0, SlotNumber: 1}, // Outside the week
+	}
+	return newOccupancyIndex(committed, newGroupTree(offerings))
+}
+
+func TestOccupancyIndexClash(t *testing.T) {
+	index := occupancyFixture()
+	block := func(group uint, teacherID uint, roomID uint, length int) models.ClassBlock {
+		return models.ClassBlock{SemesterOfferingID: 1, StudentGroupID: group, TeacherID: teacherID, RoomID: roomID, DurationSlots: length}
+	}
+
+	tests := []struct {
+		name         string
+		block        models.ClassBlock
+		day, slot    int
+		wantResource string
+		wantID       uint
+	}{
+		{name: "free", block: block(3, 8, 25, 2), day: 1, slot: 1},
+		{name: "batch busy", block: block(2, 8, 25, 1), day: 1, slot: 1, wantResource: ResourceStudentGroup, wantID: 1},
+		{name: "section containing the batch", block: block(1, 8, 25, 1), day: 1, slot: 1, wantResource: ResourceStudentGroup, wantID: 1},
+		{name: "whole offering", block: block(0, 8, 25, 1), day: 1, slot: 1, wantResource: ResourceStudentGroup, wantID: 1},
+		{name: "teacher busy", block: block(3, 5, 25, 2), day: 2, slot: 1, wantResource: ResourceTeacher, wantID: 5},
+		{name: "room taken", block: block(3, 8, 21, 1), day: 2, slot: 2, wantResource: ResourceRoom, wantID: 21},
+		{name: "split room taken", block: block(3, 8, 22, 1), day: 3, slot: 3, wantResource: ResourceRoom, wantID: 22},
+		{name: "entry outside the week ignored", block: block(3, 7, 24, 1), day: 1, slot: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, id := index.clash(tt.block, tt.day, tt.slot)
+			if resource != tt.wantResource || id != tt.wantID {
+				t.Errorf("clash = %q %d, want %q %d", resource, id, tt.wantResource, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestOccupancyIndexReserve(t *testing.T) {
+	index := occupancyFixture()
+	placed := models.ClassBlock{SemesterOfferingID: 1, StudentGroupID: 3, TeacherID: 8, RoomID: 25, SplitRoomIDs: []uint{26}, DurationSlots: 2}
+	other := models.ClassBlock{SemesterOfferingID: 1, StudentGroupID: 0, TeacherID: 9, RoomID: 26, DurationSlots: 1}
+
+	index.reserve(placed, 4, 1)
+	if resource, _ := index.clash(other, 4, 2); resource != ResourceStudentGroup {
+		t.Errorf("clash = %q, want the whole offering busy while section 3 is in class", resource)
+	}
+	other.StudentGroupID = 1
+	if resource, id := index.clash(other, 4, 2); resource != ResourceRoom || id != 26 {
+		t.Errorf("clash = %q %d, want the split room 26", resource, id)
+	}
+
+	index.release(placed, 4, 1)
+	if resource, _ := index.clash(other, 4, 2); resource != "" {
+		t.Errorf("clash after release = %q, want none", resource)
+	}
+}
+
+func TestBlockedSlots(t *testing.T) {
+	index := occupancyFixture()
+	blocks := []models.ClassBlock{
+		{SemesterOfferingID: 1, TeacherID: 5, RoomID: 22},
+		{SemesterOfferingID: 1, TeacherCandidates: []uint{5, 6}, RoomID: 25},
+	}
+
+	blocked := index.blockedSlots(blocks)
+	want := []BlockedSlot{
+		{SemesterOfferingID: 1, DayOfWeek: 1, SlotNumber: 1, Resource: ResourceStudentGroup, ResourceID: 1, HeldBySemesterOfferingID: 1, HeldByCourseOfferingID: 10},
+		{SemesterOfferingID: 1, DayOfWeek: 2, SlotNumber: 2, Resource: ResourceTeacher, ResourceID: 5, HeldBySemesterOfferingID: 2, HeldByCourseOfferingID: 30},
+		{SemesterOfferingID: 1, DayOfWeek: 3, SlotNumber: 3, Resource: ResourceRoom, ResourceID: 22, HeldBySemesterOfferingID: 2, HeldByCourseOfferingID: 31},
+		{SemesterOfferingID: 1, DayOfWeek: 3, SlotNumber: 3, Resource: ResourceTeacher, ResourceID: 6, HeldBySemesterOfferingID: 2, HeldByCourseOfferingID: 31},
+	}
+	if len(blocked) != len(want) {
+		t.Fatalf("blocked slots %+v, want %+v", blocked, want)
+	}
+	for i := range want {
+		if blocked[i] != want[i] {
+			t.Errorf("blocked slot %d is %+v, want %+v", i, blocked[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"strconv"
+	"strings"
+)
+
+// theoryMaxSlotsPerDay is the most slots a theory course may take on one day,
+// so no theory block can be longer than this
+const theoryMaxSlotsPerDay = 2
+
+// ParseRequiredPattern parses a CourseOffering.RequiredPattern such as
+// ["2+2","2+1+1","1+1+1+1"] into block lengths per alternative, in the order
+// of preference. A bare pattern like "2+2" is accepted as a single alternative.
+func ParseRequiredPattern(raw string) ([][]int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, errors.New("required pattern is empty")
+	}
+
+	var alternatives []string
+	if err := json.Unmarshal([]byte(raw), &alternatives); err != nil {
+		alternatives = []string{raw}
+	}
+	if len(alternatives) == 0 {
+		return nil, errors.New("required pattern has no alternatives")
+	}
+
+	var result [][]int
+	for _, alternative := range alternatives {
+		parts := strings.Split(alternative, "+")
+		lengths := make([]int, 0, len(parts))
+		for _, part := range parts {
+			length, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || length <= 0 {
+				return nil, fmt.Errorf("invalid block length %q in pattern %q", strings.TrimSpace(part), alternative)
+			}
+			lengths = append(lengths, length)
+		}
+		result = append(result, lengths)
+	}
+
+	return result, nil
+}
+
+// ValidateRequiredPattern checks that every alternative of the pattern adds up
+// to the weekly required slots and, for theory, respects the per-day limit
+func ValidateRequiredPattern(raw string, weeklySlots int, isLab bool) error {
+	alternatives, err := ParseRequiredPattern(raw)
+	if err != nil {
+		return err
+	}
+
+	for _, lengths := range alternatives {
+		total := 0
+		for _, length := range lengths {
+			if !isLab && length > theoryMaxSlotsPerDay {
+				return fmt.Errorf("pattern %q has a %d-slot block but theory classes allow at most %d slots per day",
+					formatPattern(lengths), length, theoryMaxSlotsPerDay)
+			}
+			total += length
+		}
+		if total != weeklySlots {
+			return fmt.Errorf("pattern %q adds up to %d slots but weekly required slots is %d",
+				formatPattern(lengths), total, weeklySlots)
+		}
+	}
+
+	return nil
+}
+
+// EncodeRequiredPattern renders pattern alternatives back into the stored JSON form
+func EncodeRequiredPattern(alternatives [][]int) string {
+	patterns := make([]string, len(alternatives))
+	for i, lengths := range alternatives {
+		patterns[i] = formatPattern(lengths)
+	}
+	jsonBytes, _ := json.Marshal(patterns)
+	return string(jsonBytes)
+}
+
+// patternAlternatives returns the pattern alternatives the generator uses for
+// a course offering: the stored ones, or the defaults along with the reason
+// the stored ones are unusable. Offerings saved before patterns were validated
+// may not add up.
+func patternAlternatives(offering models.CourseOffering) ([][]int, error) {
+	alternatives, err := ParseRequiredPattern(offering.RequiredPattern)
+	if err == nil {
+		err = ValidateRequiredPattern(offering.RequiredPattern, offering.WeeklyRequiredSlots, offering.IsLab)
+	}
+	if err != nil {
+		return defaultPatternAlternatives(offering.WeeklyRequiredSlots, offering.Subject.Credit, offering.IsLab), err
+	}
+	return alternatives, nil
+}
+
+// formatPattern renders block lengths as "2+1+1"
+func formatPattern(lengths []int) string {
+	parts := make([]string, len(lengths))
+	for i, length := range lengths {
+		parts[i] = strconv.Itoa(length)
+	}
+	return strings.Join(parts, "+")
+}
+
+// defaultPatternAlternatives returns the pattern alternatives used when a course
+// offering does not give its own, based on DESIGN_v1.md credit-to-sessions mapping
+func defaultPatternAlternatives(weeklySlots int, credit int, isLab bool) [][]int {
+	if weeklySlots <= 0 {
+		return nil
+	}
+
+	if isLab {
+		// Labs are typically 3-hour blocks
+		var lengths []int
+		remaining := weeklySlots
+		for remaining > 0 {
+			length := 3
+			if remaining < length {
+				length = remaining
+			}
+			lengths = append(lengths, length)
+			remaining -= length
+		}
+		return [][]int{lengths}
+	}
+
+	var alternatives [][]int
+	add := func(lengths []int) {
+		total := 0
+		for _, length := range lengths {
+			total += length
+		}
+		if total != weeklySlots {
+			return
+		}
+		for _, existing := range alternatives {
+			if formatPattern(existing) == formatPattern(lengths) {
+				return
+			}
+		}
+		alternatives = append(alternatives, lengths)
+	}
+
+	switch credit {
+	case 4:
+		// Credit 4: prefer 2+2, fallback 2+1+1
+		add([]int{2, 2})
+		add([]int{2, 1, 1})
+	case 3:
+		// Credit 3: prefer 2+1, fallback 1+1+1
+		add([]int{2, 1})
+	case 2:
+		// Credit 2: prefer consecutive 2, fallback 1+1
+		add([]int{2})
+	}
+
+	// Split into 2-slot blocks where possible
+	var pairs []int
+	remaining := weeklySlots
+	for remaining > 0 {
+		if remaining >= 2 {
+			pairs = append(pairs, 2)
+			remaining -= 2
+		} else {
+			pairs = append(pairs, 1)
+			remaining--
+		}
+	}
+	add(pairs)
+
+	// Last resort: single slots only
+	singles := make([]int, weeklySlots)
+	for i := range singles {
+		singles[i] = 1
+	}
+	add(singles)
+
+	return alternatives
+}
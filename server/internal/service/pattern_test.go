@@ -0,0 +1,135 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"icrogen/internal/models"
+)
+
+func TestParseRequiredPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    [][]int
+		wantErr bool
+	}{
+		{name: "alternatives", raw: `["2+2","2+1+1"]`, want: [][]int{{2, 2}, {2, 1, 1}}},
+		{name: "bare pattern", raw: "2+2", want: [][]int{{2, 2}}},
+		{name: "spaces", raw: " 1 + 2 ", want: [][]int{{1, 2}}},
+		{name: "empty", raw: "  ", wantErr: true},
+		{name: "no alternatives", raw: `[]`, wantErr: true},
+		{name: "zero length", raw: `["2+0"]`, wantErr: true},
+		{name: "not a number", raw: "2+x", wantErr: true},
+		{name: "comma separated", raw: "2+2, 2+1+1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRequiredPattern(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRequiredPattern(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRequiredPattern(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRequiredPattern(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		weeklySlots int
+		isLab       bool
+		wantErr     bool
+	}{
+		{name: "every alternative adds up", raw: `["2+2","2+1+1","1+1+1+1"]`, weeklySlots: 4},
+		{name: "lab block longer than theory allows", raw: "3", weeklySlots: 3, isLab: true},
+		{name: "one alternative short", raw: `["2+2","2+1"]`, weeklySlots: 4, wantErr: true},
+		{name: "too many slots", raw: "2+2", weeklySlots: 3, wantErr: true},
+		{name: "theory block over the daily limit", raw: "3+1", weeklySlots: 4, wantErr: true},
+		{name: "unparsable", raw: "two", weeklySlots: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRequiredPattern(tt.raw, tt.weeklySlots, tt.isLab)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRequiredPattern(%q, %d, %v) error = %v, wantErr %v", tt.raw, tt.weeklySlots, tt.isLab, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatternAlternatives(t *testing.T) {
+	tests := []struct {
+		name     string
+		offering models.CourseOffering
+		want     [][]int
+		wantErr  bool
+	}{
+		{
+			name:     "stored pattern",
+			offering: models.CourseOffering{RequiredPattern: `["1+1+1"]`, WeeklyRequiredSlots: 3},
+			want:     [][]int{{1, 1, 1}},
+		},
+		{
+			name:     "defaults for a pattern that does not add up",
+			offering: models.CourseOffering{RequiredPattern: `["2+2"]`, WeeklyRequiredSlots: 3, Subject: models.Subject{Credit: 3}},
+			want:     [][]int{{2, 1}, {1, 1, 1}},
+			wantErr:  true,
+		},
+		{
+			name:     "defaults for a lab without a pattern",
+			offering: models.CourseOffering{WeeklyRequiredSlots: 3, IsLab: true},
+			want:     [][]int{{3}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := patternAlternatives(tt.offering)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("patternAlternatives error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("patternAlternatives = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetRequiredPattern(t *testing.T) {
+	lab := &models.Subject{Credit: 2, SubjectType: models.SubjectType{IsLab: true}}
+	theory := &models.Subject{Credit: 4}
+
+	tests := []struct {
+		name     string
+		offering models.CourseOffering
+		subject  *models.Subject
+		want     string
+		wantLab  bool
+		wantErr  bool
+	}{
+		{name: "bare pattern normalised", offering: models.CourseOffering{RequiredPattern: "2+2", WeeklyRequiredSlots: 4}, subject: theory, want: `["2+2"]`},
+		{name: "default pattern", offering: models.CourseOffering{WeeklyRequiredSlots: 4}, subject: theory, want: `["2+2","2+1+1","1+1+1+1"]`},
+		{name: "lab flag from the subject", offering: models.CourseOffering{RequiredPattern: "3", WeeklyRequiredSlots: 3}, subject: lab, want: `["3"]`, wantLab: true},
+		{name: "lab flag in the request ignored", offering: models.CourseOffering{RequiredPattern: "3+1", WeeklyRequiredSlots: 4, IsLab: true}, subject: theory, wantErr: true},
+		{name: "pattern short of the weekly slots", offering: models.CourseOffering{RequiredPattern: "2+1", WeeklyRequiredSlots: 4}, subject: theory, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offering := tt.offering
+			err := setRequiredPattern(&offering, tt.subject)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setRequiredPattern error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if offering.RequiredPattern != tt.want || offering.IsLab != tt.wantLab {
+				t.Errorf("got pattern %s and lab %v, want %s and %v", offering.RequiredPattern, offering.IsLab, tt.want, tt.wantLab)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"encoding/json"
+	"icrogen/internal/models"
+	"sort"
+)
+
+// Soft constraint types the generator scores placements against
+const (
+	ConstraintSpreadSubject      = "SPREAD_SUBJECT"
+	ConstraintBalancedDays       = "BALANCED_DAYS"
+	ConstraintStudentGaps        = "MIN_STUDENT_GAPS"
+	ConstraintTeacherConsecutive = "TEACHER_CONSECUTIVE"
+	ConstraintTheoryMorning      = "THEORY_MORNING"
+	ConstraintLabAfternoon       = "LAB_AFTERNOON"
+	ConstraintAvoidFirstSlot     = "AVOID_FIRST_SLOT"
+	ConstraintAvoidLastSlot      = "AVOID_LAST_SLOT"
+	ConstraintAvoidLastDay       = "AVOID_LAST_DAY"
+	ConstraintTeacherPreferred   = "TEACHER_PREFERRED_SLOTS"
+)
+
+// SoftConstraintType describes a soft constraint, what one unit of penalty
+// means and the weight it has unless a programme or department overrides it
+type SoftConstraintType struct {
+	Type          string         `json:"type"`
+	Description   string         `json:"description"`
+	DefaultWeight int            `json:"default_weight"`
+	Params        map[string]int `json:"params,omitempty"` // Parameter names with their defaults
+}
+
+// SoftConstraintTypes lists every soft constraint the generator knows. The
+// default weights reproduce the generator's original placement preferences.
+var SoftConstraintTypes = []SoftConstraintType{
+	{Type: ConstraintSpreadSubject, Description: "Each pair of blocks of the same subject on one day", DefaultWeight: 10},
+	{Type: ConstraintBalancedDays, Description: "Each pair of booked slots on one day of a student group, so classes spread across the week", DefaultWeight: 5},
+	{Type: ConstraintStudentGaps, Description: "Each idle slot of a student group between two classes of the same session", DefaultWeight: 3},
+	{Type: ConstraintTeacherConsecutive, Description: "Each slot a teacher teaches beyond max_consecutive in a row", DefaultWeight: 10, Params: map[string]int{"max_consecutive": 3}},
+	{Type: ConstraintTheoryMorning, Description: "Theory blocks away from the morning: 1 for early afternoon, 3 for the end of a session or day", DefaultWeight: 5},
+	{Type: ConstraintLabAfternoon, Description: "Each lab block held before the lunch break", DefaultWeight: 20},
+	{Type: ConstraintAvoidFirstSlot, Description: "Each day a student group has class in the first slot", DefaultWeight: 0},
+	{Type: ConstraintAvoidLastSlot, Description: "Each day a student group has class in the last slot", DefaultWeight: 10},
+	{Type: ConstraintAvoidLastDay, Description: "Each block held on the last teaching day of the week", DefaultWeight: 5},
+	{Type: ConstraintTeacherPreferred, Description: "Each slot taught outside the teacher's preferred slots, for teachers who have set any", DefaultWeight: 5},
+}
+
+// softConstraintType looks up a constraint type by name
+func softConstraintType(name string) (SoftConstraintType, bool) {
+	for _, t := range SoftConstraintTypes {
+		if t.Type == name {
+			return t, true
+		}
+	}
+	return SoftConstraintType{}, false
+}
+
+// PenaltyReport is the weighted soft-constraint penalty of a timetable, lower
+// being better, so runs can be compared on the same scale
+type PenaltyReport struct {
+	Total        int            `json:"total"`
+	ByConstraint map[string]int `json:"by_constraint"`
+}
+
+// softConstraintSet holds the weights and parameters that apply to one
+// semester offering
+type softConstraintSet struct {
+	weights map[string]int
+	params  map[string]map[string]int
+}
+
+func defaultSoftConstraints() *softConstraintSet {
+	set := &softConstraintSet{
+		weights: make(map[string]int),
+		params:  make(map[string]map[string]int),
+	}
+	for _, t := range SoftConstraintTypes {
+		set.weights[t.Type] = t.DefaultWeight
+		set.params[t.Type] = make(map[string]int)
+		for name, value := range t.Params {
+			set.params[t.Type][name] = value
+		}
+	}
+	return set
+}
+
+// override applies a stored constraint on top of the set
+func (c *softConstraintSet) override(constraint models.SoftConstraint) {
+	if !constraint.IsActive {
+		return
+	}
+	if _, known := c.weights[constraint.Type]; !known {
+		return
+	}
+	c.weights[constraint.Type] = constraint.Weight
+
+	if constraint.Params != "" {
+		var params map[string]int
+		if err := json.Unmarshal([]byte(constraint.Params), &params); err == nil {
+			for name, value := range params {
+				c.params[constraint.Type][name] = value
+			}
+		}
+	}
+}
+
+// weighted turns penalty units into weighted penalties
+func (c *softConstraintSet) weighted(units map[string]int) map[string]int {
+	result := make(map[string]int, len(units))
+	for constraintType, count := range units {
+		result[constraintType] = count * c.weights[constraintType]
+	}
+	return result
+}
+
+func (c *softConstraintSet) total(units map[string]int) int {
+	total := 0
+	for _, penalty := range c.weighted(units) {
+		total += penalty
+	}
+	return total
+}
+
+// bookedBlocks returns the block held in each booked slot of a timetable day
+func bookedBlocks(daySlots map[int]models.TimeSlotInfo) map[int]*models.ClassBlock {
+	booked := make(map[int]*models.ClassBlock)
+	for slot, slotInfo := range daySlots {
+		if slotInfo.IsBooked && slotInfo.Block != nil {
+			booked[slot] = slotInfo.Block
+		}
+	}
+	return booked
+}
+
+// groupDayBlocks returns the blocks the students of a group attend on a day:
+// the group's own and those of the groups containing it
+func groupDayBlocks(state *generationState, key groupKey, day int) map[int]*models.ClassBlock {
+	booked := bookedBlocks(state.timetables[key][day])
+	for _, ancestor := range state.groups.ancestors(key) {
+		for slot, block := range bookedBlocks(state.timetables[ancestor][day]) {
+			booked[slot] = block
+		}
+	}
+	return booked
+}
+
+// groupDayPenalties adds the penalty units of one student group's day
+func groupDayPenalties(grid *timeGrid, day int, booked map[int]*models.ClassBlock, units map[string]int) {
+	slots := grid.slots(day)
+	if len(slots) == 0 {
+		return
+	}
+
+	n := len(booked)
+	units[ConstraintBalancedDays] += n * (n - 1) / 2
+
+	blocksPerCourse := make(map[uint]int)
+	seen := make(map[*models.ClassBlock]bool)
+	for _, block := range booked {
+		if !seen[block] {
+			seen[block] = true
+			blocksPerCourse[block.CourseOfferingID]++
+		}
+	}
+	for _, k := range blocksPerCourse {
+		units[ConstraintSpreadSubject] += k * (k - 1) / 2
+	}
+
+	// Idle slots between classes, counted within each session between breaks
+	first, idle := -1, 0
+	for i, slot := range slots {
+		if booked[slot] != nil {
+			if first >= 0 {
+				units[ConstraintStudentGaps] += idle
+			}
+			first, idle = i, 0
+		} else if first >= 0 {
+			idle++
+		}
+		if grid.endsSession(day, slot) {
+			first, idle = -1, 0
+		}
+	}
+
+	if booked[slots[0]] != nil {
+		units[ConstraintAvoidFirstSlot]++
+	}
+	if booked[slots[len(slots)-1]] != nil {
+		units[ConstraintAvoidLastSlot]++
+	}
+}
+
+// blockPenalties adds the penalty units of one block at its start slot
+func blockPenalties(grid *timeGrid, availability *teacherAvailability, block models.ClassBlock, day int, slot int, units map[string]int) {
+	afternoon := grid.afterMainBreak(day, slot)
+
+	if block.IsLab {
+		if !afternoon {
+			units[ConstraintLabAfternoon]++
+		}
+	} else {
+		switch {
+		case !afternoon && !grid.endsSession(day, slot):
+			// Morning
+		case afternoon && !grid.lastSlot(day, slot):
+			units[ConstraintTheoryMorning]++
+		default:
+			units[ConstraintTheoryMorning] += 3
+		}
+	}
+
+	if day == grid.lastDay() {
+		units[ConstraintAvoidLastDay]++
+	}
+
+	units[ConstraintTeacherPreferred] += availability.outsidePreferred(block.TeacherID, day, slot, block.DurationSlots)
+}
+
+// teacherDayPenalty counts the slots a teacher teaches beyond maxConsecutive
+// in a row on one day, given the day's occupancy bits; a break ends a run
+func teacherDayPenalty(grid *timeGrid, day int, bits uint64, maxConsecutive int) int {
+	if maxConsecutive <= 0 {
+		return 0
+	}
+	penalty, run := 0, 0
+	for _, slot := range grid.slots(day) {
+		if bits&spanMask(slot, 1) != 0 {
+			run++
+			if run > maxConsecutive {
+				penalty++
+			}
+		} else {
+			run = 0
+		}
+		if grid.endsSession(day, slot) {
+			run = 0
+		}
+	}
+	return penalty
+}
+
+// softPenalty is how much the weighted soft-constraint penalty of the
+// timetable grows if the block is placed at the given slot
+func (s *routineGenerationService) softPenalty(block models.ClassBlock, day int, slot int, state *generationState) int {
+	set := state.constraints[block.SemesterOfferingID]
+	grid := state.grids[block.SemesterOfferingID]
+
+	// The block changes the day of every group within its group
+	unitsBefore := make(map[string]int)
+	unitsAfter := make(map[string]int)
+	for _, leaf := range state.groups.leaves(blockGroup(block)) {
+		before := groupDayBlocks(state, leaf, day)
+		after := make(map[int]*models.ClassBlock, len(before)+block.DurationSlots)
+		for bookedSlot, booked := range before {
+			after[bookedSlot] = booked
+		}
+		for i := 0; i < block.DurationSlots; i++ {
+			after[slot+i] = &block
+		}
+		groupDayPenalties(grid, day, before, unitsBefore)
+		groupDayPenalties(grid, day, after, unitsAfter)
+	}
+	blockPenalties(grid, state.availability, block, day, slot, unitsAfter)
+
+	if set.weights[ConstraintTeacherConsecutive] > 0 {
+		maxConsecutive := set.params[ConstraintTeacherConsecutive]["max_consecutive"]
+		var bits uint64
+		if teacherBits := state.index.teachers[block.TeacherID]; teacherBits != nil {
+			bits = teacherBits[day]
+		}
+		unitsBefore[ConstraintTeacherConsecutive] = teacherDayPenalty(grid, day, bits, maxConsecutive)
+		unitsAfter[ConstraintTeacherConsecutive] = teacherDayPenalty(grid, day, bits|spanMask(slot, block.DurationSlots), maxConsecutive)
+	}
+
+	return set.total(unitsAfter) - set.total(unitsBefore)
+}
+
+// evaluatePenalties scores the finished timetables of the given semester
+// offerings. A teacher's days are scored once, with the weights and grid of
+// the first offering (by ID) they teach.
+func (s *routineGenerationService) evaluatePenalties(state *generationState, semesterOfferingIDs []uint) PenaltyReport {
+	report := PenaltyReport{ByConstraint: make(map[string]int)}
+	add := func(weighted map[string]int) {
+		for constraintType, penalty := range weighted {
+			report.ByConstraint[constraintType] += penalty
+			report.Total += penalty
+		}
+	}
+
+	ids := append([]uint(nil), semesterOfferingIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	teachersSeen := make(map[uint]bool)
+	for _, id := range ids {
+		set, grid := state.constraints[id], state.grids[id]
+		if set == nil || grid == nil {
+			continue
+		}
+
+		// Days are scored for every group of students that has no groups within
+		// it, blocks once in the timetable of the group they are taught to
+		units := make(map[string]int)
+		var teachers []uint
+		root := groupKey{id, 0}
+		for _, day := range grid.days {
+			for _, leaf := range state.groups.leaves(root) {
+				groupDayPenalties(grid, day, groupDayBlocks(state, leaf, day), units)
+			}
+
+			for _, key := range state.groups.units(id) {
+				booked := bookedBlocks(state.timetables[key][day])
+				for _, slot := range grid.slots(day) {
+					block := booked[slot]
+					if block == nil || booked[slot-1] == block {
+						continue
+					}
+					for _, part := range blockParts(*block) {
+						if blockGroup(part) != key {
+							continue
+						}
+						blockPenalties(grid, state.availability, part, day, slot, units)
+						if !teachersSeen[part.TeacherID] {
+							teachersSeen[part.TeacherID] = true
+							teachers = append(teachers, part.TeacherID)
+						}
+					}
+				}
+			}
+		}
+
+		if set.weights[ConstraintTeacherConsecutive] > 0 {
+			maxConsecutive := set.params[ConstraintTeacherConsecutive]["max_consecutive"]
+			for _, teacherID := range teachers {
+				teacherBits := state.index.teachers[teacherID]
+				if teacherBits == nil {
+					continue
+				}
+				for _, day := range grid.days {
+					units[ConstraintTeacherConsecutive] += teacherDayPenalty(grid, day, teacherBits[day], maxConsecutive)
+				}
+			}
+		}
+
+		add(set.weighted(units))
+	}
+
+	return report
+}
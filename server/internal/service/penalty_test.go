@@ -0,0 +1,195 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"icrogen/internal/models"
+)
+
+func TestGroupDayPenalties(t *testing.T) {
+	grid := defaultTimeGrid()
+	first := &models.ClassBlock{CourseOfferingID: 1, DurationSlots: 2}
+	second := &models.ClassBlock{CourseOfferingID: 1, DurationSlots: 1}
+	other := &models.ClassBlock{CourseOfferingID: 2, DurationSlots: 1}
+
+	tests := []struct {
+		name   string
+		booked map[int]*models.ClassBlock
+		want   map[string]int
+	}{
+		{name: "free day", booked: map[int]*models.ClassBlock{}, want: map[string]int{}},
+		{
+			name:   "one pair mid-morning",
+			booked: map[int]*models.ClassBlock{2: first, 3: first},
+			want:   map[string]int{ConstraintBalancedDays: 1},
+		},
+		{
+			// Slots 1-2, 5 and 7: lunch ends the morning without a gap, slot 6 is idle
+			name:   "full spread",
+			booked: map[int]*models.ClassBlock{1: first, 2: first, 5: second, 7: other},
+			want: map[string]int{
+				ConstraintBalancedDays:   6,
+				ConstraintSpreadSubject:  1,
+				ConstraintStudentGaps:    1,
+				ConstraintAvoidFirstSlot: 1,
+				ConstraintAvoidLastSlot:  1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			units := make(map[string]int)
+			groupDayPenalties(grid, 1, tt.booked, units)
+			if !reflect.DeepEqual(withoutZeros(units), tt.want) {
+				t.Errorf("units = %v, want %v", units, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockPenalties(t *testing.T) {
+	grid := defaultTimeGrid()
+	preferred := &teacherAvailability{preferred: map[uint]*slotBitset{7: {}}}
+	preferred.preferred[7].set(1, 1, 2)
+
+	tests := []struct {
+		name         string
+		availability *teacherAvailability
+		block        models.ClassBlock
+		day, slot    int
+		want         map[string]int
+	}{
+		{name: "theory in the morning", block: models.ClassBlock{DurationSlots: 1}, day: 1, slot: 1, want: map[string]int{}},
+		{name: "theory before lunch", block: models.ClassBlock{DurationSlots: 1}, day: 1, slot: 4, want: map[string]int{ConstraintTheoryMorning: 3}},
+		{name: "theory early afternoon", block: models.ClassBlock{DurationSlots: 1}, day: 1, slot: 5, want: map[string]int{ConstraintTheoryMorning: 1}},
+		{name: "theory last slot", block: models.ClassBlock{DurationSlots: 1}, day: 1, slot: 7, want: map[string]int{ConstraintTheoryMorning: 3}},
+		{name: "lab in the morning", block: models.ClassBlock{DurationSlots: 3, IsLab: true}, day: 1, slot: 2, want: map[string]int{ConstraintLabAfternoon: 1}},
+		{name: "lab in the afternoon on friday", block: models.ClassBlock{DurationSlots: 3, IsLab: true}, day: 5, slot: 5, want: map[string]int{ConstraintAvoidLastDay: 1}},
+		{
+			name:         "partly outside preferred slots",
+			availability: preferred,
+			block:        models.ClassBlock{TeacherID: 7, DurationSlots: 2},
+			day:          1,
+			slot:         2,
+			want:         map[string]int{ConstraintTeacherPreferred: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			units := make(map[string]int)
+			blockPenalties(grid, tt.availability, tt.block, tt.day, tt.slot, units)
+			if !reflect.DeepEqual(withoutZeros(units), tt.want) {
+				t.Errorf("units = %v, want %v", units, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeacherDayPenalty(t *testing.T) {
+	grid := defaultTimeGrid()
+	wholeDay := spanMask(1, 7)
+
+	tests := []struct {
+		name           string
+		bits           uint64
+		maxConsecutive int
+		want           int
+	}{
+		{name: "lunch breaks the run", bits: wholeDay, maxConsecutive: 3, want: 1},
+		{name: "tighter limit", bits: wholeDay, maxConsecutive: 2, want: 3},
+		{name: "within the limit", bits: spanMask(1, 3), maxConsecutive: 3},
+		{name: "turned off", bits: wholeDay, maxConsecutive: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := teacherDayPenalty(grid, 1, tt.bits, tt.maxConsecutive); got != tt.want {
+				t.Errorf("teacherDayPenalty = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSoftConstraintSetOverride(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint models.SoftConstraint
+		wantWeight int
+		wantMaxRun int
+	}{
+		{
+			name:       "weight and parameter",
+			constraint: models.SoftConstraint{Type: ConstraintTeacherConsecutive, Weight: 4, Params: `{"max_consecutive": 2}`, IsActive: true},
+			wantWeight: 4, wantMaxRun: 2,
+		},
+		{
+			name:       "inactive",
+			constraint: models.SoftConstraint{Type: ConstraintTeacherConsecutive, Weight: 4, Params: `{"max_consecutive": 2}`},
+			wantWeight: 10, wantMaxRun: 3,
+		},
+		{
+			name:       "invalid parameters keep the defaults",
+			constraint: models.SoftConstraint{Type: ConstraintTeacherConsecutive, Weight: 0, Params: `{"max_consecutive": "two"}`, IsActive: true},
+			wantWeight: 0, wantMaxRun: 3,
+		},
+		{
+			name:       "unknown type",
+			constraint: models.SoftConstraint{Type: "NO_SUCH_CONSTRAINT", Weight: 4, IsActive: true},
+			wantWeight: 10, wantMaxRun: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := defaultSoftConstraints()
+			set.override(tt.constraint)
+			if _, added := set.weights["NO_SUCH_CONSTRAINT"]; added {
+				t.Errorf("override added an unknown constraint type")
+			}
+			weight := set.weights[ConstraintTeacherConsecutive]
+			maxRun := set.params[ConstraintTeacherConsecutive]["max_consecutive"]
+			if weight != tt.wantWeight || maxRun != tt.wantMaxRun {
+				t.Errorf("weight %d and max_consecutive %d, want %d and %d", weight, maxRun, tt.wantWeight, tt.wantMaxRun)
+			}
+			units := map[string]int{ConstraintTeacherConsecutive: 2, ConstraintSpreadSubject: 1}
+			if got, want := set.total(units), 2*tt.wantWeight+10; got != want {
+				t.Errorf("total = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestValidateSoftConstraintRejects(t *testing.T) {
+	programmeID, departmentID := uint(1), uint(2)
+	tests := []struct {
+		name       string
+		constraint models.SoftConstraint
+	}{
+		{name: "unknown type", constraint: models.SoftConstraint{Type: "NO_SUCH_CONSTRAINT", ProgrammeID: &programmeID}},
+		{name: "negative weight", constraint: models.SoftConstraint{Type: ConstraintSpreadSubject, Weight: -1, ProgrammeID: &programmeID}},
+		{name: "params not an object", constraint: models.SoftConstraint{Type: ConstraintTeacherConsecutive, Params: `[3]`, ProgrammeID: &programmeID}},
+		{name: "unknown parameter", constraint: models.SoftConstraint{Type: ConstraintSpreadSubject, Params: `{"max_consecutive": 3}`, ProgrammeID: &programmeID}},
+		{name: "negative parameter", constraint: models.SoftConstraint{Type: ConstraintTeacherConsecutive, Params: `{"max_consecutive": -1}`, ProgrammeID: &programmeID}},
+		{name: "no scope", constraint: models.SoftConstraint{Type: ConstraintSpreadSubject}},
+		{name: "two scopes", constraint: models.SoftConstraint{Type: ConstraintSpreadSubject, ProgrammeID: &programmeID, DepartmentID: &departmentID}},
+	}
+	service := &softConstraintService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraint := tt.constraint
+			if err := service.validateSoftConstraint(&constraint); err == nil {
+				t.Errorf("validateSoftConstraint accepted %+v", tt.constraint)
+			}
+		})
+	}
+}
+
+// withoutZeros drops the constraint types with no penalty units
+func withoutZeros(units map[string]int) map[string]int {
+	result := make(map[string]int)
+	for constraintType, count := range units {
+		if count != 0 {
+			result[constraintType] = count
+		}
+	}
+	return result
+}
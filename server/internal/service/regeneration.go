@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RegenerationScope says which blocks of a run a regeneration keeps. By
+// default only pinned blocks are kept. In repair mode every block is kept
+// except those of the listed course offerings and teachers, which are solved
+// again; pinned blocks are kept either way.
+type RegenerationScope struct {
+	Repair            bool   `json:"repair"`
+	CourseOfferingIDs []uint `json:"course_offering_ids,omitempty"`
+	TeacherIDs        []uint `json:"teacher_ids,omitempty"`
+}
+
+// keeps reports whether the block stays where it is in the new run
+func (sc RegenerationScope) keeps(block models.ScheduleBlock) bool {
+	if block.IsPinned {
+		return true
+	}
+	if !sc.Repair {
+		return false
+	}
+	return !containsUint(sc.CourseOfferingIDs, block.CourseOfferingID) && !containsUint(sc.TeacherIDs, block.TeacherID)
+}
+
+// RegenerateRoutine generates a new run for the semester offering of a draft
+// or failed run, keeping the blocks the scope selects at their slots with
+// their teachers and rooms and solving the rest around them. Kept blocks that
+// no longer fit, e.g. because their course's pattern or teachers changed, are
+// reported as conflicts and solved again.
+func (s *routineGenerationService) RegenerateRoutine(ctx context.Context, scheduleRunID uint, opts GenerationOptions, scope RegenerationScope) (*models.ScheduleRun, error) {
+	logrus.Info("Starting routine regeneration from schedule run ID: ", scheduleRunID)
+
+	source, err := s.scheduleRepo.GetScheduleRunByID(scheduleRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule run: %w", err)
+	}
+	if source.Status != "DRAFT" && source.Status != "FAILED" {
+		return nil, errors.New("only draft or failed schedule runs can be regenerated")
+	}
+
+	// A combined class is placed with all its semester offerings at once,
+	// which a regeneration of one offering's run cannot do
+	semesterOffering, err := s.semesterOfferingRepo.GetWithCourseOfferings(source.SemesterOfferingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get semester offering: %w", err)
+	}
+	if err := s.checkCombinedClasses([]models.SemesterOffering{*semesterOffering}); err != nil {
+		return nil, fmt.Errorf("schedule run %d cannot be regenerated: %w", source.ID, err)
+	}
+
+	blocks, err := s.runBlocks(source)
+	if err != nil {
+		return nil, err
+	}
+	var kept []models.ScheduleBlock
+	for _, block := range blocks {
+		if scope.keeps(block) {
+			kept = append(kept, block)
+		}
+	}
+
+	return s.generateRoutine(ctx, source.SemesterOfferingID, opts, source, kept)
+}
+
+// runBlocks lists the schedule blocks of a run, including the combined class
+// blocks stored under another run that its entries refer to
+func (s *routineGenerationService) runBlocks(run *models.ScheduleRun) ([]models.ScheduleBlock, error) {
+	blocks := append([]models.ScheduleBlock(nil), run.ScheduleBlocks...)
+	var ids []uint
+	for _, block := range blocks {
+		ids = append(ids, block.ID)
+	}
+	for _, entry := range run.ScheduleEntries {
+		if entry.BlockID == nil || containsUint(ids, *entry.BlockID) {
+			continue
+		}
+		block, err := s.scheduleRepo.GetScheduleBlockByID(*entry.BlockID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schedule block %d: %w", *entry.BlockID, err)
+		}
+		ids = append(ids, block.ID)
+		blocks = append(blocks, *block)
+	}
+	return blocks, nil
+}
+
+// placeFixedBlocks books the blocks kept from the run being regenerated before
+// the solver starts. Each kept schedule block claims a generated block of the
+// same course offering, group, length and kind, which takes its slot, teacher
+// and rooms and is marked fixed. An elective block goes to the slot of the
+// first of its parts that was kept; its other parts take the resources of
+// their own kept blocks at that slot, or are given free ones. An unpinned
+// block whose teacher is no longer assigned to the course is not kept.
+//
+// It returns the blocks left to solve, the fixed blocks placed, and why each
+// kept block that could not be placed was dropped.
+func (s *routineGenerationService) placeFixedBlocks(blocks []models.ClassBlock, kept []models.ScheduleBlock, state *generationState) ([]models.ClassBlock, []models.ClassBlock, []string) {
+	if len(kept) == 0 {
+		return blocks, nil, nil
+	}
+
+	used := make([]bool, len(kept))
+	match := func(part models.ClassBlock, day int, slot int) int {
+		for i, block := range kept {
+			if used[i] || block.CourseOfferingID != part.CourseOfferingID || block.StudentGroupID != part.StudentGroupID ||
+				block.SlotLength != part.DurationSlots || block.IsLab != part.IsLab {
+				continue
+			}
+			if day != 0 && (block.DayOfWeek != day || block.SlotStart != slot) {
+				continue
+			}
+			if !block.IsPinned && !containsUint(part.TeacherCandidates, block.TeacherID) {
+				continue
+			}
+			return i
+		}
+		return -1
+	}
+	keep := func(part models.ClassBlock, block models.ScheduleBlock) models.ClassBlock {
+		part.TeacherID = block.TeacherID
+		part.RoomID = block.RoomID
+		part.SplitRoomIDs = parseRoomIDs(block.SplitRoomIDs)
+		part.Fixed = true
+		part.Pinned = block.IsPinned
+		return part
+	}
+
+	var remaining, fixed []models.ClassBlock
+	var dropped []string
+	for _, block := range blocks {
+		found := -1
+		for _, part := range blockParts(block) {
+			if found = match(part, 0, 0); found >= 0 {
+				break
+			}
+		}
+		if found < 0 {
+			remaining = append(remaining, block)
+			continue
+		}
+		day, slot := kept[found].DayOfWeek, kept[found].SlotStart
+
+		candidate, ok := block, true
+		if len(block.Electives) > 0 {
+			candidate, ok = s.keepElectiveParts(block, day, slot, state, match, used, keep, kept)
+		} else {
+			used[found] = true
+			candidate = keep(block, kept[found])
+		}
+
+		if !ok || !s.canPlaceBlock(candidate, day, slot, state) {
+			dropped = append(dropped, fmt.Sprintf("Kept block %d of course offering %d no longer fits on day %d slot %d and was scheduled again",
+				kept[found].ID, kept[found].CourseOfferingID, day, slot))
+			remaining = append(remaining, block)
+			continue
+		}
+		s.placeBlock(candidate, day, slot, state)
+		fixed = append(fixed, candidate)
+	}
+
+	for i, block := range kept {
+		if !used[i] {
+			dropped = append(dropped, fmt.Sprintf("Kept block %d of course offering %d matches no class being generated and was dropped",
+				block.ID, block.CourseOfferingID))
+		}
+	}
+
+	return remaining, fixed, dropped
+}
+
+// keepElectiveParts gives every part of an elective block placed at the given
+// slot the resources of its kept block there, and free resources to the parts
+// without one
+func (s *routineGenerationService) keepElectiveParts(block models.ClassBlock, day int, slot int, state *generationState,
+	match func(models.ClassBlock, int, int) int, used []bool,
+	keep func(models.ClassBlock, models.ScheduleBlock) models.ClassBlock, kept []models.ScheduleBlock) (models.ClassBlock, bool) {
+	parts := blockParts(block)
+	chosen := make([]models.ClassBlock, len(parts))
+	settled := make([]bool, len(parts))
+	defer func() {
+		for i, part := range chosen {
+			if settled[i] {
+				state.index.releaseResources(part, day, slot)
+			}
+		}
+	}()
+
+	for i, part := range parts {
+		if found := match(part, day, slot); found >= 0 {
+			used[found] = true
+			chosen[i] = keep(part, kept[found])
+			state.index.reserveResources(chosen[i], day, slot)
+			settled[i] = true
+		}
+	}
+	for i, part := range parts {
+		if settled[i] {
+			continue
+		}
+		picked, ok := s.chooseResources(part, day, slot, state)
+		if !ok {
+			return block, false
+		}
+		picked.Fixed = true
+		chosen[i] = picked
+		state.index.reserveResources(picked, day, slot)
+		settled[i] = true
+	}
+
+	result := chosen[0]
+	result.Electives = append([]models.ClassBlock(nil), chosen[1:]...)
+	return result, true
+}
+
+// addFixed counts the blocks kept from the run regenerated from, all placed,
+// and the kept blocks that had to be solved again
+func (r *GenerationReport) addFixed(fixed []models.ClassBlock, dropped []string) {
+	r.TotalBlocks += countParts(fixed)
+	r.PlacedBlocks += countParts(fixed)
+	r.FixedBlocks = countParts(fixed)
+	r.Conflicts = append(r.Conflicts, dropped...)
+	r.blocks = append(r.blocks, fixed...)
+}
+
+// addElectiveSlots counts the blocks placed in the slots their elective group
+// already meets in, and those that fit none of them
+func (r *GenerationReport) addElectiveSlots(placed []models.ClassBlock, unplaced []models.ClassBlock) {
+	r.TotalBlocks += countParts(placed) + countParts(unplaced)
+	r.PlacedBlocks += countParts(placed)
+	r.UnplacedBlocks = append(r.UnplacedBlocks, unplaced...)
+	for _, block := range unplaced {
+		r.Conflicts = append(r.Conflicts, fmt.Sprintf("Block of course offering %d fits none of the committed slots its elective group meets in",
+			block.CourseOfferingID))
+	}
+	r.blocks = append(r.blocks, placed...)
+	r.blocks = append(r.blocks, unplaced...)
+}
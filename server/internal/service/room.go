@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"icrogen/internal/models"
 	"icrogen/internal/repository"
 )
@@ -63,6 +64,12 @@ func (s *roomService) CreateRoom(room *models.Room) error {
 		}
 	}
 	
+	features, err := NormalizeFeatures(room.Features)
+	if err != nil {
+		return err
+	}
+	room.Features = features
+
 	return s.roomRepo.Create(room)
 }
 
@@ -111,6 +118,15 @@ func (s *roomService) UpdateRoom(room *models.Room) error {
 		return errors.New("capacity cannot be negative")
 	}
 	
+	// Empty features were not given and are left as stored
+	if room.Features != "" {
+		features, err := NormalizeFeatures(room.Features)
+		if err != nil {
+			return err
+		}
+		room.Features = features
+	}
+
 	return s.roomRepo.Update(room)
 }
 
@@ -129,11 +145,11 @@ func (s *roomService) CheckRoomAvailability(roomID uint, sessionID uint, dayOfWe
 	if roomID == 0 || sessionID == 0 {
 		return false, errors.New("invalid room ID or session ID")
 	}
-	if dayOfWeek < 1 || dayOfWeek > 5 {
-		return false, errors.New("invalid day of week (1-5)")
+	if dayOfWeek < 1 || dayOfWeek > 7 {
+		return false, errors.New("invalid day of week (1-7)")
 	}
-	if slotNumber < 1 || slotNumber > 8 {
-		return false, errors.New("invalid slot number (1-8)")
+	if slotNumber < 1 || slotNumber > maxGridSlots {
+		return false, fmt.Errorf("invalid slot number (1-%d)", maxGridSlots)
 	}
 	
 	return s.roomRepo.CheckAvailability(roomID, sessionID, dayOfWeek, slotNumber)
@@ -143,11 +159,11 @@ func (s *roomService) GetAvailableRooms(sessionID uint, dayOfWeek int, slotNumbe
 	if sessionID == 0 {
 		return nil, errors.New("invalid session ID")
 	}
-	if dayOfWeek < 1 || dayOfWeek > 5 {
-		return nil, errors.New("invalid day of week (1-5)")
+	if dayOfWeek < 1 || dayOfWeek > 7 {
+		return nil, errors.New("invalid day of week (1-7)")
 	}
-	if slotNumber < 1 || slotNumber > 8 {
-		return nil, errors.New("invalid slot number (1-8)")
+	if slotNumber < 1 || slotNumber > maxGridSlots {
+		return nil, fmt.Errorf("invalid slot number (1-%d)", maxGridSlots)
 	}
 	
 	return s.roomRepo.GetAvailableRooms(sessionID, dayOfWeek, slotNumber, roomType)
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"sort"
+	"strings"
+)
+
+// NormalizeFeatures checks a JSON object of feature names and quantities,
+// e.g. {"projector": 1, "pc": 60}, and returns it with lower-case names. An
+// empty value is an empty object.
+func NormalizeFeatures(value string) (string, error) {
+	features, err := ParseFeatures(value)
+	if err != nil {
+		return "", err
+	}
+	return EncodeFeatures(features), nil
+}
+
+// ParseFeatures reads a features object, folding names to lower case. A
+// quantity of 0 means the feature is present without a count.
+func ParseFeatures(value string) (map[string]int, error) {
+	features := make(map[string]int)
+	if strings.TrimSpace(value) == "" {
+		return features, nil
+	}
+
+	var raw map[string]int
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, errors.New("features must be a JSON object of integer quantities")
+	}
+	for name, quantity := range raw {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return nil, errors.New("feature names cannot be empty")
+		}
+		if quantity < 0 {
+			return nil, fmt.Errorf("feature %q cannot have a negative quantity", name)
+		}
+		features[name] = quantity
+	}
+	return features, nil
+}
+
+// EncodeFeatures stores features as a JSON object
+func EncodeFeatures(features map[string]int) string {
+	if len(features) == 0 {
+		return "{}"
+	}
+	encoded, _ := json.Marshal(features)
+	return string(encoded)
+}
+
+// requiredFeatures returns what rooms need for a subject: its subject type's
+// requirements, with the subject's own overriding them feature by feature
+func requiredFeatures(subject models.Subject) map[string]int {
+	required, _ := ParseFeatures(subject.SubjectType.RequiredFeatures)
+	if required == nil {
+		required = make(map[string]int)
+	}
+	own, _ := ParseFeatures(subject.RequiredFeatures)
+	for name, quantity := range own {
+		required[name] = quantity
+	}
+	return required
+}
+
+// missingFeatures lists, sorted, the required features a room lacks or has
+// too few of, e.g. "fume_hood" or "pc (needs 60, has 40)"
+func missingFeatures(required map[string]int, available map[string]int) []string {
+	var missing []string
+	for name, quantity := range required {
+		has, exists := available[name]
+		switch {
+		case !exists:
+			missing = append(missing, name)
+		case has < quantity:
+			missing = append(missing, fmt.Sprintf("%s (needs %d, has %d)", name, quantity, has))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// roomSuitsSubject returns an error naming what the room lacks of the
+// subject's required features, or nil when it has them all
+func roomSuitsSubject(room *models.Room, subject *models.Subject) error {
+	available, err := ParseFeatures(room.Features)
+	if err != nil {
+		available = make(map[string]int)
+	}
+	if missing := missingFeatures(requiredFeatures(*subject), available); len(missing) > 0 {
+		return fmt.Errorf("room %s lacks features required by %s: %s", room.RoomNumber, subject.Code, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// maxRoomSplit caps how many rooms one group may be split across
+const maxRoomSplit = 4
+
+// groupSize is the number of students a semester offering's classes seat, or 0
+// when its department has no strength recorded
+func groupSize(offering models.SemesterOffering) int {
+	return offering.Department.Strength
+}
+
+// courseGroupSize is the number of students the largest group a course
+// offering is taught to has: its biggest targeted section or batch, or the
+// whole semester offering when it targets none
+func courseGroupSize(offering *models.CourseOffering) int {
+	if len(offering.StudentGroups) == 0 {
+		return groupSize(offering.SemesterOffering)
+	}
+	size := 0
+	for _, group := range offering.StudentGroups {
+		if group.Strength > size {
+			size = group.Strength
+		}
+	}
+	return size
+}
+
+// roomSeatsGroup returns an error when the room is too small for size, the
+// students the course offering's classes seat, and the group may not be split
+// across rooms. A capacity or strength of 0 means it was never recorded and is
+// not checked.
+func roomSeatsGroup(room *models.Room, offering *models.CourseOffering, size int) error {
+	if room.Capacity == 0 || size == 0 || room.Capacity >= size || offering.MaxRoomSplit > 1 {
+		return nil
+	}
+	return fmt.Errorf("room %s seats %d but the group has %d students", room.RoomNumber, room.Capacity, size)
+}
+
+// RoomCapacityIssue is a room assignment whose room seats fewer students than
+// the semester offering's group
+type RoomCapacityIssue struct {
+	RoomAssignmentID   uint   `json:"room_assignment_id"`
+	CourseOfferingID   uint   `json:"course_offering_id"`
+	SemesterOfferingID uint   `json:"semester_offering_id"`
+	SubjectCode        string `json:"subject_code"`
+	RoomID             uint   `json:"room_id"`
+	RoomNumber         string `json:"room_number"`
+	Capacity           int    `json:"capacity"`
+	GroupSize          int    `json:"group_size"`
+	MaxRoomSplit       int    `json:"max_room_split"` // Above 1 the generator may add rooms to seat the group
+}
+
+// roomCatalog is what the generator knows about rooms: those usable when no
+// assigned room is free, the features and capacity of every room a block may
+// use, and what each course offering needs of its rooms
+type roomCatalog struct {
+	fallback map[bool][]uint // Active rooms by IsLab
+	features map[uint]map[string]int
+	capacity map[uint]int
+	required map[uint]map[string]int // By course offering
+	size     map[groupKey]int        // Students to seat, by group
+	maxSplit map[uint]int            // Rooms the group may use at once, by course offering
+}
+
+func newRoomCatalog() *roomCatalog {
+	return &roomCatalog{
+		fallback: make(map[bool][]uint),
+		features: make(map[uint]map[string]int),
+		capacity: make(map[uint]int),
+		required: make(map[uint]map[string]int),
+		size:     make(map[groupKey]int),
+		maxSplit: make(map[uint]int),
+	}
+}
+
+// addRoom records a room's features and capacity
+func (c *roomCatalog) addRoom(room models.Room) {
+	features, err := ParseFeatures(room.Features)
+	if err != nil {
+		features = make(map[string]int)
+	}
+	c.features[room.ID] = features
+	c.capacity[room.ID] = room.Capacity
+}
+
+// addCourseOffering records what the course offering needs of its rooms
+func (c *roomCatalog) addCourseOffering(offering models.CourseOffering) {
+	if required := requiredFeatures(offering.Subject); len(required) > 0 {
+		c.required[offering.ID] = required
+	}
+	if offering.MaxRoomSplit > 1 {
+		c.maxSplit[offering.ID] = offering.MaxRoomSplit
+	}
+}
+
+// addGroup records how many students a group's classes seat
+func (c *roomCatalog) addGroup(group groupKey, size int) {
+	c.size[group] = size
+}
+
+// missing lists the features a course offering needs that the room lacks
+func (c *roomCatalog) missing(courseOfferingID uint, roomID uint) []string {
+	required := c.required[courseOfferingID]
+	if len(required) == 0 {
+		return nil
+	}
+	return missingFeatures(required, c.features[roomID])
+}
+
+// suits reports whether the room has every feature the course offering needs
+func (c *roomCatalog) suits(courseOfferingID uint, roomID uint) bool {
+	return len(c.missing(courseOfferingID, roomID)) == 0
+}
+
+// blockSize is the number of students a block seats: its group's, plus those
+// of the other groups of a combined class. Groups without a recorded strength
+// add nothing.
+func (c *roomCatalog) blockSize(block models.ClassBlock) int {
+	size := c.size[blockGroup(block)]
+	for _, part := range block.Combined {
+		size += c.size[blockGroup(part)]
+	}
+	return size
+}
+
+// seats reports whether the rooms together seat the block's students. Rooms
+// without a recorded capacity count as large enough.
+func (c *roomCatalog) seats(block models.ClassBlock, roomIDs ...uint) bool {
+	size := c.blockSize(block)
+	if size == 0 {
+		return true
+	}
+	total := 0
+	for _, roomID := range roomIDs {
+		if c.capacity[roomID] == 0 {
+			return true
+		}
+		total += c.capacity[roomID]
+	}
+	return total >= size
+}
+
+// canSplit reports whether the course offering's group may use more rooms
+// than it already has
+func (c *roomCatalog) canSplit(courseOfferingID uint, rooms int) bool {
+	return rooms < c.maxSplit[courseOfferingID]
+}
+
+// blockRooms returns every room the block uses, the chosen room first
+func blockRooms(block models.ClassBlock) []uint {
+	if len(block.SplitRoomIDs) == 0 {
+		return []uint{block.RoomID}
+	}
+	return append([]uint{block.RoomID}, block.SplitRoomIDs...)
+}
+
+// encodeRoomIDs stores split rooms as a JSON array, or empty for none
+func encodeRoomIDs(roomIDs []uint) string {
+	if len(roomIDs) == 0 {
+		return ""
+	}
+	encoded, _ := json.Marshal(roomIDs)
+	return string(encoded)
+}
+
+// parseRoomIDs reads the split rooms of a stored entry
+func parseRoomIDs(value string) []uint {
+	var roomIDs []uint
+	if value != "" {
+		_ = json.Unmarshal([]byte(value), &roomIDs)
+	}
+	return roomIDs
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,15 +11,27 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 // RoutineGenerationService interface for routine generation business logic
 type RoutineGenerationService interface {
-	GenerateRoutine(semesterOfferingID uint) (*models.ScheduleRun, error)
+	GenerateRoutine(ctx context.Context, semesterOfferingID uint, opts GenerationOptions) (*models.ScheduleRun, error)
+	GenerateSessionRoutine(ctx context.Context, sessionID uint, filters SessionGenerationFilters, opts GenerationOptions) (*models.SessionScheduleRun, error)
 	CommitScheduleRun(scheduleRunID uint) error
 	CancelScheduleRun(scheduleRunID uint) error
 	GetScheduleRun(scheduleRunID uint) (*models.ScheduleRun, error)
+	GetSessionScheduleRun(sessionScheduleRunID uint) (*models.SessionScheduleRun, error)
 	GetScheduleRunsBySemesterOffering(semesterOfferingID uint) ([]models.ScheduleRun, error)
+	RegenerateRoutine(ctx context.Context, scheduleRunID uint, opts GenerationOptions, scope RegenerationScope) (*models.ScheduleRun, error)
+	StartGenerationJob(semesterOfferingID uint, opts GenerationOptions) (*GenerationJob, error)
+	GetGenerationJob(id uint) (*GenerationJob, error)
+	CancelGenerationJob(id uint) (*GenerationJob, error)
+	MoveScheduleBlock(scheduleRunID uint, blockID uint, move BlockMove) ([]SlotConflict, error)
+	SwapScheduleBlocks(scheduleRunID uint, blockID uint, otherBlockID uint) ([]SlotConflict, error)
+	PinScheduleBlock(scheduleRunID uint, blockID uint, pinned bool) error
+	DeleteScheduleBlock(scheduleRunID uint, blockID uint) error
+	DiffScheduleRuns(scheduleRunID uint, otherRunID uint) (*RunDiff, error)
 }
 
 type routineGenerationService struct {
@@ -27,6 +40,12 @@ type routineGenerationService struct {
 	courseOfferingRepo   repository.CourseOfferingRepository
 	teacherRepo          repository.TeacherRepository
 	roomRepo             repository.RoomRepository
+	timeGridRepo         repository.TimeGridRepository
+	softConstraintRepo   repository.SoftConstraintRepository
+	availabilityRepo     repository.TeacherAvailabilityRepository
+	workloadRepo         repository.TeacherWorkloadRepository
+	jobs                 *generationJobs
+	locks                *scheduleLocks
 }
 
 func NewRoutineGenerationService(
@@ -35,14 +54,26 @@ func NewRoutineGenerationService(
 	courseOfferingRepo repository.CourseOfferingRepository,
 	teacherRepo repository.TeacherRepository,
 	roomRepo repository.RoomRepository,
+	timeGridRepo repository.TimeGridRepository,
+	softConstraintRepo repository.SoftConstraintRepository,
+	availabilityRepo repository.TeacherAvailabilityRepository,
+	workloadRepo repository.TeacherWorkloadRepository,
+	lockRepo repository.ScheduleLockRepository,
 ) RoutineGenerationService {
-	return &routineGenerationService{
+	s := &routineGenerationService{
 		scheduleRepo:         scheduleRepo,
 		semesterOfferingRepo: semesterOfferingRepo,
 		courseOfferingRepo:   courseOfferingRepo,
 		teacherRepo:          teacherRepo,
 		roomRepo:             roomRepo,
+		timeGridRepo:         timeGridRepo,
+		softConstraintRepo:   softConstraintRepo,
+		availabilityRepo:     availabilityRepo,
+		workloadRepo:         workloadRepo,
+		jobs:                 newGenerationJobs(),
 	}
+	s.locks = newScheduleLocks(lockRepo, s.runInProgress)
+	return s
 }
 
 // GenerationReport represents the result of routine generation
@@ -52,13 +83,32 @@ type GenerationReport struct {
 	UnplacedBlocks []models.ClassBlock   `json:"unplaced_blocks"`
 	Conflicts      []string              `json:"conflicts"`
 	Suggestions    []PlacementSuggestion `json:"suggestions"`
+	BlockedSlots   []BlockedSlot         `json:"blocked_slots"` // Committed slots held by this offering's group, teachers or rooms
+	Patterns       []PatternChoice       `json:"patterns"`      // Required pattern finally used per course offering
+	Search         SearchStats           `json:"search"`        // Work done by the solver over every pass
+	Penalties      PenaltyReport         `json:"penalties"`     // Soft-constraint penalty of the timetable, by constraint
+	TeacherLoads   []TeacherLoad         `json:"teacher_loads"` // Session load of every teacher placed, against their workload limits
+	FixedBlocks    int                   `json:"fixed_blocks"`  // Blocks kept in place from the run regenerated from
+
+	blocks []models.ClassBlock // every block that took part, used to split session reports
 }
 
-// PlacementSuggestion represents alternative time slots for unplaced blocks
+// PlacementSuggestion explains why a block could not be placed and where it
+// could go if other placements were moved
 type PlacementSuggestion struct {
 	Block           models.ClassBlock `json:"block"`
-	SuggestedSlots  []TimeSlot        `json:"suggested_slots"`
-	ConflictReasons []string          `json:"conflict_reasons"`
+	SuggestedSlots  []TimeSlot        `json:"suggested_slots"`  // Slots needing the fewest moves, best first
+	ConflictReasons []string          `json:"conflict_reasons"` // How many candidate slots each cause blocks
+	Diagnosis       []SlotDiagnosis   `json:"diagnosis"`        // Every candidate day/slot with what blocks it, best first
+}
+
+// PatternChoice records which required pattern alternative a course offering
+// was finally scheduled with, and the alternatives tried before it
+type PatternChoice struct {
+	SemesterOfferingID uint     `json:"semester_offering_id"`
+	CourseOfferingID   uint     `json:"course_offering_id"`
+	Pattern            string   `json:"pattern"`
+	Tried              []string `json:"tried,omitempty"`
 }
 
 // TimeSlot represents a suggested time slot
@@ -68,58 +118,543 @@ type TimeSlot struct {
 	SlotLength  int `json:"slot_length"`
 }
 
-func (s *routineGenerationService) GenerateRoutine(semesterOfferingID uint) (*models.ScheduleRun, error) {
+// SessionGenerationFilters narrows down which semester offerings of a session
+// take part in a session-wide generation. Empty filters select everything.
+type SessionGenerationFilters struct {
+	ProgrammeIDs    []uint `json:"programme_ids,omitempty"`
+	DepartmentIDs   []uint `json:"department_ids,omitempty"`
+	SemesterNumbers []int  `json:"semester_numbers,omitempty"`
+}
+
+// generationState holds the timetables being filled during one generation pass,
+// one per semester offering and per section or batch so several offerings can
+// be solved together, and the occupancy index of every group, teacher and room
+// in the session
+type generationState struct {
+	sessionID     uint
+	grids         map[uint]*timeGrid          // Time grid of each semester offering
+	constraints   map[uint]*softConstraintSet // Soft-constraint weights of each semester offering
+	timetables    map[groupKey]models.Timetable
+	groups        *groupTree                  // Sections and batches of each semester offering
+	index         *occupancyIndex
+	rooms         *roomCatalog                // Fallback rooms and the features rooms have and courses need
+	availability  *teacherAvailability        // Slots teachers cannot teach or prefer to teach this session
+	workload      *teacherWorkload            // How much each teacher may teach
+	savedBlocks   map[*models.ClassBlock]*models.ScheduleBlock // Schedule block stored for each combined class placement
+	progress      *jobProgress                // Progress of the background job running the generation, if any
+}
+
+func (s *routineGenerationService) newGenerationState(sessionID uint, grids map[uint]*timeGrid, constraints map[uint]*softConstraintSet, availability *teacherAvailability, workload *teacherWorkload, committedEntries []models.ScheduleEntry, groups *groupTree, rooms *roomCatalog) *generationState {
+	state := &generationState{
+		sessionID:     sessionID,
+		grids:         grids,
+		constraints:   constraints,
+		timetables:    make(map[groupKey]models.Timetable),
+		groups:        groups,
+		index:         newOccupancyIndex(committedEntries, groups),
+		rooms:         rooms,
+		availability:  availability,
+		workload:      workload,
+		savedBlocks:   make(map[*models.ClassBlock]*models.ScheduleBlock),
+	}
+	for id, grid := range grids {
+		for _, key := range groups.units(id) {
+			state.timetables[key] = s.initializeTimetable(grid)
+		}
+	}
+	return state
+}
+
+// GenerateRoutine generates a routine for one semester offering. The search
+// stops at the options' time budget, or early when ctx is cancelled, in which
+// case the run is marked CANCELLED and nothing is saved.
+func (s *routineGenerationService) GenerateRoutine(ctx context.Context, semesterOfferingID uint, opts GenerationOptions) (*models.ScheduleRun, error) {
 	logrus.Info("Starting routine generation for semester offering ID: ", semesterOfferingID)
-	
+	return s.generateRoutine(ctx, semesterOfferingID, opts, nil, nil)
+}
+
+// generateRoutine generates a new run for one semester offering. A
+// regeneration passes the run it starts from and the blocks of it to keep in
+// place; the rest are solved around them.
+func (s *routineGenerationService) generateRoutine(ctx context.Context, semesterOfferingID uint, opts GenerationOptions, source *models.ScheduleRun, fixed []models.ScheduleBlock) (*models.ScheduleRun, error) {
+	locks, err := s.offeringLock(semesterOfferingID)
+	if err != nil {
+		return nil, err
+	}
+	defer locks.release()
+
+	scheduleRun, semesterOffering, solver, err := s.createScheduleRun(semesterOfferingID, opts, source, "DRAFT")
+	if err != nil {
+		return nil, err
+	}
+	locks.setRun(scheduleRun.ID, 0)
+	return s.fillScheduleRun(ctx, scheduleRun, semesterOffering, solver, opts, fixed)
+}
+
+// createScheduleRun creates the run a generation for the semester offering
+// fills, with the given status
+func (s *routineGenerationService) createScheduleRun(semesterOfferingID uint, opts GenerationOptions, source *models.ScheduleRun, status string) (*models.ScheduleRun, *models.SemesterOffering, Solver, error) {
+	solver, err := s.newSolver(opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	// Get semester offering with all course offerings
 	semesterOffering, err := s.semesterOfferingRepo.GetWithCourseOfferings(semesterOfferingID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get semester offering: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to get semester offering: %w", err)
 	}
 	
 	// Create a new schedule run
 	scheduleRun := &models.ScheduleRun{
 		SemesterOfferingID: semesterOfferingID,
-		Status:             "DRAFT",
-		AlgorithmVersion:   "v1.0",
+		Status:             status,
+		AlgorithmVersion:   solver.Name(),
 		GeneratedAt:        time.Now(),
 		Meta:               "{}", // Initialize with empty JSON object
 	}
-	
-	if err := s.scheduleRepo.CreateScheduleRun(scheduleRun); err != nil {
-		return nil, fmt.Errorf("failed to create schedule run: %w", err)
+	if source != nil {
+		scheduleRun.SourceRunID = &source.ID
 	}
 	
-	// Generate class blocks from course offerings
-	classBlocks, err := s.generateClassBlocks(semesterOffering.CourseOfferings)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate class blocks: %w", err)
+	if err := s.scheduleRepo.CreateScheduleRun(scheduleRun); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create schedule run: %w", err)
 	}
 	
-	// Initialize timetable
-	timetable := s.initializeTimetable()
-	
+	return scheduleRun, semesterOffering, solver, nil
+}
+
+// fillScheduleRun solves the semester offering and saves the result into its
+// run, marking the run CANCELLED instead when ctx is cancelled. When loading
+// or saving fails the run is marked FAILED with the error.
+func (s *routineGenerationService) fillScheduleRun(ctx context.Context, scheduleRun *models.ScheduleRun, semesterOffering *models.SemesterOffering, solver Solver, opts GenerationOptions, fixed []models.ScheduleBlock) (*models.ScheduleRun, error) {
 	// Load existing committed schedules for the session
 	existingEntries, err := s.scheduleRepo.GetCommittedScheduleEntries(semesterOffering.SessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get existing schedule entries: %w", err)
+		return nil, s.markRunFailed(scheduleRun, fmt.Errorf("failed to get existing schedule entries: %w", err))
 	}
 	
-	// Mark existing committed slots as occupied
-	s.markExistingSlots(timetable, existingEntries)
-	
-	// Run the backtracking algorithm
-	report := s.runBacktrackingAlgorithm(classBlocks, timetable, semesterOffering.SessionID)
-	
-	// Convert timetable to schedule entries
-	scheduleEntries := s.convertTimetableToEntries(timetable, scheduleRun.ID, semesterOffering)
+	grids, err := s.loadTimeGrids([]models.SemesterOffering{*semesterOffering})
+	if err != nil {
+		return nil, s.markRunFailed(scheduleRun, err)
+	}
+
+	constraints, err := s.loadSoftConstraints([]models.SemesterOffering{*semesterOffering})
+	if err != nil {
+		return nil, s.markRunFailed(scheduleRun, err)
+	}
+
+	availability, err := s.loadTeacherAvailability(semesterOffering.SessionID)
+	if err != nil {
+		return nil, s.markRunFailed(scheduleRun, err)
+	}
+
+	workload, err := s.loadTeacherWorkload()
+	if err != nil {
+		return nil, s.markRunFailed(scheduleRun, err)
+	}
+
+	if err := s.checkCombinedClasses([]models.SemesterOffering{*semesterOffering}); err != nil {
+		return nil, s.markRunFailed(scheduleRun, err)
+	}
+
+	electiveSlots, err := s.loadElectiveSlots([]models.SemesterOffering{*semesterOffering}, existingEntries)
+	if err != nil {
+		return nil, s.markRunFailed(scheduleRun, err)
+	}
+
+	solveCtx, cancel := context.WithTimeout(ctx, opts.timeBudget())
+	defer cancel()
+
+	// Expand course offerings into class blocks and run the solver
+	state, report, err := s.solve(solveCtx, solver, semesterOffering.SessionID, grids, constraints, availability, workload, []models.SemesterOffering{*semesterOffering}, existingEntries, fixed, electiveSlots)
+	if err != nil {
+		return nil, s.markRunFailed(scheduleRun, err)
+	}
+
+	if ctx.Err() != nil {
+		s.markRunCancelled(scheduleRun, report)
+		return nil, fmt.Errorf("routine generation cancelled: %w", ctx.Err())
+	}
+
+	generated := s.generatedRun(scheduleRun, semesterOffering, state, report)
+	if err := s.scheduleRepo.SaveGeneratedRuns([]repository.GeneratedRun{generated}); err != nil {
+		return nil, s.markRunFailed(scheduleRun, fmt.Errorf("failed to save schedule run: %w", err))
+	}
+
+	logrus.Info("Routine generation completed. Placed: ", report.PlacedBlocks, "/", report.TotalBlocks)
+
+	return s.scheduleRepo.GetScheduleRunByID(scheduleRun.ID)
+}
+
+// GenerateSessionRoutine generates routines for every DRAFT/ACTIVE semester offering
+// of a session in a single search, so teachers and rooms shared between offerings
+// are divided up together rather than claimed by whichever offering runs first
+func (s *routineGenerationService) GenerateSessionRoutine(ctx context.Context, sessionID uint, filters SessionGenerationFilters, opts GenerationOptions) (*models.SessionScheduleRun, error) {
+	logrus.Info("Starting session-wide routine generation for session ID: ", sessionID)
+
+	solver, err := s.newSolver(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	allOfferings, err := s.semesterOfferingRepo.GetBySession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get semester offerings: %w", err)
+	}
+
+	var offerings []models.SemesterOffering
+	for _, offering := range allOfferings {
+		if offering.Status != "DRAFT" && offering.Status != "ACTIVE" {
+			continue
+		}
+		if filters.matches(offering) {
+			offerings = append(offerings, offering)
+		}
+	}
+
+	if len(offerings) == 0 {
+		return nil, errors.New("no draft or active semester offerings match the given filters")
+	}
+
+	targets := []lockTarget{{LockSession, sessionID}}
+	for _, offering := range offerings {
+		targets = append(targets, lockTarget{LockSemesterOffering, offering.ID})
+	}
+	locks, err := s.locks.acquire(targets...)
+	if err != nil {
+		return nil, err
+	}
+	defer locks.release()
+
+	filtersJSON, _ := json.Marshal(filters)
+	parentRun := &models.SessionScheduleRun{
+		SessionID:        sessionID,
+		Status:           "DRAFT",
+		AlgorithmVersion: solver.Name(),
+		Filters:          string(filtersJSON),
+		GeneratedAt:      time.Now(),
+		Meta:             "{}",
+	}
+
+	if err := s.scheduleRepo.CreateSessionScheduleRun(parentRun); err != nil {
+		return nil, fmt.Errorf("failed to create session schedule run: %w", err)
+	}
+	locks.setRun(0, parentRun.ID)
+
+	// One schedule run per offering, all linked to the parent run
+	scheduleRuns := make([]*models.ScheduleRun, len(offerings))
+	for i := range offerings {
+		scheduleRuns[i] = &models.ScheduleRun{
+			SemesterOfferingID: offerings[i].ID,
+			ParentRunID:        &parentRun.ID,
+			Status:             "DRAFT",
+			AlgorithmVersion:   solver.Name(),
+			GeneratedAt:        parentRun.GeneratedAt,
+			Meta:               "{}",
+		}
+		if err := s.scheduleRepo.CreateScheduleRun(scheduleRuns[i]); err != nil {
+			return nil, s.markSessionRunFailed(parentRun, scheduleRuns[:i],
+				fmt.Errorf("failed to create schedule run for semester offering %d: %w", offerings[i].ID, err))
+		}
+	}
+
+	existingEntries, err := s.scheduleRepo.GetCommittedScheduleEntries(sessionID)
+	if err != nil {
+		return nil, s.markSessionRunFailed(parentRun, scheduleRuns, fmt.Errorf("failed to get existing schedule entries: %w", err))
+	}
+
+	grids, err := s.loadTimeGrids(offerings)
+	if err != nil {
+		return nil, s.markSessionRunFailed(parentRun, scheduleRuns, err)
+	}
+
+	constraints, err := s.loadSoftConstraints(offerings)
+	if err != nil {
+		return nil, s.markSessionRunFailed(parentRun, scheduleRuns, err)
+	}
+
+	availability, err := s.loadTeacherAvailability(sessionID)
+	if err != nil {
+		return nil, s.markSessionRunFailed(parentRun, scheduleRuns, err)
+	}
+
+	workload, err := s.loadTeacherWorkload()
+	if err != nil {
+		return nil, s.markSessionRunFailed(parentRun, scheduleRuns, err)
+	}
+
+	if err := s.checkCombinedClasses(offerings); err != nil {
+		return nil, s.markSessionRunFailed(parentRun, scheduleRuns, err)
+	}
+
+	electiveSlots, err := s.loadElectiveSlots(offerings, existingEntries)
+	if err != nil {
+		return nil, s.markSessionRunFailed(parentRun, scheduleRuns, err)
+	}
+
+	solveCtx, cancel := context.WithTimeout(ctx, opts.timeBudget())
+	defer cancel()
+
+	// Place every offering's blocks in the same search
+	state, report, err := s.solve(solveCtx, solver, sessionID, grids, constraints, availability, workload, offerings, existingEntries, nil, electiveSlots)
+	if err != nil {
+		return nil, s.markSessionRunFailed(parentRun, scheduleRuns, err)
+	}
+
+	if ctx.Err() != nil {
+		for i := range offerings {
+			s.markRunCancelled(scheduleRuns[i], report.forSemesterOffering(offerings[i].ID))
+		}
+		reportJSON, _ := json.Marshal(report)
+		parentRun.Meta = string(reportJSON)
+		parentRun.Status = "CANCELLED"
+		if err := s.scheduleRepo.UpdateSessionScheduleRun(parentRun); err != nil {
+			logrus.Errorf("Failed to mark session schedule run %d cancelled: %v", parentRun.ID, err)
+		}
+		return nil, fmt.Errorf("session routine generation cancelled: %w", ctx.Err())
+	}
+
+	// Every offering's run is saved in one transaction, so combined classes
+	// shared between them are stored together or not at all
+	generated := make([]repository.GeneratedRun, len(offerings))
+	for i := range offerings {
+		offeringReport := report.forSemesterOffering(offerings[i].ID)
+		offeringReport.Penalties = s.evaluatePenalties(state, []uint{offerings[i].ID})
+		offeringReport.TeacherLoads = s.teacherLoads(state, []uint{offerings[i].ID})
+		generated[i] = s.generatedRun(scheduleRuns[i], &offerings[i], state, offeringReport)
+	}
+	if err := s.scheduleRepo.SaveGeneratedRuns(generated); err != nil {
+		return nil, s.markSessionRunFailed(parentRun, scheduleRuns, fmt.Errorf("failed to save schedule runs: %w", err))
+	}
+
+	reportJSON, _ := json.Marshal(report)
+	parentRun.Meta = string(reportJSON)
+	if report.PlacedBlocks == report.TotalBlocks {
+		parentRun.Status = "DRAFT"
+	} else {
+		parentRun.Status = "FAILED"
+	}
+
+	if err := s.scheduleRepo.UpdateSessionScheduleRun(parentRun); err != nil {
+		return nil, fmt.Errorf("failed to update session schedule run: %w", err)
+	}
+
+	logrus.Info("Session routine generation completed. Offerings: ", len(offerings),
+		", placed: ", report.PlacedBlocks, "/", report.TotalBlocks)
 	
-	// Save schedule entries
-	if len(scheduleEntries) > 0 {
-		if err := s.scheduleRepo.CreateScheduleEntries(scheduleEntries); err != nil {
-			return nil, fmt.Errorf("failed to save schedule entries: %w", err)
+	return s.scheduleRepo.GetSessionScheduleRunByID(parentRun.ID)
+}
+
+// solve expands the offerings' course offerings into class blocks and places them. Any
+// course that could not be fully placed is retried with the next alternative of
+// its required pattern until every course is placed or has no alternative left,
+// or until ctx is done. Fixed blocks, kept from a run being regenerated, are
+// placed first on every pass, then blocks of elective groups that already meet
+// in committed slots go into those slots. It fails when linked course
+// offerings cannot meet at once.
+func (s *routineGenerationService) solve(ctx context.Context, solver Solver, sessionID uint, grids map[uint]*timeGrid, constraints map[uint]*softConstraintSet, availability *teacherAvailability, workload *teacherWorkload, offerings []models.SemesterOffering, committedEntries []models.ScheduleEntry, fixed []models.ScheduleBlock, electiveSlots map[uint][]linkSlot) (*generationState, GenerationReport, error) {
+	var courseOfferings []models.CourseOffering
+	for _, offering := range offerings {
+		courseOfferings = append(courseOfferings, offering.CourseOfferings...)
+	}
+	plans := s.planCourseOfferings(courseOfferings)
+	if err := alignLinkedPlans(plans); err != nil {
+		return nil, GenerationReport{}, err
+	}
+	alignToElectiveSlots(plans, electiveSlots)
+	groups := newGroupTree(offerings)
+	rooms := s.loadRoomCatalog(offerings)
+	var stats SearchStats
+
+	for {
+		classBlocks := s.generateClassBlocks(plans)
+		state := s.newGenerationState(sessionID, grids, constraints, availability, workload, committedEntries, groups, rooms)
+		state.progress = progressFrom(ctx)
+		state.progress.startPass(countParts(classBlocks))
+		classBlocks, kept, dropped := s.placeFixedBlocks(classBlocks, fixed, state)
+		classBlocks, aligned, misaligned := s.placeAtElectiveSlots(classBlocks, electiveSlots, state)
+		report := s.runSolver(ctx, solver, classBlocks, state)
+		report.addFixed(kept, dropped)
+		report.addElectiveSlots(aligned, misaligned)
+		stats.add(report.Search)
+
+		unplacedCourses := make(map[uint]bool)
+		for _, block := range report.UnplacedBlocks {
+			for _, part := range blockParts(block) {
+				unplacedCourses[part.CourseOfferingID] = true
+			}
+		}
+
+		retry := false
+		for _, plan := range plans {
+			if unplacedCourses[plan.offering.ID] && plan.current < len(plan.alternatives)-1 {
+				logrus.Infof("Course offering %d could not be placed with pattern %s, trying %s",
+					plan.offering.ID, formatPattern(plan.alternatives[plan.current]), formatPattern(plan.alternatives[plan.current+1]))
+				plan.current++
+				retry = true
+			}
+		}
+
+		if !retry || ctx.Err() != nil {
+			report.Patterns = s.patternChoices(plans)
+			report.Search = stats
+			report.Penalties = s.evaluatePenalties(state, semesterOfferingIDs(grids))
+			report.TeacherLoads = s.teacherLoads(state, semesterOfferingIDs(grids))
+			return state, report, nil
 		}
 	}
+}
+
+// loadRoomCatalog returns the active rooms a block may use when none of its
+// assigned rooms is free (lab rooms for labs and theory rooms for theory), the
+// features and capacity of those and the assigned rooms, and what each course
+// offering needs of a room: its subject's features and seats for its group
+func (s *routineGenerationService) loadRoomCatalog(offerings []models.SemesterOffering) *roomCatalog {
+	catalog := newRoomCatalog()
+
+	rooms, err := s.roomRepo.GetAll()
+	if err != nil {
+		logrus.Warnf("Failed to load rooms for fallback allocation: %v", err)
+	}
+	for _, room := range rooms {
+		catalog.addRoom(room)
+		switch room.Type {
+		case "LAB":
+			catalog.fallback[true] = append(catalog.fallback[true], room.ID)
+		case "THEORY":
+			catalog.fallback[false] = append(catalog.fallback[false], room.ID)
+		}
+	}
+
+	for _, semesterOffering := range offerings {
+		for _, offering := range semesterOffering.CourseOfferings {
+			// Assigned rooms may be inactive and so missing from GetAll
+			for _, assignment := range offering.RoomAssignments {
+				if _, ok := catalog.features[assignment.RoomID]; !ok && assignment.Room.ID != 0 {
+					catalog.addRoom(assignment.Room)
+				}
+			}
+			catalog.addCourseOffering(offering)
+		}
+		catalog.addGroup(groupKey{semesterOffering.ID, 0}, groupSize(semesterOffering))
+		for _, group := range semesterOffering.StudentGroups {
+			catalog.addGroup(groupKey{semesterOffering.ID, group.ID}, group.Strength)
+		}
+	}
+
+	return catalog
+}
+
+// loadTimeGrids returns the time grid of each semester offering: its
+// programme's active grid when it has one, otherwise the default grid from the
+// time slots that belong to no grid, or the built-in grid when there are none
+func (s *routineGenerationService) loadTimeGrids(offerings []models.SemesterOffering) (map[uint]*timeGrid, error) {
+	defaultSlots, err := s.timeGridRepo.GetDefaultTimeSlots()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time slots: %w", err)
+	}
+	defaultGrid := defaultTimeGrid()
+	if len(defaultSlots) > 0 {
+		defaultGrid = newTimeGrid(defaultSlots)
+	}
+
+	grids := make(map[uint]*timeGrid)
+	programmeGrids := make(map[uint]*timeGrid)
+	for _, offering := range offerings {
+		grid, loaded := programmeGrids[offering.ProgrammeID]
+		if !loaded {
+			grid = defaultGrid
+			programmeGrid, err := s.timeGridRepo.GetByProgrammeID(offering.ProgrammeID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("failed to get time grid for programme %d: %w", offering.ProgrammeID, err)
+			}
+			if programmeGrid != nil {
+				grid = newTimeGrid(programmeGrid.TimeSlots)
+			}
+			programmeGrids[offering.ProgrammeID] = grid
+		}
+		grids[offering.ID] = grid
+	}
+
+	return grids, nil
+}
+
+// loadSoftConstraints returns the soft-constraint weights of each semester
+// offering: the built-in weights, overridden by its programme's settings and
+// then by its department's
+func (s *routineGenerationService) loadSoftConstraints(offerings []models.SemesterOffering) (map[uint]*softConstraintSet, error) {
+	sets := make(map[uint]*softConstraintSet)
+
+	for _, offering := range offerings {
+		set := defaultSoftConstraints()
+
+		programmeConstraints, err := s.softConstraintRepo.GetByProgrammeID(offering.ProgrammeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get soft constraints for programme %d: %w", offering.ProgrammeID, err)
+		}
+		for _, constraint := range programmeConstraints {
+			set.override(constraint)
+		}
+
+		departmentConstraints, err := s.softConstraintRepo.GetByDepartmentID(offering.DepartmentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get soft constraints for department %d: %w", offering.DepartmentID, err)
+		}
+		for _, constraint := range departmentConstraints {
+			set.override(constraint)
+		}
+
+		sets[offering.ID] = set
+	}
+
+	return sets, nil
+}
+
+// loadTeacherAvailability resolves every teacher's weekly availability and
+// the overrides set for the session
+func (s *routineGenerationService) loadTeacherAvailability(sessionID uint) (*teacherAvailability, error) {
+	rows, err := s.availabilityRepo.GetForSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get teacher availability: %w", err)
+	}
+	return newTeacherAvailability(rows), nil
+}
+
+// loadTeacherWorkload resolves the workload limits of every active teacher
+func (s *routineGenerationService) loadTeacherWorkload() (*teacherWorkload, error) {
+	teachers, err := s.teacherRepo.GetActive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get teachers: %w", err)
+	}
+	rows, err := s.workloadRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get teacher workload limits: %w", err)
+	}
+	return newTeacherWorkload(teachers, rows), nil
+}
+
+// semesterOfferingIDs returns the offerings a set of grids was loaded for
+func semesterOfferingIDs(grids map[uint]*timeGrid) []uint {
+	ids := make([]uint, 0, len(grids))
+	for id := range grids {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// generatedRun collects the blocks and entries placed for one semester
+// offering and records the generation report on its schedule run, ready to be
+// saved
+func (s *routineGenerationService) generatedRun(scheduleRun *models.ScheduleRun, semesterOffering *models.SemesterOffering, state *generationState, report GenerationReport) repository.GeneratedRun {
+	generated := repository.GeneratedRun{Run: scheduleRun}
+
+	// Convert the timetables of the offering and each of its groups to schedule blocks and entries
+	for _, unit := range state.groups.units(semesterOffering.ID) {
+		blocks, entries := s.convertTimetableToEntries(state.grids[semesterOffering.ID], unit, state.timetables[unit], scheduleRun.ID, semesterOffering, state.savedBlocks)
+		generated.Blocks = append(generated.Blocks, blocks...)
+		generated.Entries = append(generated.Entries, entries...)
+	}
 	
 	// Update schedule run with report
 	reportJSON, _ := json.Marshal(report)
@@ -131,23 +666,142 @@ func (s *routineGenerationService) GenerateRoutine(semesterOfferingID uint) (*mo
 		scheduleRun.Status = "FAILED" // Partial solution
 	}
 	
-	if err := s.scheduleRepo.UpdateScheduleRun(scheduleRun); err != nil {
-		return nil, fmt.Errorf("failed to update schedule run: %w", err)
+	return generated
+}
+
+// markSessionRunFailed records why a session-wide generation stopped before
+// its runs were saved on the parent run and every run created for it, and
+// returns that cause
+func (s *routineGenerationService) markSessionRunFailed(parentRun *models.SessionScheduleRun, scheduleRuns []*models.ScheduleRun, cause error) error {
+	for _, scheduleRun := range scheduleRuns {
+		s.markRunFailed(scheduleRun, cause)
 	}
 	
-	logrus.Info("Routine generation completed. Placed: ", report.PlacedBlocks, "/", report.TotalBlocks)
+	meta, _ := json.Marshal(map[string]string{"error": cause.Error()})
+	parentRun.Meta = string(meta)
+	parentRun.Status = "FAILED"
+	if err := s.scheduleRepo.UpdateSessionScheduleRun(parentRun); err != nil {
+		logrus.Errorf("Failed to mark session schedule run %d failed: %v", parentRun.ID, err)
+	}
+	return cause
+}
+
+// markRunCancelled records the report of a generation abandoned by the caller
+// without saving any of its entries
+func (s *routineGenerationService) markRunCancelled(scheduleRun *models.ScheduleRun, report GenerationReport) {
+	reportJSON, _ := json.Marshal(report)
+	scheduleRun.Meta = string(reportJSON)
+	scheduleRun.Status = "CANCELLED"
+
+	if err := s.scheduleRepo.UpdateScheduleRun(scheduleRun); err != nil {
+		logrus.Errorf("Failed to mark schedule run %d cancelled: %v", scheduleRun.ID, err)
+	}
+}
+
+func (f SessionGenerationFilters) matches(offering models.SemesterOffering) bool {
+	if len(f.ProgrammeIDs) > 0 && !containsUint(f.ProgrammeIDs, offering.ProgrammeID) {
+		return false
+	}
+	if len(f.DepartmentIDs) > 0 && !containsUint(f.DepartmentIDs, offering.DepartmentID) {
+		return false
+	}
+	if len(f.SemesterNumbers) > 0 {
+		found := false
+		for _, n := range f.SemesterNumbers {
+			if n == offering.SemesterNumber {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsUint(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// forSemesterOffering narrows a combined report down to one semester offering
+func (r GenerationReport) forSemesterOffering(semesterOfferingID uint) GenerationReport {
+	report := GenerationReport{
+		UnplacedBlocks: []models.ClassBlock{},
+		Conflicts:      r.Conflicts,
+		Suggestions:    []PlacementSuggestion{},
+		BlockedSlots:   []BlockedSlot{},
+		Patterns:       []PatternChoice{},
+		Search:         r.Search, // The search was shared, so its stats are too
+	}
 	
-	return s.scheduleRepo.GetScheduleRunByID(scheduleRun.ID)
+	// An elective block counts for every offering taking part in it
+	for _, block := range r.blocks {
+		for _, part := range blockParts(block) {
+			if part.SemesterOfferingID == semesterOfferingID {
+				report.TotalBlocks++
+			}
+		}
+	}
+	unplaced := 0
+	for _, block := range r.UnplacedBlocks {
+		included := false
+		for _, part := range blockParts(block) {
+			if part.SemesterOfferingID == semesterOfferingID {
+				unplaced++
+				included = true
+			}
+		}
+		if included {
+			report.UnplacedBlocks = append(report.UnplacedBlocks, block)
+		}
+	}
+	for _, suggestion := range r.Suggestions {
+		if suggestion.Block.SemesterOfferingID == semesterOfferingID {
+			report.Suggestions = append(report.Suggestions, suggestion)
+		}
+	}
+	for _, blocked := range r.BlockedSlots {
+		if blocked.SemesterOfferingID == semesterOfferingID {
+			report.BlockedSlots = append(report.BlockedSlots, blocked)
+		}
+	}
+	for _, choice := range r.Patterns {
+		if choice.SemesterOfferingID == semesterOfferingID {
+			report.Patterns = append(report.Patterns, choice)
+		}
+	}
+	report.PlacedBlocks = report.TotalBlocks - unplaced
+
+	return report
 }
 
-func (s *routineGenerationService) generateClassBlocks(courseOfferings []models.CourseOffering) ([]models.ClassBlock, error) {
-	var blocks []models.ClassBlock
+// coursePlan holds the required pattern alternatives of a course offering
+// and which of them the generator is currently trying
+type coursePlan struct {
+	offering     models.CourseOffering
+	alternatives [][]int
+	current      int
+}
+
+func (s *routineGenerationService) planCourseOfferings(courseOfferings []models.CourseOffering) []*coursePlan {
+	var plans []*coursePlan
 	
 	for _, offering := range courseOfferings {
-		// Determine how many blocks needed based on weekly required slots
-		weeklySlots := offering.WeeklyRequiredSlots
-		isLab := offering.IsLab
-		
 		// Get assigned teachers and rooms
 		if len(offering.TeacherAssignments) == 0 {
 			logrus.Warnf("No teachers assigned to course offering %d (subject: %s), skipping", 
@@ -161,105 +815,176 @@ func (s *routineGenerationService) generateClassBlocks(courseOfferings []models.
 			continue // Skip this offering instead of failing
 		}
 		
-		// Use first assigned teacher and room (can be enhanced for multiple assignments)
-		teacherID := offering.TeacherAssignments[0].TeacherID
-		roomID := offering.RoomAssignments[0].RoomID
+		alternatives, err := patternAlternatives(offering)
+		if err != nil {
+			logrus.Warnf("Course offering %d has an unusable required pattern %q (%v), using defaults",
+				offering.ID, offering.RequiredPattern, err)
+		}
+		if len(alternatives) == 0 {
+			logrus.Warnf("No pattern available for course offering %d (subject: %s), skipping",
+				offering.ID, offering.Subject.Name)
+			continue
+		}
+
+		plans = append(plans, &coursePlan{
+			offering:     offering,
+			alternatives: alternatives,
+		})
+	}
+
+	return plans
+}
+
+func (s *routineGenerationService) generateClassBlocks(plans []*coursePlan) []models.ClassBlock {
+	var blocks []models.ClassBlock
+
+	for _, plan := range plans {
+		offering := plan.offering
+
+		// Every assigned teacher and room is a candidate for each block. Blocks are
+		// shared out between teachers in proportion to their weight, so two equally
+		// weighted co-teachers alternate the blocks of a 2+2 pattern.
+		lengths := plan.alternatives[plan.current]
+		teachers := sortedTeacherAssignments(offering.TeacherAssignments)
+		roomCandidates := sortedRoomCandidates(offering.RoomAssignments)
 		
-		if isLab {
-			// Labs are typically 3-hour blocks, once per week
-			block := models.ClassBlock{
-				SubjectID:          offering.SubjectID,
-				TeacherID:          teacherID,
-				RoomID:             roomID,
-				DurationSlots:      3,
-				IsLab:              true,
-				SemesterOfferingID: offering.SemesterOfferingID,
-				CourseOfferingID:   offering.ID,
-			}
-			blocks = append(blocks, block)
-		} else {
-			// Theory subjects - create blocks based on credit and weekly load
-			// Apply patterns from DESIGN document
-			patterns := s.getTheoryPatterns(weeklySlots, offering.Subject.Credit)
-			
-			for _, slotLength := range patterns {
+		// The pattern is taught to each targeted section or batch, or to the whole
+		// offering when none is targeted. Sharing all the blocks out at once
+		// gives parallel batches different teachers where there are enough.
+		targets := courseGroupIDs(offering)
+		preferredTeachers := splitByWeight(teachers, len(lengths)*len(targets))
+
+		// One block per part of the pattern currently tried, e.g. 2+2 gives two 2-slot blocks
+		for t, studentGroupID := range targets {
+			for i, slotLength := range lengths {
+				preferred := preferredTeachers[t*len(lengths)+i]
+				teacherCandidates := []uint{preferred}
+				for _, assignment := range teachers {
+					if assignment.TeacherID != preferred {
+						teacherCandidates = append(teacherCandidates, assignment.TeacherID)
+					}
+				}
+
 				block := models.ClassBlock{
 					SubjectID:          offering.SubjectID,
-					TeacherID:          teacherID,
-					RoomID:             roomID,
+					TeacherID:          preferred,
+					RoomID:             roomCandidates[0],
 					DurationSlots:      slotLength,
-					IsLab:              false,
+					IsLab:              offering.IsLab,
 					SemesterOfferingID: offering.SemesterOfferingID,
+					StudentGroupID:     studentGroupID,
 					CourseOfferingID:   offering.ID,
+					TeacherCandidates:  teacherCandidates,
+					RoomCandidates:     roomCandidates,
 				}
 				blocks = append(blocks, block)
 			}
 		}
 	}
 	
-	return blocks, nil
+	return bundleLinked(blocks, plans)
 }
 
-// getTheoryPatterns returns the pattern of slot lengths for theory subjects
-// Based on DESIGN_v1.md credit-to-sessions mapping
-func (s *routineGenerationService) getTheoryPatterns(weeklySlots int, credit int) []int {
-	var patterns []int
-	
-	switch credit {
-	case 4:
-		// Credit 4: prefer 2+2, fallback 2+1+1
-		if weeklySlots >= 4 {
-			patterns = []int{2, 2}
-		} else if weeklySlots == 3 {
-			patterns = []int{2, 1}
-		} else {
-			for i := 0; i < weeklySlots; i++ {
-				patterns = append(patterns, 1)
-			}
+// sortedTeacherAssignments orders assignments by weight (heaviest first)
+func sortedTeacherAssignments(assignments []models.TeacherAssignment) []models.TeacherAssignment {
+	sorted := make([]models.TeacherAssignment, len(assignments))
+	copy(sorted, assignments)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if assignmentWeight(sorted[i]) != assignmentWeight(sorted[j]) {
+			return assignmentWeight(sorted[i]) > assignmentWeight(sorted[j])
 		}
-	case 3:
-		// Credit 3: prefer 2+1, fallback 1+1+1
-		if weeklySlots >= 3 {
-			patterns = []int{2, 1}
-		} else {
-			for i := 0; i < weeklySlots; i++ {
-				patterns = append(patterns, 1)
+		return sorted[i].TeacherID < sorted[j].TeacherID
+	})
+	return sorted
+}
+
+func assignmentWeight(assignment models.TeacherAssignment) int {
+	if assignment.Weight <= 0 {
+		return 1
+	}
+	return assignment.Weight
+}
+
+// splitByWeight picks a teacher for each of n blocks so that every teacher gets
+// a share of the blocks proportional to its assignment weight
+func splitByWeight(assignments []models.TeacherAssignment, n int) []uint {
+	result := make([]uint, n)
+	given := make([]int, len(assignments))
+
+	totalWeight := 0
+	for _, assignment := range assignments {
+		totalWeight += assignmentWeight(assignment)
+	}
+
+	for i := 0; i < n; i++ {
+		best := 0
+		bestDeficit := 0.0
+		for j, assignment := range assignments {
+			// How far this teacher is behind its fair share after i+1 blocks
+			deficit := float64(assignmentWeight(assignment)*(i+1))/float64(totalWeight) - float64(given[j])
+			if j == 0 || deficit > bestDeficit {
+				best = j
+				bestDeficit = deficit
 			}
 		}
-	case 2:
-		// Credit 2: prefer consecutive 2, fallback 1+1
-		if weeklySlots >= 2 {
-			patterns = []int{2}
-		} else {
-			patterns = []int{1}
-		}
-	default:
-		// Default pattern: split into 2-slot blocks where possible
-		remaining := weeklySlots
-		for remaining > 0 {
-			if remaining >= 2 {
-				patterns = append(patterns, 2)
-				remaining -= 2
-			} else {
-				patterns = append(patterns, 1)
-				remaining--
-			}
+		given[best]++
+		result[i] = assignments[best].TeacherID
+	}
+
+	return result
+}
+
+// sortedRoomCandidates returns the assigned active rooms by priority (1 = highest)
+func sortedRoomCandidates(assignments []models.RoomAssignment) []uint {
+	sorted := make([]models.RoomAssignment, 0, len(assignments))
+	for _, assignment := range assignments {
+		if assignment.Room.ID != 0 && !assignment.Room.IsActive {
+			continue
 		}
+		sorted = append(sorted, assignment)
 	}
-	
-	return patterns
+	if len(sorted) == 0 {
+		sorted = append(sorted, assignments...) // Keep the inactive ones rather than none
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority < sorted[j].Priority
+		}
+		return sorted[i].RoomID < sorted[j].RoomID
+	})
+
+	roomIDs := make([]uint, len(sorted))
+	for i, assignment := range sorted {
+		roomIDs[i] = assignment.RoomID
+	}
+	return roomIDs
 }
 
-func (s *routineGenerationService) initializeTimetable() models.Timetable {
+// patternChoices reports the pattern each course offering ended up with
+func (s *routineGenerationService) patternChoices(plans []*coursePlan) []PatternChoice {
+	choices := make([]PatternChoice, 0, len(plans))
+	for _, plan := range plans {
+		choice := PatternChoice{
+			SemesterOfferingID: plan.offering.SemesterOfferingID,
+			CourseOfferingID:   plan.offering.ID,
+			Pattern:            formatPattern(plan.alternatives[plan.current]),
+		}
+		for i := 0; i < plan.current; i++ {
+			choice.Tried = append(choice.Tried, formatPattern(plan.alternatives[i]))
+		}
+		choices = append(choices, choice)
+	}
+	return choices
+}
+
+func (s *routineGenerationService) initializeTimetable(grid *timeGrid) models.Timetable {
 	timetable := make(models.Timetable)
 	
-	// Initialize for Monday to Friday (1-5), 7 slots per day
-	// Slots 1-4: Morning (9:00-12:40)
-	// Lunch break: 12:40-13:50 (not a slot)
-	// Slots 5-7: Afternoon (13:50-16:35)
-	for day := 1; day <= 5; day++ {
+	// One entry per slot of each teaching day in the grid
+	for _, day := range grid.days {
 		timetable[day] = make(map[int]models.TimeSlotInfo)
-		for slot := 1; slot <= 7; slot++ {
+		for _, slot := range grid.slots(day) {
 			timetable[day][slot] = models.TimeSlotInfo{
 				IsBooked: false,
 				Block:    nil,
@@ -270,382 +995,356 @@ func (s *routineGenerationService) initializeTimetable() models.Timetable {
 	return timetable
 }
 
-func (s *routineGenerationService) markExistingSlots(timetable models.Timetable, existingEntries []models.ScheduleEntry) {
-	for _, entry := range existingEntries {
-		if daySlots, exists := timetable[entry.DayOfWeek]; exists {
-			if _, slotExists := daySlots[entry.SlotNumber]; slotExists {
-				timetable[entry.DayOfWeek][entry.SlotNumber] = models.TimeSlotInfo{
-					IsBooked: true,
-					Block:    nil, // External block
-				}
-			}
-		}
-	}
-}
-
-func (s *routineGenerationService) runBacktrackingAlgorithm(blocks []models.ClassBlock, timetable models.Timetable, sessionID uint) GenerationReport {
+// runSolver places the blocks with the given solver and reports what it could not place
+func (s *routineGenerationService) runSolver(ctx context.Context, solver Solver, blocks []models.ClassBlock, state *generationState) GenerationReport {
 	report := GenerationReport{
-		TotalBlocks:    len(blocks),
+		TotalBlocks:    countParts(blocks),
 		PlacedBlocks:   0,
 		UnplacedBlocks: []models.ClassBlock{},
 		Conflicts:      []string{},
 		Suggestions:    []PlacementSuggestion{},
+		BlockedSlots:   state.index.blockedSlots(blocks),
+		blocks:         blocks,
 	}
 	
-	// Sort blocks by constraint priority (most constrained first)
-	s.sortBlocksByConstraints(blocks)
+	started := time.Now()
+	unplaced, stats := solver.Solve(ctx, blocks, state)
+	stats.ElapsedMs = time.Since(started).Milliseconds()
+	report.PlacedBlocks = report.TotalBlocks - countParts(unplaced)
+	report.Search = stats
 	
-	placedBlocks := s.backtrack(blocks, 0, timetable, sessionID)
-	report.PlacedBlocks = placedBlocks
-	
-	// Identify unplaced blocks
-	for i := placedBlocks; i < len(blocks); i++ {
-		report.UnplacedBlocks = append(report.UnplacedBlocks, blocks[i])
-		
-		// Generate suggestions for unplaced blocks
-		suggestions := s.generatePlacementSuggestions(blocks[i], timetable, sessionID)
-		report.Suggestions = append(report.Suggestions, PlacementSuggestion{
-			Block:           blocks[i],
-			SuggestedSlots:  suggestions,
-			ConflictReasons: []string{"No available slot found"},
-		})
+	// Diagnose every unplaced block against the finished timetables
+	var holders *slotHolders
+	if len(unplaced) > 0 {
+		holders = s.newSlotHolders(state)
+	}
+	for _, block := range unplaced {
+		report.UnplacedBlocks = append(report.UnplacedBlocks, block)
+		report.Suggestions = append(report.Suggestions, suggestPlacement(block, s.diagnoseBlock(block, state, holders)))
 	}
 	
 	return report
 }
 
-func (s *routineGenerationService) sortBlocksByConstraints(blocks []models.ClassBlock) {
-	sort.Slice(blocks, func(i, j int) bool {
-		// Labs first (more constrained - need 3 consecutive slots)
-		if blocks[i].IsLab && !blocks[j].IsLab {
-			return true
-		}
-		if !blocks[i].IsLab && blocks[j].IsLab {
-			return false
-		}
-		
-		// Then by duration (longer blocks first)
-		if blocks[i].DurationSlots != blocks[j].DurationSlots {
-			return blocks[i].DurationSlots > blocks[j].DurationSlots
-		}
-		
-		// Then by teacher ID (group by teacher)
-		return blocks[i].TeacherID < blocks[j].TeacherID
-	})
+// scorePlacement scores a potential placement (higher = better) by how little
+// it adds to the soft-constraint penalty
+func (s *routineGenerationService) scorePlacement(block models.ClassBlock, day int, slot int, state *generationState) int {
+	return -s.softPenalty(block, day, slot, state)
 }
 
-func (s *routineGenerationService) backtrack(blocks []models.ClassBlock, index int, timetable models.Timetable, sessionID uint) int {
-	// Base case: all blocks placed
-	if index >= len(blocks) {
-		return index
-	}
-	
-	currentBlock := blocks[index]
-	
-	// Get valid slot candidates for this block type
-	slotCandidates := s.getSlotCandidates(currentBlock)
-	
-	// Try all possible placements with scoring
-	type placement struct {
-		day   int
-		slot  int
-		score int
-	}
-	
-	var validPlacements []placement
-	
-	for day := 1; day <= 5; day++ {
-		for _, slot := range slotCandidates {
-			if s.canPlaceBlock(currentBlock, day, slot, timetable, sessionID) {
-				score := s.scorePlacement(currentBlock, day, slot, timetable)
-				validPlacements = append(validPlacements, placement{day, slot, score})
-			}
-		}
-	}
-	
-	// Sort by score (higher score = better placement)
-	sort.Slice(validPlacements, func(i, j int) bool {
-		return validPlacements[i].score > validPlacements[j].score
-	})
-	
-	// Try placements in order of preference
-	for _, p := range validPlacements {
-		// Place the block
-		s.placeBlock(currentBlock, p.day, p.slot, timetable)
-		
-		// Recurse
-		result := s.backtrack(blocks, index+1, timetable, sessionID)
-		if result > index {
-			return result // Found a solution
-		}
-		
-		// Backtrack
-		s.removeBlock(currentBlock, p.day, p.slot, timetable)
+// placementScore is the score of a placement with the block's teacher and
+// room. An elective block or combined class scores as the sum of its parts,
+// though a combined class's shared teacher and room only count once.
+func (s *routineGenerationService) placementScore(block models.ClassBlock, day int, slot int, state *generationState) int {
+	score := -s.resourcePenalty(block)
+	for _, part := range blockParts(block) {
+		score += s.scorePlacement(part, day, slot, state)
 	}
-	
-	// No placement found for this block
-	return index
-}
-
-// getSlotCandidates returns valid starting slots for a block
-func (s *routineGenerationService) getSlotCandidates(block models.ClassBlock) []int {
-	if block.IsLab && block.DurationSlots == 3 {
-		// Labs: slots 2-4 (morning 09:55-12:40) or 5-7 (afternoon 13:50-16:35)
-		return []int{2, 5} // Morning or afternoon lab slots
-	} else if block.DurationSlots == 2 {
-		// 2-slot blocks: avoid spanning lunch
-		return []int{1, 2, 3, 5, 6} // Can start at 1-3 (morning) or 5-6 (afternoon)
-	} else {
-		// Single slots: all available
-		return []int{1, 2, 3, 4, 5, 6, 7}
+	for _, part := range block.Electives {
+		score -= s.resourcePenalty(part)
 	}
+	return score
 }
 
-// scorePlacement scores a potential placement (higher = better)
-func (s *routineGenerationService) scorePlacement(block models.ClassBlock, day int, slot int, timetable models.Timetable) int {
-	score := 100
-	
-	// Prefer spreading classes across the week
-	slotsOnDay := 0
-	for s := 1; s <= 7; s++ {
-		if daySlots, exists := timetable[day]; exists {
-			if slotInfo, slotExists := daySlots[s]; slotExists && slotInfo.IsBooked {
-				slotsOnDay++
-			}
-		}
-	}
-	score -= slotsOnDay * 5
-	
-	// Labs: prefer afternoon
-	if block.IsLab && slot >= 5 {
-		score += 20
-	}
-	
-	// Theory: prefer morning and early afternoon
-	if !block.IsLab {
-		if slot <= 3 {
-			score += 15
-		} else if slot == 5 || slot == 6 {
-			score += 10
+// canPlaceBlock reports whether the block, with its teacher and rooms, can
+// start at the given slot. A combined class must fit every group it is taught
+// to, and its rooms must seat them all together.
+func (s *routineGenerationService) canPlaceBlock(block models.ClassBlock, day int, startSlot int, state *generationState) bool {
+	for _, part := range blockParts(block) {
+		if !s.canPlacePart(part, day, startSlot, state) {
+			return false
 		}
 	}
-	
-	// Avoid late slots
-	if slot >= 7 {
-		score -= 10
+	return state.rooms.seats(block, blockRooms(block)...)
+}
+
+// canPlacePart checks one course offering's share of a block
+func (s *routineGenerationService) canPlacePart(block models.ClassBlock, day int, startSlot int, state *generationState) bool {
+	grid := state.grids[block.SemesterOfferingID]
+	timetable := state.timetables[blockGroup(block)]
+
+	// The block must fit in the grid without running across a break
+	if !grid.fits(day, startSlot, block.DurationSlots) {
+		return false
 	}
 	
-	// Prefer Monday-Thursday over Friday
-	if day == 5 {
-		score -= 5
+	// Labs may only start in the grid's lab windows
+	if block.IsLab && !grid.allowsLabStart(day, startSlot) {
+		return false
 	}
-	
-	return score
-}
 
-func (s *routineGenerationService) canPlaceBlock(block models.ClassBlock, day int, startSlot int, timetable models.Timetable, sessionID uint) bool {
-	// Check if enough consecutive slots are available
+	// Check slot availability
 	for i := 0; i < block.DurationSlots; i++ {
-		slot := startSlot + i
-		if slot > 7 { // Maximum 7 slots per day
+		if slotInfo, slotExists := timetable[day][startSlot+i]; slotExists && slotInfo.IsBooked {
 			return false
 		}
-		
-		// Check if block spans across lunch break
-		if startSlot <= 4 && slot > 4 {
-			return false // Cannot span from morning to afternoon
-		}
-		
-		// Check slot availability
-		if daySlots, exists := timetable[day]; exists {
-			if slotInfo, slotExists := daySlots[slot]; slotExists && slotInfo.IsBooked {
-				return false
-			}
-		}
-	}
-	
-	// Additional constraints for labs
-	if block.IsLab {
-		// Labs must be 3 consecutive slots
-		if block.DurationSlots == 3 {
-			// Valid lab slots: 2-4 (morning 09:55-12:40) or 5-7 (afternoon 13:50-16:35)
-			if startSlot != 2 && startSlot != 5 {
-				return false
-			}
-		}
 	}
 	
 	// Theory constraint: max 2 slots per day for same course
 	// For 4-credit courses with 2+2 pattern, only one 2-hour block per day
 	if !block.IsLab {
-		// Count existing slots for this course on this day
-		slotsOnDay := 0
-		for slot := 1; slot <= 7; slot++ {
-			if daySlots, exists := timetable[day]; exists {
-				if slotInfo, slotExists := daySlots[slot]; slotExists && slotInfo.IsBooked && slotInfo.Block != nil {
-					if slotInfo.Block.CourseOfferingID == block.CourseOfferingID {
-						slotsOnDay++
-					}
-				}
-			}
-		}
-		// Check if adding this block would exceed the limit
-		if slotsOnDay + block.DurationSlots > 2 {
+		if courseSlotsOnDay(block, day, state) + block.DurationSlots > theoryMaxSlotsPerDay {
 			return false
 		}
 	}
 	
-	// Check global constraints (teacher and room availability)
-	slotNumbers := make([]int, 0, block.DurationSlots)
-	for i := 0; i < block.DurationSlots; i++ {
-		slot := startSlot + i
-		slotNumbers = append(slotNumbers, slot)
-	}
-	
-	// Check teacher availability
-	teacherAvailable, _ := s.scheduleRepo.CheckTeacherAvailability(block.TeacherID, sessionID, day, slotNumbers)
-	if !teacherAvailable {
+	// Check global constraints (student group, teacher and room availability)
+	// against the in-memory occupancy index
+	if resource, _ := state.index.clash(block, day, startSlot); resource != "" {
 		return false
 	}
 	
-	// Check room availability
-	roomAvailable, _ := s.scheduleRepo.CheckRoomAvailability(block.RoomID, sessionID, day, slotNumbers)
-	if !roomAvailable {
+	// The teacher must not be marked unavailable for any of the slots
+	if state.availability.blocks(block.TeacherID, day, startSlot, block.DurationSlots) {
 		return false
 	}
-	
-	// Check student group availability (same semester offering)
-	studentAvailable, _ := s.scheduleRepo.CheckStudentGroupAvailability(block.SemesterOfferingID, day, slotNumbers, 0)
-	if !studentAvailable {
+
+	// Nor teach more than their workload limits allow, counting what they
+	// teach in committed runs of the session
+	if !state.workload.allows(block.TeacherID, state.index.teachers[block.TeacherID], grid, day, startSlot, block.DurationSlots) {
 		return false
 	}
-	
+
+	// Every room must have the features the subject needs
+	for _, roomID := range blockRooms(block) {
+		if !state.rooms.suits(block.CourseOfferingID, roomID) {
+			return false
+		}
+	}
+
 	return true
 }
 
-func (s *routineGenerationService) hasConsecutiveSlots(day int, startSlot int, requiredSlots int, timetable models.Timetable) bool {
-	for i := 0; i < requiredSlots; i++ {
-		slot := startSlot + i
-		if slot > 7 {
-			return false
+// chooseResources picks a teacher and room for the block at the given slot. It
+// tries the assigned teachers in order of preference and the assigned rooms by
+// priority, then falls back to any free room of a compatible type. When no room
+// seats the group alone and the course allows it, the group is split across
+// the chosen room and further free rooms.
+func (s *routineGenerationService) chooseResources(block models.ClassBlock, day int, startSlot int, state *generationState) (models.ClassBlock, bool) {
+	if len(block.Electives) > 0 {
+		return s.chooseElectiveResources(block, day, startSlot, state)
+	}
+
+	teachers := block.TeacherCandidates
+	if len(teachers) == 0 {
+		teachers = []uint{block.TeacherID}
+	}
+	rooms := block.RoomCandidates
+	if len(rooms) == 0 {
+		rooms = []uint{block.RoomID}
+	}
+	for _, roomID := range state.rooms.fallback[block.IsLab] {
+		if !containsUint(rooms, roomID) {
+			rooms = append(rooms, roomID)
 		}
-		
-		// Check if spans lunch break
-		if startSlot <= 4 && slot > 4 {
-			return false
+	}
+
+	for _, split := range []bool{false, true} {
+		if split && !state.rooms.canSplit(block.CourseOfferingID, 1) {
+			break
 		}
-		
-		if daySlots, exists := timetable[day]; exists {
-			if slotInfo, slotExists := daySlots[slot]; slotExists && slotInfo.IsBooked {
-				return false
+		for _, teacherID := range teachers {
+			for _, roomID := range rooms {
+				candidate := block
+				candidate.TeacherID = teacherID
+				candidate.RoomID = roomID
+				candidate.SplitRoomIDs = nil
+				if split {
+					candidate.SplitRoomIDs = s.splitRooms(candidate, rooms, day, startSlot, state)
+				}
+				if s.canPlaceBlock(candidate, day, startSlot, state) {
+					return candidate, true
+				}
 			}
 		}
 	}
-	return true
+
+	return block, false
 }
 
-func (s *routineGenerationService) placeBlock(block models.ClassBlock, day int, startSlot int, timetable models.Timetable) {
-	for i := 0; i < block.DurationSlots; i++ {
-		slot := startSlot + i
-		timetable[day][slot] = models.TimeSlotInfo{
-			IsBooked: true,
-			Block:    &block,
+// chooseElectiveResources picks a teacher and room for every part of an
+// elective block in turn, holding those already picked so no two parts share
+// a teacher or room. Parts may share a student group: each student takes only
+// one of the basket's courses.
+func (s *routineGenerationService) chooseElectiveResources(block models.ClassBlock, day int, startSlot int, state *generationState) (models.ClassBlock, bool) {
+	var chosen []models.ClassBlock
+	defer func() {
+		for _, part := range chosen {
+			state.index.releaseResources(part, day, startSlot)
+		}
+	}()
+
+	for _, part := range blockParts(block) {
+		picked, ok := s.chooseResources(part, day, startSlot, state)
+		if !ok {
+			return block, false
 		}
+		state.index.reserveResources(picked, day, startSlot)
+		chosen = append(chosen, picked)
 	}
+
+	result := chosen[0]
+	result.Electives = append([]models.ClassBlock(nil), chosen[1:]...)
+	return result, true
 }
 
-func (s *routineGenerationService) removeBlock(block models.ClassBlock, day int, startSlot int, timetable models.Timetable) {
-	for i := 0; i < block.DurationSlots; i++ {
-		slot := startSlot + i
-		timetable[day][slot] = models.TimeSlotInfo{
-			IsBooked: false,
-			Block:    nil,
+// splitRooms adds free, suitable rooms to the block's chosen room, in order,
+// until together they seat the group or the course's room limit is reached
+func (s *routineGenerationService) splitRooms(block models.ClassBlock, rooms []uint, day int, startSlot int, state *generationState) []uint {
+	var split []uint
+	for _, roomID := range rooms {
+		chosen := append([]uint{block.RoomID}, split...)
+		if state.rooms.seats(block, chosen...) || !state.rooms.canSplit(block.CourseOfferingID, len(chosen)) {
+			break
+		}
+		if containsUint(chosen, roomID) || !state.rooms.suits(block.CourseOfferingID, roomID) ||
+			state.index.rooms[roomID].overlaps(day, startSlot, block.DurationSlots) {
+			continue
 		}
+		split = append(split, roomID)
 	}
+	return split
 }
 
-func (s *routineGenerationService) generatePlacementSuggestions(block models.ClassBlock, timetable models.Timetable, sessionID uint) []TimeSlot {
-	var suggestions []TimeSlot
-	
-	// Find all possible slots where this block could be placed
-	for day := 1; day <= 5; day++ {
-		for slot := 1; slot <= 7; slot++ {
-			if s.hasConsecutiveSlots(day, slot, block.DurationSlots, timetable) {
-				suggestions = append(suggestions, TimeSlot{
-					DayOfWeek:  day,
-					SlotStart:  slot,
-					SlotLength: block.DurationSlots,
-				})
+// resourcePenalty lowers the score of placements that use a less preferred
+// teacher or room, so the weighted split and room priorities win when possible
+func (s *routineGenerationService) resourcePenalty(block models.ClassBlock) int {
+	penalty := 0
+
+	for rank, teacherID := range block.TeacherCandidates {
+		if teacherID == block.TeacherID {
+			penalty += rank * 3
+			break
+		}
+	}
+
+	if len(block.RoomCandidates) > 0 {
+		rank := -1
+		for i, roomID := range block.RoomCandidates {
+			if roomID == block.RoomID {
+				rank = i
+				break
 			}
 		}
+		if rank < 0 {
+			penalty += 10 // Fallback room outside the assignments
+		} else {
+			penalty += rank * 2
+		}
 	}
-	
-	return suggestions
+
+	return penalty
 }
 
-func (s *routineGenerationService) convertTimetableToEntries(timetable models.Timetable, scheduleRunID uint, semesterOffering *models.SemesterOffering) []models.ScheduleEntry {
-	var entries []models.ScheduleEntry
-	blockToID := make(map[*models.ClassBlock]uint)
-	blockIDCounter := uint(1)
-	
-	// First pass: create schedule blocks
-	processedBlocks := make(map[string]bool)
-	
-	for day := 1; day <= 5; day++ {
-		for slot := 1; slot <= 7; slot++ {
-			if slotInfo, exists := timetable[day][slot]; exists && slotInfo.IsBooked && slotInfo.Block != nil {
-				// Create a unique key for this block placement
-				blockKey := fmt.Sprintf("%d-%d-%d-%d", slotInfo.Block.CourseOfferingID, day, slot, slotInfo.Block.DurationSlots)
-				
-				// Only create schedule block once for multi-slot blocks
-				if !processedBlocks[blockKey] {
-					scheduleBlock := models.ScheduleBlock{
-						ScheduleRunID:    scheduleRunID,
-						CourseOfferingID: slotInfo.Block.CourseOfferingID,
-						TeacherID:        slotInfo.Block.TeacherID,
-						RoomID:           slotInfo.Block.RoomID,
-						DayOfWeek:        day,
-						SlotStart:        slot,
-						SlotLength:       slotInfo.Block.DurationSlots,
-						IsLab:            slotInfo.Block.IsLab,
-					}
-					
-					if err := s.scheduleRepo.CreateScheduleBlock(&scheduleBlock); err == nil {
-						blockToID[slotInfo.Block] = scheduleBlock.ID
-						processedBlocks[blockKey] = true
-					}
-				}
+// placeBlock books the block in the timetable of every group it is taught to;
+// the parts of an elective block share one pointer across those timetables
+func (s *routineGenerationService) placeBlock(block models.ClassBlock, day int, startSlot int, state *generationState) {
+	parts := blockParts(block)
+	for _, part := range parts {
+		state.index.reserve(part, day, startSlot)
+	}
+	state.progress.placed(len(parts))
+	for _, key := range blockGroups(block) {
+		timetable := state.timetables[key]
+		for i := 0; i < block.DurationSlots; i++ {
+			slot := startSlot + i
+			timetable[day][slot] = models.TimeSlotInfo{
+				IsBooked: true,
+				Block:    &block,
+			}
+		}
+	}
+}
+
+func (s *routineGenerationService) removeBlock(block models.ClassBlock, day int, startSlot int, state *generationState) {
+	parts := blockParts(block)
+	for _, part := range parts {
+		state.index.release(part, day, startSlot)
+	}
+	state.progress.placed(-len(parts))
+	for _, key := range blockGroups(block) {
+		timetable := state.timetables[key]
+		for i := 0; i < block.DurationSlots; i++ {
+			slot := startSlot + i
+			timetable[day][slot] = models.TimeSlotInfo{
+				IsBooked: false,
+				Block:    nil,
 			}
 		}
 	}
+}
+
+// convertTimetableToEntries turns the group's timetable into schedule blocks
+// and entries, each entry pointing at its block. Of an elective block or
+// combined class only the parts taught to the group are stored; the rest
+// belong to the timetables of their own groups. A combined class gets one
+// schedule block, recorded in saved, that the entries of every group it is
+// taught to refer to; it is returned only with the first group's blocks.
+func (s *routineGenerationService) convertTimetableToEntries(grid *timeGrid, group groupKey, timetable models.Timetable, scheduleRunID uint, semesterOffering *models.SemesterOffering, saved map[*models.ClassBlock]*models.ScheduleBlock) ([]*models.ScheduleBlock, []models.ScheduleEntry) {
+	var blocks []*models.ScheduleBlock
+	var entries []models.ScheduleEntry
+	type partKey struct {
+		block            *models.ClassBlock
+		courseOfferingID uint
+	}
+	blockFor := make(map[partKey]*models.ScheduleBlock)
 	
-	// Second pass: create schedule entries
-	for day := 1; day <= 5; day++ {
-		for slot := 1; slot <= 7; slot++ {
+	for _, day := range grid.days {
+		for _, slot := range grid.slots(day) {
 			if slotInfo, exists := timetable[day][slot]; exists && slotInfo.IsBooked && slotInfo.Block != nil {
-				blockID := blockToID[slotInfo.Block]
-				if blockID == 0 {
-					blockID = blockIDCounter
-					blockIDCounter++
-				}
-				
-				entry := models.ScheduleEntry{
-					ScheduleRunID:        scheduleRunID,
-					SemesterOfferingID:   slotInfo.Block.SemesterOfferingID,
-					SessionID:            semesterOffering.SessionID,
-					CourseOfferingID:     slotInfo.Block.CourseOfferingID,
-					TeacherID:            slotInfo.Block.TeacherID,
-					RoomID:               slotInfo.Block.RoomID,
-					DayOfWeek:            day,
-					SlotNumber:           slot,
-					BlockID:              &blockID,
+				for _, part := range blockParts(*slotInfo.Block) {
+					if blockGroup(part) != group {
+						continue
+					}
+
+					// A multi-slot block gets its schedule block at its first slot
+					key := partKey{slotInfo.Block, part.CourseOfferingID}
+					scheduleBlock := blockFor[key]
+					if scheduleBlock == nil {
+						scheduleBlock = saved[slotInfo.Block]
+					}
+					if scheduleBlock == nil {
+						scheduleBlock = &models.ScheduleBlock{
+							ScheduleRunID:    scheduleRunID,
+							CourseOfferingID: part.CourseOfferingID,
+							StudentGroupID:   part.StudentGroupID,
+							TeacherID:        part.TeacherID,
+							RoomID:           part.RoomID,
+							SplitRoomIDs:     encodeRoomIDs(part.SplitRoomIDs),
+							DayOfWeek:        day,
+							SlotStart:        slot,
+							SlotLength:       part.DurationSlots,
+							IsLab:            part.IsLab,
+							IsPinned:         part.Pinned,
+						}
+						blocks = append(blocks, scheduleBlock)
+						if len(slotInfo.Block.Combined) > 0 {
+							saved[slotInfo.Block] = scheduleBlock
+						}
+					}
+					blockFor[key] = scheduleBlock
+
+					entry := models.ScheduleEntry{
+						ScheduleRunID:        scheduleRunID,
+						SemesterOfferingID:   part.SemesterOfferingID,
+						SessionID:            semesterOffering.SessionID,
+						CourseOfferingID:     part.CourseOfferingID,
+						StudentGroupID:       part.StudentGroupID,
+						TeacherID:            part.TeacherID,
+						RoomID:               part.RoomID,
+						SplitRoomIDs:         encodeRoomIDs(part.SplitRoomIDs),
+						DayOfWeek:            day,
+						SlotNumber:           slot,
+						Block:                scheduleBlock,
+						CombinedClassID:      part.CombinedClassID,
+					}
+					entries = append(entries, entry)
 				}
-				entries = append(entries, entry)
 			}
 		}
 	}
 	
-	return entries
+	return blocks, entries
 }
 
 func (s *routineGenerationService) CommitScheduleRun(scheduleRunID uint) error {
@@ -659,8 +1358,21 @@ func (s *routineGenerationService) CommitScheduleRun(scheduleRunID uint) error {
 		return errors.New("only draft schedule runs can be committed")
 	}
 	
-	// Commit the schedule run
-	return s.scheduleRepo.CommitScheduleRun(scheduleRunID)
+	// Commits in one session run one at a time, as session generations do
+	locks, err := s.locks.acquire(lockTarget{LockSession, run.SemesterOffering.SessionID}, lockTarget{LockSemesterOffering, run.SemesterOfferingID})
+	if err != nil {
+		return err
+	}
+	defer locks.release()
+	locks.setRun(run.ID, 0)
+
+	// Commit the schedule run, checking it again once the session is locked
+	return s.scheduleRepo.CommitScheduleRun(scheduleRunID, func(run *models.ScheduleRun, entries []models.ScheduleEntry, committed []models.ScheduleEntry) error {
+		if conflicts := commitConflicts(entries, committed); len(conflicts) > 0 {
+			return &CommitConflictError{ScheduleRunID: run.ID, Conflicts: conflicts}
+		}
+		return nil
+	})
 }
 
 func (s *routineGenerationService) CancelScheduleRun(scheduleRunID uint) error {
@@ -674,6 +1386,19 @@ func (s *routineGenerationService) CancelScheduleRun(scheduleRunID uint) error {
 		return errors.New("committed schedule runs cannot be cancelled")
 	}
 	
+	// A generation still in progress is stopped; it marks the run itself
+	if job := s.jobs.get(scheduleRunID); job != nil && !job.finished() {
+		job.cancel()
+		return nil
+	}
+
+	locks, err := s.offeringLock(run.SemesterOfferingID)
+	if err != nil {
+		return err
+	}
+	defer locks.release()
+	locks.setRun(run.ID, 0)
+
 	// Delete schedule entries
 	if err := s.scheduleRepo.DeleteScheduleEntriesByRun(scheduleRunID); err != nil {
 		return fmt.Errorf("failed to delete schedule entries: %w", err)
@@ -688,6 +1413,10 @@ func (s *routineGenerationService) GetScheduleRun(scheduleRunID uint) (*models.S
 	return s.scheduleRepo.GetScheduleRunByID(scheduleRunID)
 }
 
+func (s *routineGenerationService) GetSessionScheduleRun(sessionScheduleRunID uint) (*models.SessionScheduleRun, error) {
+	return s.scheduleRepo.GetSessionScheduleRunByID(sessionScheduleRunID)
+}
+
 func (s *routineGenerationService) GetScheduleRunsBySemesterOffering(semesterOfferingID uint) ([]models.ScheduleRun, error) {
 	return s.scheduleRepo.GetScheduleRunsBySemesterOffering(semesterOfferingID)
 }
\ No newline at end of file
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"icrogen/internal/models"
+)
+
+// syntheticSession builds a session of semester offerings that each take five
+// theory courses in their own room and one lab in a shared lab room. Every
+// teacher teaches two courses of offerings far apart in the list, so teacher
+// clashes cross offerings the way they do in a real department.
+func syntheticSession(offeringCount int) ([]models.SemesterOffering, []models.Teacher, []models.Room) {
+	const theoryPerOffering = 5
+	const labRoomCount = 10
+
+	coursesPerOffering := theoryPerOffering + 1
+	teachers := make([]models.Teacher, offeringCount*coursesPerOffering/2)
+	for i := range teachers {
+		teachers[i] = models.Teacher{ID: uint(i + 1), Name: fmt.Sprintf("Teacher %d", i+1), IsActive: true}
+	}
+
+	var rooms []models.Room
+	for i := 0; i < labRoomCount; i++ {
+		rooms = append(rooms, models.Room{ID: uint(i + 1), Name: fmt.Sprintf("Lab %d", i+1), RoomNumber: fmt.Sprintf("L%d", i+1), Capacity: 70, Type: "LAB", IsActive: true})
+	}
+
+	offerings := make([]models.SemesterOffering, offeringCount)
+	courseID := uint(0)
+	for i := range offerings {
+		theoryRoom := models.Room{ID: uint(labRoomCount + i + 1), Name: fmt.Sprintf("Room %d", i+1), RoomNumber: fmt.Sprintf("R%d", i+1), Capacity: 70, Type: "THEORY", IsActive: true}
+		rooms = append(rooms, theoryRoom)
+
+		offering := models.SemesterOffering{
+			ID:             uint(i + 1),
+			ProgrammeID:    1,
+			DepartmentID:   uint(i%5 + 1),
+			SessionID:      1,
+			SemesterNumber: i/5 + 1,
+			Status:         "ACTIVE",
+			Department:     models.Department{ID: uint(i%5 + 1), Strength: 60},
+		}
+		courses := make([]models.CourseOffering, coursesPerOffering)
+		for c := range courses {
+			courseID++
+			teacher := teachers[int(courseID-1)%len(teachers)]
+			course := models.CourseOffering{
+				ID:                  courseID,
+				SemesterOfferingID:  offering.ID,
+				SubjectID:           courseID,
+				WeeklyRequiredSlots: 3,
+				RequiredPattern:     `["1+1+1"]`,
+				MaxRoomSplit:        1,
+				SemesterOffering:    offering,
+				Subject:             models.Subject{ID: courseID, Code: fmt.Sprintf("S%d", courseID), Credit: 3},
+				TeacherAssignments:  []models.TeacherAssignment{{CourseOfferingID: courseID, TeacherID: teacher.ID, Weight: 1, Teacher: teacher}},
+				RoomAssignments:     []models.RoomAssignment{{CourseOfferingID: courseID, RoomID: theoryRoom.ID, Priority: 1, Room: theoryRoom}},
+			}
+			if c == theoryPerOffering {
+				course.IsLab = true
+				course.RequiredPattern = `["3"]`
+				course.RoomAssignments = nil
+				for r := 0; r < 2; r++ {
+					lab := rooms[(i+r)%labRoomCount]
+					course.RoomAssignments = append(course.RoomAssignments, models.RoomAssignment{CourseOfferingID: courseID, RoomID: lab.ID, Priority: r + 1, Room: lab})
+				}
+			}
+			courses[c] = course
+		}
+		offering.CourseOfferings = courses
+		offerings[i] = offering
+	}
+
+	return offerings, teachers, rooms
+}
+
+// newFakeGenerationService wires a routine generation service to the schedule
+// repository and in-memory repositories holding the given session
+func newFakeGenerationService(scheduleRepo *fakeScheduleRepo, offerings []models.SemesterOffering, teachers []models.Teacher, rooms []models.Room) RoutineGenerationService {
+	return NewRoutineGenerationService(
+		scheduleRepo,
+		&fakeSemesterOfferingRepo{offerings: offerings},
+		nil,
+		&fakeTeacherRepo{teachers: teachers},
+		&fakeRoomRepo{rooms: rooms},
+		&fakeTimeGridRepo{},
+		&fakeSoftConstraintRepo{},
+		&fakeAvailabilityRepo{},
+		&fakeWorkloadRepo{},
+		newFakeLockRepo(),
+	)
+}
+
+// BenchmarkGenerateSessionRoutine measures generating a whole 50-offering
+// session, where every placement is checked against the occupancy index of
+// all groups, teachers and rooms in the session
+func BenchmarkGenerateSessionRoutine(b *testing.B) {
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.ErrorLevel)
+	defer logrus.SetLevel(level)
+
+	offerings, teachers, rooms := syntheticSession(50)
+	opts := GenerationOptions{TimeBudget: time.Minute}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		service := newFakeGenerationService(newFakeScheduleRepo(), offerings, teachers, rooms)
+		run, err := service.GenerateSessionRoutine(context.Background(), 1, SessionGenerationFilters{}, opts)
+		if err != nil {
+			b.Fatalf("generation failed: %v", err)
+		}
+		if run.Status != "DRAFT" {
+			b.Fatalf("generation left blocks unplaced: %s", run.Meta)
+		}
+	}
+}
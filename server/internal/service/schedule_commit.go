@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"icrogen/internal/models"
+)
+
+// CommitConflict is a slot of a run being committed whose teacher or room was
+// committed by another run after this one was generated
+type CommitConflict struct {
+	DayOfWeek        int   `json:"day_of_week"`
+	SlotNumber       int   `json:"slot_number"`
+	CourseOfferingID uint  `json:"course_offering_id"`
+	BlockID          *uint `json:"block_id,omitempty"`
+	SlotConflict
+}
+
+// CommitConflictError aborts the commit of a run that clashes with the
+// committed runs of its session
+type CommitConflictError struct {
+	ScheduleRunID uint
+	Conflicts     []CommitConflict
+}
+
+func (e *CommitConflictError) Error() string {
+	return fmt.Sprintf("schedule run %d clashes with committed runs in %d places; regenerate or edit it before committing",
+		e.ScheduleRunID, len(e.Conflicts))
+}
+
+// commitConflicts lists the committed entries holding a teacher or room one of
+// the run's entries needs. Student groups are not checked: the only committed
+// run that could hold them, the offering's previous one, is being superseded.
+// Entries of one combined class share their block, teacher and room, so the
+// entries of the class already committed with another offering do not clash.
+func commitConflicts(entries []models.ScheduleEntry, committed []models.ScheduleEntry) []CommitConflict {
+	holders := &slotHolders{
+		groups:   make(map[groupSlot]slotHolder),
+		teachers: make(map[holderSlot]slotHolder),
+		rooms:    make(map[holderSlot]slotHolder),
+	}
+	for i := range committed {
+		holders.addEntry(&committed[i], true)
+	}
+
+	var conflicts []CommitConflict
+	for i := range entries {
+		entry := &entries[i]
+		own := entryHolderKey(entry)
+		found := holders.conflicts(holders.teachers, BlockedTeacherBusy, entry.TeacherID, entry.DayOfWeek, entry.SlotNumber, 1)
+		for _, roomID := range append([]uint{entry.RoomID}, parseRoomIDs(entry.SplitRoomIDs)...) {
+			found = append(found, holders.conflicts(holders.rooms, BlockedRoomTaken, roomID, entry.DayOfWeek, entry.SlotNumber, 1)...)
+		}
+		for _, conflict := range found {
+			if conflict.holder == own {
+				continue
+			}
+			conflicts = append(conflicts, CommitConflict{
+				DayOfWeek:        entry.DayOfWeek,
+				SlotNumber:       entry.SlotNumber,
+				CourseOfferingID: entry.CourseOfferingID,
+				BlockID:          entry.BlockID,
+				SlotConflict:     conflict,
+			})
+		}
+	}
+	return conflicts
+}
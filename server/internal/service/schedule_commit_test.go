@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+
+	"icrogen/internal/models"
+)
+
+func TestCommitConflicts(t *testing.T) {
+	block, otherBlock := uint(100), uint(200)
+	committed := []models.ScheduleEntry{
+		{ID: 1, SemesterOfferingID: 2, CourseOfferingID: 30, TeacherID: 5, RoomID: 20, DayOfWeek: 1, SlotNumber: 1, BlockID: &otherBlock},
+		{ID: 2, SemesterOfferingID: 2, CourseOfferingID: 30, TeacherID: 5, RoomID: 20, DayOfWeek: 1, SlotNumber: 2, BlockID: &otherBlock},
+		{ID: 3, SemesterOfferingID: 3, CourseOfferingID: 40, TeacherID: 6, RoomID: 21, DayOfWeek: 2, SlotNumber: 1, SplitRoomIDs: "[22]"},
+		{ID: 4, SemesterOfferingID: 4, CourseOfferingID: 50, TeacherID: 7, RoomID: 23, DayOfWeek: 3, SlotNumber: 1, BlockID: &block},
+	}
+	entry := func(teacherID, roomID uint, day, slot int) models.ScheduleEntry {
+		return models.ScheduleEntry{SemesterOfferingID: 1, CourseOfferingID: 10, TeacherID: teacherID, RoomID: roomID, DayOfWeek: day, SlotNumber: slot}
+	}
+
+	tests := []struct {
+		name    string
+		entries []models.ScheduleEntry
+		want    []string // Reason of each conflict, in order
+	}{
+		{name: "free slots", entries: []models.ScheduleEntry{entry(5, 20, 1, 3), entry(8, 24, 2, 1)}, want: nil},
+		{name: "committed teacher slot", entries: []models.ScheduleEntry{entry(5, 24, 1, 1)}, want: []string{BlockedTeacherBusy}},
+		{name: "committed room slot", entries: []models.ScheduleEntry{entry(8, 20, 1, 2)}, want: []string{BlockedRoomTaken}},
+		{name: "teacher and room", entries: []models.ScheduleEntry{entry(5, 20, 1, 1)}, want: []string{BlockedTeacherBusy, BlockedRoomTaken}},
+		{name: "committed split room", entries: []models.ScheduleEntry{entry(8, 22, 2, 1)}, want: []string{BlockedRoomTaken}},
+		{
+			name: "own split room",
+			entries: []models.ScheduleEntry{{
+				SemesterOfferingID: 1, CourseOfferingID: 10, TeacherID: 8, RoomID: 24, SplitRoomIDs: "[21]", DayOfWeek: 2, SlotNumber: 1,
+			}},
+			want: []string{BlockedRoomTaken},
+		},
+		{
+			name: "combined class already committed",
+			entries: []models.ScheduleEntry{{
+				SemesterOfferingID: 1, CourseOfferingID: 10, TeacherID: 7, RoomID: 23, DayOfWeek: 3, SlotNumber: 1, BlockID: &block,
+			}},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := commitConflicts(tt.entries, committed)
+			if len(conflicts) != len(tt.want) {
+				t.Fatalf("%d conflicts %+v, want %v", len(conflicts), conflicts, tt.want)
+			}
+			for i, conflict := range conflicts {
+				if conflict.Reason != tt.want[i] {
+					t.Errorf("conflict %d is %s, want %s", i, conflict.Reason, tt.want[i])
+				}
+				if !conflict.HeldByCommittedRun || conflict.CourseOfferingID != 10 {
+					t.Errorf("conflict %d is %+v, want course offering 10 held by a committed run", i, conflict)
+				}
+			}
+		})
+	}
+}
+
+func TestCommitConflictsNameTheHolder(t *testing.T) {
+	committed := []models.ScheduleEntry{
+		{ID: 1, SemesterOfferingID: 2, CourseOfferingID: 30, TeacherID: 5, RoomID: 20, DayOfWeek: 1, SlotNumber: 1},
+	}
+	entries := []models.ScheduleEntry{
+		{SemesterOfferingID: 1, CourseOfferingID: 10, TeacherID: 5, RoomID: 24, DayOfWeek: 1, SlotNumber: 1},
+	}
+
+	conflicts := commitConflicts(entries, committed)
+	if len(conflicts) != 1 {
+		t.Fatalf("%d conflicts, want 1", len(conflicts))
+	}
+	conflict := conflicts[0]
+	if conflict.DayOfWeek != 1 || conflict.SlotNumber != 1 || conflict.ResourceID != 5 ||
+		conflict.HeldBySemesterOfferingID != 2 || conflict.HeldByCourseOfferingID != 30 {
+		t.Errorf("conflict %+v, want teacher 5 on Monday's first slot held by course offering 30 of semester offering 2", conflict)
+	}
+}
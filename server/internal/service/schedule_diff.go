@@ -0,0 +1,492 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// ErrScheduleRunNotFound is returned when a schedule run to compare does not
+// exist
+var ErrScheduleRunNotFound = errors.New("schedule run not found")
+
+// BlockPlacement is where a block sits in one run, and who teaches it where
+type BlockPlacement struct {
+	BlockID      uint   `json:"block_id"`
+	DayOfWeek    int    `json:"day_of_week"`
+	SlotStart    int    `json:"slot_start"`
+	TeacherID    uint   `json:"teacher_id"`
+	RoomID       uint   `json:"room_id"`
+	SplitRoomIDs []uint `json:"split_room_ids,omitempty"`
+	IsPinned     bool   `json:"is_pinned"`
+}
+
+// BlockChange is a block added, removed or moved between two runs. Added
+// blocks have no Before, removed ones no After.
+type BlockChange struct {
+	CourseOfferingID uint            `json:"course_offering_id"`
+	StudentGroupID   uint            `json:"student_group_id"`
+	SlotLength       int             `json:"slot_length"`
+	IsLab            bool            `json:"is_lab"`
+	Before           *BlockPlacement `json:"before,omitempty"`
+	After            *BlockPlacement `json:"after,omitempty"`
+	Changed          []string        `json:"changed,omitempty"` // What a move changed: "slot", "teacher" and/or "room"
+}
+
+// ChangeCount counts the changes touching one course offering, teacher or room
+type ChangeCount struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Moved   int `json:"moved"`
+}
+
+// PenaltyChange compares the soft-constraint penalty of two runs. A lower
+// penalty is better, so a negative change is an improvement.
+type PenaltyChange struct {
+	Before       PenaltyReport  `json:"before"`
+	After        PenaltyReport  `json:"after"`
+	Change       int            `json:"change"`
+	ByConstraint map[string]int `json:"by_constraint"` // Change of every constraint that changed
+}
+
+// TeacherLoadChange is a teacher whose session load differs between two runs
+type TeacherLoadChange struct {
+	TeacherID     uint        `json:"teacher_id"`
+	TeacherName   string      `json:"teacher_name"`
+	Before        TeacherLoad `json:"before"`
+	After         TeacherLoad `json:"after"`
+	PeriodsChange int         `json:"periods_change"`
+}
+
+// RunDiff is what changes going from one schedule run of a semester offering
+// to another
+type RunDiff struct {
+	ScheduleRunID      uint                  `json:"schedule_run_id"`
+	OtherRunID         uint                  `json:"other_run_id"`
+	SemesterOfferingID uint                  `json:"semester_offering_id"`
+	Added              []BlockChange         `json:"added"`
+	Removed            []BlockChange         `json:"removed"`
+	Moved              []BlockChange         `json:"moved"`
+	Unchanged          int                   `json:"unchanged"`
+	ByCourseOffering   map[uint]*ChangeCount `json:"by_course_offering"`
+	ByTeacher          map[uint]*ChangeCount `json:"by_teacher"`
+	ByRoom             map[uint]*ChangeCount `json:"by_room"`
+	Penalties          PenaltyChange         `json:"penalties"`
+	TeacherLoads       []TeacherLoadChange   `json:"teacher_loads"`
+}
+
+// DiffScheduleRuns compares two runs of the same semester offering, from the
+// first to the second. Blocks are matched by course offering, group, length
+// and kind: a match at the same slot with the same teacher and rooms is
+// unchanged, any other match a move. Both runs are scored as they are stored
+// now, edits included, against the entries committed for the session's other
+// offerings.
+func (s *routineGenerationService) DiffScheduleRuns(scheduleRunID uint, otherRunID uint) (*RunDiff, error) {
+	if scheduleRunID == otherRunID {
+		return nil, errors.New("cannot compare a schedule run with itself")
+	}
+	run, err := s.diffedRun(scheduleRunID)
+	if err != nil {
+		return nil, err
+	}
+	other, err := s.diffedRun(otherRunID)
+	if err != nil {
+		return nil, err
+	}
+	if run.SemesterOfferingID != other.SemesterOfferingID {
+		return nil, errors.New("only schedule runs of the same semester offering can be compared")
+	}
+
+	before, err := s.runBlocks(run)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.runBlocks(other)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &RunDiff{
+		ScheduleRunID:      run.ID,
+		OtherRunID:         other.ID,
+		SemesterOfferingID: run.SemesterOfferingID,
+		Added:              []BlockChange{},
+		Removed:            []BlockChange{},
+		Moved:              []BlockChange{},
+		ByCourseOffering:   make(map[uint]*ChangeCount),
+		ByTeacher:          make(map[uint]*ChangeCount),
+		ByRoom:             make(map[uint]*ChangeCount),
+	}
+	diff.matchBlocks(before, after)
+
+	if err := s.scoreRunDiff(diff, run, other); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// diffedRun loads a run to compare, telling a missing run from a failed read
+func (s *routineGenerationService) diffedRun(id uint) (*models.ScheduleRun, error) {
+	run, err := s.scheduleRepo.GetScheduleRunByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %d", ErrScheduleRunNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule run: %w", err)
+	}
+	return run, nil
+}
+
+// matchBlocks sorts the blocks of the two runs into unchanged, moved, added
+// and removed ones
+func (d *RunDiff) matchBlocks(before []models.ScheduleBlock, after []models.ScheduleBlock) {
+	sortScheduleBlocks(before)
+	sortScheduleBlocks(after)
+	matched := make([]bool, len(after))
+	var unmatched []models.ScheduleBlock
+
+	// Blocks that stayed exactly where they were
+	for _, old := range before {
+		found := false
+		for i, block := range after {
+			if !matched[i] && sameBlockClass(old, block) && len(blockMoveChanges(old, block)) == 0 {
+				matched[i], found = true, true
+				d.Unchanged++
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, old)
+		}
+	}
+
+	// The rest moved if the other run still has a block of their class
+	for _, old := range unmatched {
+		found := false
+		for i, block := range after {
+			if !matched[i] && sameBlockClass(old, block) {
+				matched[i], found = true, true
+				change := newBlockChange(old)
+				change.Before, change.After = newBlockPlacement(old), newBlockPlacement(block)
+				change.Changed = blockMoveChanges(old, block)
+				d.Moved = append(d.Moved, change)
+				d.count(func(c *ChangeCount) { c.Moved++ }, old, block)
+				break
+			}
+		}
+		if !found {
+			change := newBlockChange(old)
+			change.Before = newBlockPlacement(old)
+			d.Removed = append(d.Removed, change)
+			d.count(func(c *ChangeCount) { c.Removed++ }, old)
+		}
+	}
+
+	for i, block := range after {
+		if matched[i] {
+			continue
+		}
+		change := newBlockChange(block)
+		change.After = newBlockPlacement(block)
+		d.Added = append(d.Added, change)
+		d.count(func(c *ChangeCount) { c.Added++ }, block)
+	}
+}
+
+// count applies a change to the counts of the course offering, teachers and
+// rooms of the blocks, the two sides of a move or one added or removed block.
+// Each is counted once, however many of the blocks it appears in.
+func (d *RunDiff) count(apply func(*ChangeCount), blocks ...models.ScheduleBlock) {
+	countOnce := func(counts map[uint]*ChangeCount, ids []uint) {
+		var counted []uint
+		for _, id := range ids {
+			if containsUint(counted, id) {
+				continue
+			}
+			counted = append(counted, id)
+			if counts[id] == nil {
+				counts[id] = &ChangeCount{}
+			}
+			apply(counts[id])
+		}
+	}
+
+	var courseOfferingIDs, teacherIDs, roomIDs []uint
+	for _, block := range blocks {
+		courseOfferingIDs = append(courseOfferingIDs, block.CourseOfferingID)
+		teacherIDs = append(teacherIDs, block.TeacherID)
+		roomIDs = append(roomIDs, block.RoomID)
+		roomIDs = append(roomIDs, parseRoomIDs(block.SplitRoomIDs)...)
+	}
+	countOnce(d.ByCourseOffering, courseOfferingIDs)
+	countOnce(d.ByTeacher, teacherIDs)
+	countOnce(d.ByRoom, roomIDs)
+}
+
+// scoreRunDiff scores both runs and compares their penalties and the session
+// load of every teacher either of them uses. A teacher's load counts what the
+// session's other offerings have committed, with the grid of this offering.
+func (s *routineGenerationService) scoreRunDiff(diff *RunDiff, run *models.ScheduleRun, other *models.ScheduleRun) error {
+	offering, err := s.semesterOfferingRepo.GetByID(run.SemesterOfferingID)
+	if err != nil {
+		return fmt.Errorf("failed to get semester offering: %w", err)
+	}
+	offerings := []models.SemesterOffering{*offering}
+
+	grids, err := s.loadTimeGrids(offerings)
+	if err != nil {
+		return err
+	}
+	constraints, err := s.loadSoftConstraints(offerings)
+	if err != nil {
+		return err
+	}
+	availability, err := s.loadTeacherAvailability(offering.SessionID)
+	if err != nil {
+		return err
+	}
+	workload, err := s.loadTeacherWorkload()
+	if err != nil {
+		return err
+	}
+
+	// What the session's other offerings have committed counts towards loads
+	committed, err := s.scheduleRepo.GetCommittedScheduleEntries(offering.SessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get committed schedule entries: %w", err)
+	}
+	var elsewhere []models.ScheduleEntry
+	for _, entry := range committed {
+		if entry.SemesterOfferingID != offering.ID {
+			elsewhere = append(elsewhere, entry)
+		}
+	}
+
+	// Every teacher of either run, whose loads are compared
+	var teacherIDs []uint
+	for _, entry := range append(append([]models.ScheduleEntry(nil), run.ScheduleEntries...), other.ScheduleEntries...) {
+		if !containsUint(teacherIDs, entry.TeacherID) {
+			teacherIDs = append(teacherIDs, entry.TeacherID)
+		}
+	}
+	sort.Slice(teacherIDs, func(i, j int) bool { return teacherIDs[i] < teacherIDs[j] })
+
+	score := func(scored *models.ScheduleRun) (PenaltyReport, map[uint]TeacherLoad, error) {
+		blocks, err := s.runBlocks(scored)
+		if err != nil {
+			return PenaltyReport{}, nil, err
+		}
+		entries := append(append([]models.ScheduleEntry(nil), elsewhere...), scored.ScheduleEntries...)
+		state := s.newGenerationState(offering.SessionID, grids, constraints, availability, workload, entries, newGroupTree(offerings), nil)
+		bookStoredBlocks(state, scored.ScheduleEntries, blocks)
+
+		loads := make(map[uint]TeacherLoad, len(teacherIDs))
+		for _, teacherID := range teacherIDs {
+			loads[teacherID] = workload.load(teacherID, state.index.teachers[teacherID], grids[offering.ID])
+		}
+		return s.evaluatePenalties(state, []uint{offering.ID}), loads, nil
+	}
+
+	penaltiesBefore, loadsBefore, err := score(run)
+	if err != nil {
+		return err
+	}
+	penaltiesAfter, loadsAfter, err := score(other)
+	if err != nil {
+		return err
+	}
+
+	diff.Penalties = PenaltyChange{
+		Before:       penaltiesBefore,
+		After:        penaltiesAfter,
+		Change:       penaltiesAfter.Total - penaltiesBefore.Total,
+		ByConstraint: make(map[string]int),
+	}
+	for constraintType, penalty := range penaltiesAfter.ByConstraint {
+		if change := penalty - penaltiesBefore.ByConstraint[constraintType]; change != 0 {
+			diff.Penalties.ByConstraint[constraintType] = change
+		}
+	}
+	for constraintType, penalty := range penaltiesBefore.ByConstraint {
+		if _, seen := penaltiesAfter.ByConstraint[constraintType]; !seen && penalty != 0 {
+			diff.Penalties.ByConstraint[constraintType] = -penalty
+		}
+	}
+
+	diff.TeacherLoads = []TeacherLoadChange{}
+	for _, teacherID := range teacherIDs {
+		old, load := loadsBefore[teacherID], loadsAfter[teacherID]
+		if sameTeacherLoad(old, load) {
+			continue
+		}
+		diff.TeacherLoads = append(diff.TeacherLoads, TeacherLoadChange{
+			TeacherID:     teacherID,
+			TeacherName:   load.TeacherName,
+			Before:        old,
+			After:         load,
+			PeriodsChange: load.PeriodsPerWeek - old.PeriodsPerWeek,
+		})
+	}
+	return nil
+}
+
+// bookStoredBlocks books a run's stored blocks into the timetables of the
+// groups its entries are for. Blocks of one group at the same slot, the parts
+// of an elective basket, are booked as one block.
+func bookStoredBlocks(state *generationState, entries []models.ScheduleEntry, blocks []models.ScheduleBlock) {
+	byID := make(map[uint]models.ScheduleBlock, len(blocks))
+	for _, block := range blocks {
+		byID[block.ID] = block
+	}
+
+	type partKey struct {
+		blockID uint
+		group   groupKey
+	}
+	type slotKey struct {
+		group groupKey
+		day   int
+		slot  int
+	}
+	seen := make(map[partKey]bool)
+	booked := make(map[slotKey]*models.ClassBlock)
+	var order []slotKey
+	for _, entry := range entries {
+		if entry.BlockID == nil {
+			continue
+		}
+		block, exists := byID[*entry.BlockID]
+		group := groupKey{entry.SemesterOfferingID, entry.StudentGroupID}
+		if !exists || seen[partKey{block.ID, group}] {
+			continue
+		}
+		seen[partKey{block.ID, group}] = true
+
+		part := models.ClassBlock{
+			TeacherID:          block.TeacherID,
+			RoomID:             block.RoomID,
+			DurationSlots:      block.SlotLength,
+			IsLab:              block.IsLab,
+			SemesterOfferingID: entry.SemesterOfferingID,
+			CourseOfferingID:   entry.CourseOfferingID,
+			StudentGroupID:     entry.StudentGroupID,
+			SplitRoomIDs:       parseRoomIDs(block.SplitRoomIDs),
+			Pinned:             block.IsPinned,
+			CombinedClassID:    entry.CombinedClassID,
+		}
+		key := slotKey{group, block.DayOfWeek, block.SlotStart}
+		if lead := booked[key]; lead != nil {
+			lead.Electives = append(lead.Electives, part)
+			continue
+		}
+		booked[key] = &part
+		order = append(order, key)
+	}
+
+	for _, key := range order {
+		timetable, exists := state.timetables[key.group]
+		if !exists {
+			continue
+		}
+		block := booked[key]
+		for i := 0; i < block.DurationSlots; i++ {
+			// Slots the offering's grid no longer has are left out
+			if _, inGrid := timetable[key.day][key.slot+i]; inGrid {
+				timetable[key.day][key.slot+i] = models.TimeSlotInfo{IsBooked: true, Block: block}
+			}
+		}
+	}
+}
+
+// sameTeacherLoad reports whether two loads of a teacher are alike
+func sameTeacherLoad(a TeacherLoad, b TeacherLoad) bool {
+	if a.PeriodsPerWeek != b.PeriodsPerWeek || a.MaxConsecutive != b.MaxConsecutive ||
+		a.FreeDays != b.FreeDays || len(a.PeriodsByDay) != len(b.PeriodsByDay) || len(a.Exceeded) != len(b.Exceeded) {
+		return false
+	}
+	for day, periods := range a.PeriodsByDay {
+		if b.PeriodsByDay[day] != periods {
+			return false
+		}
+	}
+	return true
+}
+
+// sameBlockClass reports whether two blocks teach the same class: the same
+// course offering and group, for as long, in the same kind of room
+func sameBlockClass(a models.ScheduleBlock, b models.ScheduleBlock) bool {
+	return a.CourseOfferingID == b.CourseOfferingID && a.StudentGroupID == b.StudentGroupID &&
+		a.SlotLength == b.SlotLength && a.IsLab == b.IsLab
+}
+
+// blockMoveChanges lists what differs between two placements of a class
+func blockMoveChanges(a models.ScheduleBlock, b models.ScheduleBlock) []string {
+	var changed []string
+	if a.DayOfWeek != b.DayOfWeek || a.SlotStart != b.SlotStart {
+		changed = append(changed, "slot")
+	}
+	if a.TeacherID != b.TeacherID {
+		changed = append(changed, "teacher")
+	}
+	if a.RoomID != b.RoomID || !sameUints(parseRoomIDs(a.SplitRoomIDs), parseRoomIDs(b.SplitRoomIDs)) {
+		changed = append(changed, "room")
+	}
+	return changed
+}
+
+func sameUints(a []uint, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, value := range a {
+		if !containsUint(b, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func newBlockChange(block models.ScheduleBlock) BlockChange {
+	return BlockChange{
+		CourseOfferingID: block.CourseOfferingID,
+		StudentGroupID:   block.StudentGroupID,
+		SlotLength:       block.SlotLength,
+		IsLab:            block.IsLab,
+	}
+}
+
+func newBlockPlacement(block models.ScheduleBlock) *BlockPlacement {
+	return &BlockPlacement{
+		BlockID:      block.ID,
+		DayOfWeek:    block.DayOfWeek,
+		SlotStart:    block.SlotStart,
+		TeacherID:    block.TeacherID,
+		RoomID:       block.RoomID,
+		SplitRoomIDs: parseRoomIDs(block.SplitRoomIDs),
+		IsPinned:     block.IsPinned,
+	}
+}
+
+// sortScheduleBlocks orders blocks by slot, then class, so runs are matched
+// and listed the same way every time
+func sortScheduleBlocks(blocks []models.ScheduleBlock) {
+	sort.Slice(blocks, func(i, j int) bool {
+		a, b := blocks[i], blocks[j]
+		if a.DayOfWeek != b.DayOfWeek {
+			return a.DayOfWeek < b.DayOfWeek
+		}
+		if a.SlotStart != b.SlotStart {
+			return a.SlotStart < b.SlotStart
+		}
+		if a.CourseOfferingID != b.CourseOfferingID {
+			return a.CourseOfferingID < b.CourseOfferingID
+		}
+		if a.StudentGroupID != b.StudentGroupID {
+			return a.StudentGroupID < b.StudentGroupID
+		}
+		return a.ID < b.ID
+	})
+}
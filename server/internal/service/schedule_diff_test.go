@@ -0,0 +1,197 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"icrogen/internal/models"
+)
+
+// scheduleBlock is a theory block of semester offering 1's whole group
+func scheduleBlock(id uint, courseOfferingID uint, teacherID uint, roomID uint, day int, slot int, length int) models.ScheduleBlock {
+	return models.ScheduleBlock{
+		ID:               id,
+		CourseOfferingID: courseOfferingID,
+		TeacherID:        teacherID,
+		RoomID:           roomID,
+		DayOfWeek:        day,
+		SlotStart:        slot,
+		SlotLength:       length,
+	}
+}
+
+func TestMatchBlocks(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      []models.ScheduleBlock
+		after       []models.ScheduleBlock
+		wantSame    int
+		wantMoved   [][]string // What each move changed
+		wantAdded   int
+		wantRemoved int
+	}{
+		{
+			name:     "unchanged",
+			before:   []models.ScheduleBlock{scheduleBlock(1, 10, 5, 20, 1, 1, 1)},
+			after:    []models.ScheduleBlock{scheduleBlock(2, 10, 5, 20, 1, 1, 1)},
+			wantSame: 1,
+		},
+		{
+			name:      "moved to another slot",
+			before:    []models.ScheduleBlock{scheduleBlock(1, 10, 5, 20, 1, 1, 1)},
+			after:     []models.ScheduleBlock{scheduleBlock(2, 10, 5, 20, 2, 3, 1)},
+			wantMoved: [][]string{{"slot"}},
+		},
+		{
+			name:      "new teacher and room",
+			before:    []models.ScheduleBlock{scheduleBlock(1, 10, 5, 20, 1, 1, 1)},
+			after:     []models.ScheduleBlock{scheduleBlock(2, 10, 6, 21, 1, 1, 1)},
+			wantMoved: [][]string{{"teacher", "room"}},
+		},
+		{
+			name:      "exact matches taken before moves",
+			before:    []models.ScheduleBlock{scheduleBlock(1, 10, 5, 20, 1, 1, 1), scheduleBlock(2, 10, 5, 20, 2, 1, 1)},
+			after:     []models.ScheduleBlock{scheduleBlock(3, 10, 5, 20, 2, 1, 1), scheduleBlock(4, 10, 5, 20, 3, 1, 1)},
+			wantSame:  1,
+			wantMoved: [][]string{{"slot"}},
+		},
+		{
+			name:        "a different length is another class",
+			before:      []models.ScheduleBlock{scheduleBlock(1, 10, 5, 20, 1, 1, 2)},
+			after:       []models.ScheduleBlock{scheduleBlock(2, 10, 5, 20, 1, 1, 1), scheduleBlock(3, 10, 5, 20, 1, 2, 1)},
+			wantAdded:   2,
+			wantRemoved: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := &RunDiff{
+				ByCourseOffering: make(map[uint]*ChangeCount),
+				ByTeacher:        make(map[uint]*ChangeCount),
+				ByRoom:           make(map[uint]*ChangeCount),
+			}
+			diff.matchBlocks(tt.before, tt.after)
+
+			var moved [][]string
+			for _, change := range diff.Moved {
+				moved = append(moved, change.Changed)
+			}
+			if diff.Unchanged != tt.wantSame || !reflect.DeepEqual(moved, tt.wantMoved) ||
+				len(diff.Added) != tt.wantAdded || len(diff.Removed) != tt.wantRemoved {
+				t.Errorf("%d unchanged, moved %v, %d added, %d removed; want %d, %v, %d, %d",
+					diff.Unchanged, moved, len(diff.Added), len(diff.Removed), tt.wantSame, tt.wantMoved, tt.wantAdded, tt.wantRemoved)
+			}
+
+			want := ChangeCount{Added: tt.wantAdded, Removed: tt.wantRemoved, Moved: len(tt.wantMoved)}
+			got := ChangeCount{}
+			if counts := diff.ByCourseOffering[10]; counts != nil {
+				got = *counts
+			}
+			if got != want {
+				t.Errorf("course offering 10 counts %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestMatchBlocksCountsTeachersAndRooms(t *testing.T) {
+	diff := &RunDiff{
+		ByCourseOffering: make(map[uint]*ChangeCount),
+		ByTeacher:        make(map[uint]*ChangeCount),
+		ByRoom:           make(map[uint]*ChangeCount),
+	}
+	moved := scheduleBlock(2, 10, 6, 20, 1, 1, 1)
+	moved.SplitRoomIDs = "[21]"
+	diff.matchBlocks([]models.ScheduleBlock{scheduleBlock(1, 10, 5, 20, 1, 1, 1)}, []models.ScheduleBlock{moved})
+
+	// Both teachers of the move count it; room 20, on both sides, counts it once
+	for _, id := range []uint{5, 6} {
+		if counts := diff.ByTeacher[id]; counts == nil || *counts != (ChangeCount{Moved: 1}) {
+			t.Errorf("teacher %d counts %+v, want one move", id, counts)
+		}
+	}
+	for _, id := range []uint{20, 21} {
+		if counts := diff.ByRoom[id]; counts == nil || *counts != (ChangeCount{Moved: 1}) {
+			t.Errorf("room %d counts %+v, want one move", id, counts)
+		}
+	}
+}
+
+// storedRun stores a draft run of a semester offering holding the blocks, with
+// an entry for every slot of each
+func storedRun(repo *fakeScheduleRepo, semesterOfferingID uint, blocks ...models.ScheduleBlock) *models.ScheduleRun {
+	run := &models.ScheduleRun{SemesterOfferingID: semesterOfferingID, Status: "DRAFT"}
+	repo.CreateScheduleRun(run)
+	for _, block := range blocks {
+		block.ScheduleRunID = run.ID
+		run.ScheduleBlocks = append(run.ScheduleBlocks, block)
+		for i := 0; i < block.SlotLength; i++ {
+			blockID := block.ID
+			run.ScheduleEntries = append(run.ScheduleEntries, models.ScheduleEntry{
+				ScheduleRunID:      run.ID,
+				SemesterOfferingID: semesterOfferingID,
+				SessionID:          1,
+				CourseOfferingID:   block.CourseOfferingID,
+				TeacherID:          block.TeacherID,
+				RoomID:             block.RoomID,
+				DayOfWeek:          block.DayOfWeek,
+				SlotNumber:         block.SlotStart + i,
+				BlockID:            &blockID,
+			})
+		}
+	}
+	return run
+}
+
+func TestDiffScheduleRuns(t *testing.T) {
+	repo := newFakeScheduleRepo()
+	offerings := []models.SemesterOffering{{ID: 1, SessionID: 1}, {ID: 2, SessionID: 1}}
+	teachers := []models.Teacher{{ID: 5, Name: "Teacher 5"}, {ID: 6, Name: "Teacher 6"}}
+	service := newFakeGenerationService(repo, offerings, teachers, nil)
+
+	run := storedRun(repo, 1, scheduleBlock(1, 10, 5, 20, 1, 1, 1), scheduleBlock(2, 11, 6, 20, 1, 2, 2))
+	other := storedRun(repo, 1, scheduleBlock(3, 10, 5, 20, 1, 1, 1), scheduleBlock(4, 11, 6, 20, 2, 2, 2))
+	elsewhere := storedRun(repo, 2)
+
+	diff, err := service.DiffScheduleRuns(run.ID, other.ID)
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	if diff.Unchanged != 1 || len(diff.Moved) != 1 || len(diff.Added) != 0 || len(diff.Removed) != 0 {
+		t.Fatalf("%d unchanged and %d moved, %d added, %d removed; want 1 unchanged and 1 moved",
+			diff.Unchanged, len(diff.Moved), len(diff.Added), len(diff.Removed))
+	}
+	if move := diff.Moved[0]; move.Before.BlockID != 2 || move.After.BlockID != 4 {
+		t.Errorf("move from block %d to %d, want 2 to 4", move.Before.BlockID, move.After.BlockID)
+	}
+	if diff.Penalties.Change != diff.Penalties.After.Total-diff.Penalties.Before.Total {
+		t.Errorf("penalty change %d, want %d", diff.Penalties.Change, diff.Penalties.After.Total-diff.Penalties.Before.Total)
+	}
+	// Only teacher 6's days changed
+	if len(diff.TeacherLoads) != 1 || diff.TeacherLoads[0].TeacherID != 6 || diff.TeacherLoads[0].PeriodsChange != 0 {
+		t.Errorf("teacher loads %+v, want only teacher 6's, with as many periods", diff.TeacherLoads)
+	}
+
+	tests := []struct {
+		name         string
+		runID        uint
+		otherID      uint
+		wantNotFound bool
+	}{
+		{name: "same run", runID: run.ID, otherID: run.ID},
+		{name: "missing run", runID: run.ID, otherID: 99, wantNotFound: true},
+		{name: "another semester offering", runID: run.ID, otherID: elsewhere.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.DiffScheduleRuns(tt.runID, tt.otherID)
+			if err == nil {
+				t.Fatalf("diff of runs %d and %d succeeded", tt.runID, tt.otherID)
+			}
+			if errors.Is(err, ErrScheduleRunNotFound) != tt.wantNotFound {
+				t.Errorf("error %v, want not found %v", err, tt.wantNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,641 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"sort"
+	"strings"
+)
+
+// BlockMove is where a block of a draft run should go. A zero teacher or room
+// keeps the block's own; a new room replaces any split rooms.
+type BlockMove struct {
+	DayOfWeek int
+	SlotStart int
+	TeacherID uint
+	RoomID    uint
+}
+
+// blockEdit is a schedule block with its new slot and resources, and the
+// entries it has now
+type blockEdit struct {
+	block               models.ScheduleBlock
+	entries             []models.ScheduleEntry
+	semesterOfferingIDs []uint // Of every run holding the block or its entries
+	link                string // Holder key shared by the parts of an elective block moved together
+}
+
+// blockRef is a block of a schedule run
+type blockRef struct {
+	scheduleRunID uint
+	blockID       uint
+}
+
+// MoveScheduleBlock moves a block of a draft run to another slot, optionally
+// with another teacher or room, together with the blocks of the rest of its
+// elective basket. The edit is refused, and the conflicts returned, when a
+// block would clash with another of the runs or a committed entry of the
+// session.
+func (s *routineGenerationService) MoveScheduleBlock(scheduleRunID uint, blockID uint, move BlockMove) ([]SlotConflict, error) {
+	parts, locks, err := s.lockLinkedBlocks(scheduleRunID, blockID)
+	if err != nil {
+		return nil, err
+	}
+	defer locks.release()
+	edit := parts[0][0]
+
+	changesTeacher := move.TeacherID != 0 && move.TeacherID != edit.block.TeacherID
+	changesRoom := move.RoomID != 0 && move.RoomID != edit.block.RoomID
+	if changesTeacher || changesRoom {
+		offering, err := s.courseOfferingRepo.GetByID(edit.block.CourseOfferingID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get course offering: %w", err)
+		}
+		if changesTeacher {
+			if _, err := s.teacherRepo.GetByID(move.TeacherID); err != nil {
+				return nil, errors.New("invalid teacher ID")
+			}
+			if err := checkBlockTeacher(offering, move.TeacherID); err != nil {
+				return nil, err
+			}
+			edit.block.TeacherID = move.TeacherID
+		}
+		if changesRoom {
+			room, err := s.roomRepo.GetByID(move.RoomID)
+			if err != nil {
+				return nil, errors.New("invalid room ID")
+			}
+			if err := checkBlockRoom(offering, edit.block, room); err != nil {
+				return nil, err
+			}
+			edit.block.RoomID = move.RoomID
+			edit.block.SplitRoomIDs = ""
+		}
+	}
+
+	var edits []blockEdit
+	for _, part := range parts[0] {
+		part.block.DayOfWeek = move.DayOfWeek
+		part.block.SlotStart = move.SlotStart
+		edits = append(edits, *part)
+	}
+	return s.applyBlockEdits(edits)
+}
+
+// SwapScheduleBlocks exchanges the slots of two blocks of a draft run, each
+// keeping its teacher and rooms and taking the rest of its elective basket
+// along
+func (s *routineGenerationService) SwapScheduleBlocks(scheduleRunID uint, blockID uint, otherBlockID uint) ([]SlotConflict, error) {
+	if blockID == otherBlockID {
+		return nil, errors.New("cannot swap a block with itself")
+	}
+	parts, locks, err := s.lockLinkedBlocks(scheduleRunID, blockID, otherBlockID)
+	if err != nil {
+		return nil, err
+	}
+	defer locks.release()
+	first, second := parts[0][0].block, parts[1][0].block
+
+	var edits []blockEdit
+	for _, part := range parts[0] {
+		part.block.DayOfWeek, part.block.SlotStart = second.DayOfWeek, second.SlotStart
+		edits = append(edits, *part)
+	}
+	for _, part := range parts[1] {
+		part.block.DayOfWeek, part.block.SlotStart = first.DayOfWeek, first.SlotStart
+		edits = append(edits, *part)
+	}
+	return s.applyBlockEdits(edits)
+}
+
+// checkBlockTeacher checks a new teacher the way the generator picks one: it
+// must be assigned to the block's course offering
+func checkBlockTeacher(offering *models.CourseOffering, teacherID uint) error {
+	for _, assignment := range offering.TeacherAssignments {
+		if assignment.TeacherID == teacherID {
+			return nil
+		}
+	}
+	return fmt.Errorf("teacher %d is not assigned to course offering %d", teacherID, offering.ID)
+}
+
+// checkBlockRoom checks a new room the way the generator picks one: a room
+// assigned to the block's course offering, or an active lab room for a lab and
+// theory room otherwise. Its features and seats are checked with the slot.
+func checkBlockRoom(offering *models.CourseOffering, block models.ScheduleBlock, room *models.Room) error {
+	for _, assignment := range offering.RoomAssignments {
+		if assignment.RoomID == room.ID {
+			return nil
+		}
+	}
+	kind := "THEORY"
+	if block.IsLab {
+		kind = "LAB"
+	}
+	if !room.IsActive || room.Type != kind {
+		return fmt.Errorf("room %d is not assigned to course offering %d nor an active %s room", room.ID, offering.ID, strings.ToLower(kind))
+	}
+	return nil
+}
+
+// PinScheduleBlock marks a block of a draft run as fixed in place, or frees it
+func (s *routineGenerationService) PinScheduleBlock(scheduleRunID uint, blockID uint, pinned bool) error {
+	_, locks, err := s.lockEditableBlocks(blockRef{scheduleRunID, blockID})
+	if err != nil {
+		return err
+	}
+	defer locks.release()
+	return s.scheduleRepo.SetScheduleBlockPinned(blockID, pinned)
+}
+
+// DeleteScheduleBlock removes a block and its entries from a draft run
+func (s *routineGenerationService) DeleteScheduleBlock(scheduleRunID uint, blockID uint) error {
+	_, locks, err := s.lockEditableBlocks(blockRef{scheduleRunID, blockID})
+	if err != nil {
+		return err
+	}
+	defer locks.release()
+	return s.scheduleRepo.DeleteScheduleBlock(blockID)
+}
+
+// lockLinkedBlocks locks blocks of the run for editing together with the
+// blocks of the rest of their elective baskets, and returns the parts of each
+// block, the block first. A combined class needs nothing more: its groups
+// share one block.
+func (s *routineGenerationService) lockLinkedBlocks(scheduleRunID uint, blockIDs ...uint) ([][]*blockEdit, *heldLocks, error) {
+	var refs []blockRef
+	var counts []int
+	for _, blockID := range blockIDs {
+		parts, err := s.electiveParts(scheduleRunID, blockID)
+		if err != nil {
+			return nil, nil, err
+		}
+		counts = append(counts, len(parts))
+		for _, part := range parts {
+			if containsRef(refs, part) {
+				return nil, nil, fmt.Errorf("block %d is part of the same elective block as another block being edited", part.blockID)
+			}
+			refs = append(refs, part)
+		}
+	}
+
+	edits, locks, err := s.lockEditableBlocks(refs...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The parts may have moved before the locks were taken
+	var current []blockRef
+	for _, blockID := range blockIDs {
+		parts, err := s.electiveParts(scheduleRunID, blockID)
+		if err != nil {
+			locks.release()
+			return nil, nil, err
+		}
+		current = append(current, parts...)
+	}
+	if len(current) != len(refs) {
+		locks.release()
+		return nil, nil, errors.New("the block's elective basket changed during the edit; try again")
+	}
+	for i := range refs {
+		if current[i] != refs[i] {
+			locks.release()
+			return nil, nil, errors.New("the block's elective basket changed during the edit; try again")
+		}
+	}
+
+	parts := make([][]*blockEdit, len(counts))
+	for i, count := range counts {
+		parts[i], edits = edits[:count], edits[count:]
+		if count > 1 {
+			for _, part := range parts[i] {
+				part.link = fmt.Sprintf("block-%d", parts[i][0].block.ID)
+			}
+		}
+	}
+	return parts, locks, nil
+}
+
+// electiveParts returns the block followed by the blocks at the same slot of
+// the other course offerings of its elective basket, in its run and, for a
+// session-wide generation, the other runs it produced. A part aligned with a
+// committed course offering of the basket cannot move apart from it.
+func (s *routineGenerationService) electiveParts(scheduleRunID uint, blockID uint) ([]blockRef, error) {
+	refs := []blockRef{{scheduleRunID, blockID}}
+	block, err := s.scheduleRepo.GetScheduleBlockByID(blockID)
+	if err != nil {
+		return nil, errors.New("schedule block not found")
+	}
+	offering, err := s.courseOfferingRepo.GetByID(block.CourseOfferingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course offering: %w", err)
+	}
+	if offering.ElectiveGroupID == nil {
+		return refs, nil
+	}
+	members, err := s.courseOfferingRepo.GetByElectiveGroup(*offering.ElectiveGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get elective group: %w", err)
+	}
+	var memberIDs []uint
+	for _, member := range members {
+		if member.ID != offering.ID {
+			memberIDs = append(memberIDs, member.ID)
+		}
+	}
+
+	run, err := s.scheduleRepo.GetScheduleRunByID(block.ScheduleRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule run: %w", err)
+	}
+	committed, err := s.scheduleRepo.GetCommittedScheduleEntries(run.SemesterOffering.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get committed schedule entries: %w", err)
+	}
+	for _, entry := range committed {
+		if containsUint(memberIDs, entry.CourseOfferingID) && entry.DayOfWeek == block.DayOfWeek &&
+			entry.SlotNumber >= block.SlotStart && entry.SlotNumber < block.SlotStart+block.SlotLength {
+			return nil, fmt.Errorf("block %d is aligned with committed course offering %d of elective group %d and cannot move apart from it",
+				block.ID, entry.CourseOfferingID, *offering.ElectiveGroupID)
+		}
+	}
+
+	runs := []models.ScheduleRun{*run}
+	if run.ParentRunID != nil {
+		parent, err := s.scheduleRepo.GetSessionScheduleRunByID(*run.ParentRunID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session schedule run: %w", err)
+		}
+		for _, sibling := range parent.ScheduleRuns {
+			if sibling.ID == run.ID {
+				continue
+			}
+			loaded, err := s.scheduleRepo.GetScheduleRunByID(sibling.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get schedule run: %w", err)
+			}
+			runs = append(runs, *loaded)
+		}
+	}
+	for _, r := range runs {
+		for _, other := range r.ScheduleBlocks {
+			if other.ID != block.ID && containsUint(memberIDs, other.CourseOfferingID) && other.DayOfWeek == block.DayOfWeek &&
+				other.SlotStart == block.SlotStart && other.SlotLength == block.SlotLength {
+				refs = append(refs, blockRef{r.ID, other.ID})
+			}
+		}
+	}
+	return refs, nil
+}
+
+func containsRef(refs []blockRef, ref blockRef) bool {
+	for _, r := range refs {
+		if r.blockID == ref.blockID {
+			return true
+		}
+	}
+	return false
+}
+
+// lockEditableBlocks loads blocks of their runs for editing while holding the
+// lock of every semester offering whose run holds them, so no generation,
+// commit or cancel of those runs runs alongside the edit. The blocks are read
+// again once the locks are held.
+func (s *routineGenerationService) lockEditableBlocks(refs ...blockRef) ([]*blockEdit, *heldLocks, error) {
+	var targets []lockTarget
+	for _, ref := range refs {
+		edit, err := s.editableBlock(ref.scheduleRunID, ref.blockID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range edit.semesterOfferingIDs {
+			target := lockTarget{LockSemesterOffering, id}
+			if !containsTarget(targets, target) {
+				targets = append(targets, target)
+			}
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].id < targets[j].id })
+
+	locks, err := s.locks.acquire(targets...)
+	if err != nil {
+		return nil, nil, err
+	}
+	locks.setRun(refs[0].scheduleRunID, 0)
+
+	edits := make([]*blockEdit, len(refs))
+	for i, ref := range refs {
+		edit, err := s.editableBlock(ref.scheduleRunID, ref.blockID)
+		if err != nil {
+			locks.release()
+			return nil, nil, err
+		}
+		edits[i] = edit
+	}
+	return edits, locks, nil
+}
+
+func containsTarget(targets []lockTarget, target lockTarget) bool {
+	for _, t := range targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// editableBlock loads a block of the run with its entries. Every run holding
+// the block's entries, several for a combined class, must be a draft.
+func (s *routineGenerationService) editableBlock(scheduleRunID uint, blockID uint) (*blockEdit, error) {
+	block, err := s.scheduleRepo.GetScheduleBlockByID(blockID)
+	if err != nil {
+		return nil, errors.New("schedule block not found")
+	}
+	entries, err := s.scheduleRepo.GetScheduleEntriesByBlock(blockID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule entries: %w", err)
+	}
+
+	runIDs := []uint{block.ScheduleRunID}
+	for _, entry := range entries {
+		if !containsUint(runIDs, entry.ScheduleRunID) {
+			runIDs = append(runIDs, entry.ScheduleRunID)
+		}
+	}
+	if !containsUint(runIDs, scheduleRunID) {
+		return nil, errors.New("schedule block not found in this run")
+	}
+	var semesterOfferingIDs []uint
+	for _, runID := range runIDs {
+		run, err := s.scheduleRepo.GetScheduleRunByID(runID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schedule run: %w", err)
+		}
+		if run.Status != "DRAFT" {
+			return nil, fmt.Errorf("schedule run %d is %s; only draft runs can be edited", runID, run.Status)
+		}
+		if !containsUint(semesterOfferingIDs, run.SemesterOfferingID) {
+			semesterOfferingIDs = append(semesterOfferingIDs, run.SemesterOfferingID)
+		}
+	}
+
+	return &blockEdit{block: *block, entries: entries, semesterOfferingIDs: semesterOfferingIDs}, nil
+}
+
+// applyBlockEdits checks the edited blocks against the grid, each other, the
+// rest of their runs and the committed entries of the session, and saves them
+// with fresh entries when nothing clashes
+func (s *routineGenerationService) applyBlockEdits(edits []blockEdit) ([]SlotConflict, error) {
+	conflicts, err := s.checkBlockEdits(edits)
+	if err != nil || len(conflicts) > 0 {
+		return conflicts, err
+	}
+
+	var blocks []models.ScheduleBlock
+	var entries []models.ScheduleEntry
+	for _, edit := range edits {
+		blocks = append(blocks, edit.block)
+		entries = append(entries, editedEntries(edit)...)
+	}
+	if err := s.scheduleRepo.ReplaceScheduleBlocks(blocks, entries); err != nil {
+		return nil, fmt.Errorf("failed to save schedule blocks: %w", err)
+	}
+	return nil, nil
+}
+
+// checkBlockEdits lists what stops the edited blocks from taking their new
+// slots, as the generator checks a placement: slots outside the grid or its
+// lab windows, a teacher marked unavailable or taken past their workload
+// limits, rooms lacking the subject's features or seats for the students, and
+// groups, teachers or rooms already held. The parts of an elective block may
+// share a group.
+func (s *routineGenerationService) checkBlockEdits(edits []blockEdit) ([]SlotConflict, error) {
+	conflicts := []SlotConflict{}
+
+	var sessionID uint
+	edited := make(map[uint]bool)
+	var runIDs, semesterOfferingIDs []uint
+	for _, edit := range edits {
+		edited[edit.block.ID] = true
+		for _, entry := range edit.entries {
+			sessionID = entry.SessionID
+			if !containsUint(runIDs, entry.ScheduleRunID) {
+				runIDs = append(runIDs, entry.ScheduleRunID)
+			}
+			if !containsUint(semesterOfferingIDs, entry.SemesterOfferingID) {
+				semesterOfferingIDs = append(semesterOfferingIDs, entry.SemesterOfferingID)
+			}
+		}
+	}
+	if sessionID == 0 {
+		return nil, errors.New("schedule block has no entries to edit")
+	}
+	sort.Slice(semesterOfferingIDs, func(i, j int) bool { return semesterOfferingIDs[i] < semesterOfferingIDs[j] })
+
+	var offerings []models.SemesterOffering
+	for _, id := range semesterOfferingIDs {
+		offering, err := s.semesterOfferingRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get semester offering %d: %w", id, err)
+		}
+		offerings = append(offerings, *offering)
+	}
+	grids, err := s.loadTimeGrids(offerings)
+	if err != nil {
+		return nil, err
+	}
+	availability, err := s.loadTeacherAvailability(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	workload, err := s.loadTeacherWorkload()
+	if err != nil {
+		return nil, err
+	}
+	rooms := s.loadRoomCatalog(offerings)
+
+	// Everything else the session holds: committed entries and the rest of
+	// the runs being edited
+	holders := &slotHolders{
+		groups:   make(map[groupSlot]slotHolder),
+		teachers: make(map[holderSlot]slotHolder),
+		rooms:    make(map[holderSlot]slotHolder),
+		tree:     newGroupTree(offerings),
+	}
+	teaching := make(map[uint]*slotBitset)
+	teach := func(entry *models.ScheduleEntry) {
+		if teaching[entry.TeacherID] == nil {
+			teaching[entry.TeacherID] = &slotBitset{}
+		}
+		teaching[entry.TeacherID].set(entry.DayOfWeek, entry.SlotNumber, 1)
+	}
+	committed, err := s.scheduleRepo.GetCommittedScheduleEntries(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get committed schedule entries: %w", err)
+	}
+	for i := range committed {
+		holders.addEntry(&committed[i], true)
+		teach(&committed[i])
+	}
+	for _, runID := range runIDs {
+		runEntries, err := s.scheduleRepo.GetScheduleEntriesByRun(runID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schedule entries: %w", err)
+		}
+		for i := range runEntries {
+			if runEntries[i].BlockID == nil || !edited[*runEntries[i].BlockID] {
+				holders.addEntry(&runEntries[i], false)
+				teach(&runEntries[i])
+			}
+		}
+	}
+
+	for _, edit := range edits {
+		block := edit.block
+		day, slot, length := block.DayOfWeek, block.SlotStart, block.SlotLength
+		key := edit.link
+		if key == "" {
+			key = fmt.Sprintf("block-%d", block.ID)
+		}
+
+		var inGrid []SlotConflict
+		var grid *timeGrid
+		for _, id := range semesterOfferingIDs {
+			if !editTouches(edit, id) {
+				continue
+			}
+			grid = grids[id]
+			if !grid.fits(day, slot, length) {
+				inGrid = append(inGrid, SlotConflict{Reason: BlockedOutsideGrid, ResourceID: id,
+					Message: fmt.Sprintf("block %d would run past the day's last slot or across a break", block.ID)})
+			} else if block.IsLab && !grid.allowsLabStart(day, slot) {
+				inGrid = append(inGrid, SlotConflict{Reason: BlockedLabWindow, ResourceID: id,
+					Message: fmt.Sprintf("block %d is a lab and labs may only start in the grid's lab windows", block.ID)})
+			}
+		}
+		if len(inGrid) > 0 {
+			conflicts = append(conflicts, inGrid...)
+			continue
+		}
+
+		// The students the block seats: a combined class's groups together
+		var seated models.ClassBlock
+		var courseOfferingIDs []uint
+		seen := make(map[groupKey]bool)
+		for _, entry := range edit.entries {
+			if !containsUint(courseOfferingIDs, entry.CourseOfferingID) {
+				courseOfferingIDs = append(courseOfferingIDs, entry.CourseOfferingID)
+			}
+			group := groupKey{entry.SemesterOfferingID, entry.StudentGroupID}
+			if seen[group] {
+				continue
+			}
+			seen[group] = true
+			part := models.ClassBlock{SemesterOfferingID: group.semesterOfferingID, StudentGroupID: group.studentGroupID}
+			if len(seen) == 1 {
+				seated = part
+			} else {
+				seated.Combined = append(seated.Combined, part)
+			}
+			for _, conflict := range holders.groupConflicts(group, day, slot, length) {
+				if conflict.holder != key {
+					conflicts = append(conflicts, conflict)
+				}
+			}
+		}
+		if availability.blocks(block.TeacherID, day, slot, length) {
+			conflicts = append(conflicts, SlotConflict{
+				Reason:     BlockedTeacherOff,
+				ResourceID: block.TeacherID,
+				Message:    fmt.Sprintf("teacher %d is marked unavailable", block.TeacherID),
+			})
+		}
+		if !workload.allows(block.TeacherID, teaching[block.TeacherID], grid, day, slot, length) {
+			conflicts = append(conflicts, SlotConflict{
+				Reason:     BlockedTeacherLoad,
+				ResourceID: block.TeacherID,
+				Message:    fmt.Sprintf("teacher %d would exceed their workload limits", block.TeacherID),
+			})
+		}
+		conflicts = append(conflicts, holders.conflicts(holders.teachers, BlockedTeacherBusy, block.TeacherID, day, slot, length)...)
+		blockRoomIDs := append([]uint{block.RoomID}, parseRoomIDs(block.SplitRoomIDs)...)
+		for _, roomID := range blockRoomIDs {
+			for _, courseOfferingID := range courseOfferingIDs {
+				if lacks := rooms.missing(courseOfferingID, roomID); len(lacks) > 0 {
+					conflicts = append(conflicts, SlotConflict{
+						Reason:     BlockedNoFeatures,
+						ResourceID: roomID,
+						Message:    fmt.Sprintf("room %d lacks %s", roomID, strings.Join(lacks, ", ")),
+					})
+				}
+			}
+			conflicts = append(conflicts, holders.conflicts(holders.rooms, BlockedRoomTaken, roomID, day, slot, length)...)
+		}
+		if !rooms.seats(seated, blockRoomIDs...) {
+			conflicts = append(conflicts, SlotConflict{
+				Reason:     BlockedRoomSmall,
+				ResourceID: block.RoomID,
+				Message:    fmt.Sprintf("the block's rooms seat fewer than its %d students", rooms.blockSize(seated)),
+			})
+		}
+
+		// Later edits must not clash with this one either
+		for _, entry := range editedEntries(edit) {
+			entry := entry
+			holders.addEntryAs(&entry, false, key)
+			teach(&entry)
+		}
+	}
+
+	return conflicts, nil
+}
+
+// editTouches reports whether the block has entries for the semester offering
+func editTouches(edit blockEdit, semesterOfferingID uint) bool {
+	for _, entry := range edit.entries {
+		if entry.SemesterOfferingID == semesterOfferingID {
+			return true
+		}
+	}
+	return false
+}
+
+// editedEntries rebuilds the block's entries for its new slot and resources:
+// one per slot for every run, group and course offering it had entries for
+func editedEntries(edit blockEdit) []models.ScheduleEntry {
+	type entryKey struct {
+		scheduleRunID    uint
+		studentGroupID   uint
+		courseOfferingID uint
+	}
+	block := edit.block
+	blockID := block.ID
+	seen := make(map[entryKey]bool)
+	var entries []models.ScheduleEntry
+	for _, old := range edit.entries {
+		key := entryKey{old.ScheduleRunID, old.StudentGroupID, old.CourseOfferingID}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		for i := 0; i < block.SlotLength; i++ {
+			entries = append(entries, models.ScheduleEntry{
+				ScheduleRunID:      old.ScheduleRunID,
+				SemesterOfferingID: old.SemesterOfferingID,
+				SessionID:          old.SessionID,
+				CourseOfferingID:   old.CourseOfferingID,
+				StudentGroupID:     old.StudentGroupID,
+				TeacherID:          block.TeacherID,
+				RoomID:             block.RoomID,
+				SplitRoomIDs:       block.SplitRoomIDs,
+				DayOfWeek:          block.DayOfWeek,
+				SlotNumber:         block.SlotStart + i,
+				BlockID:            &blockID,
+				CombinedClassID:    old.CombinedClassID,
+			})
+		}
+	}
+	return entries
+}
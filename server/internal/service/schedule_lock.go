@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"icrogen/internal/repository"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Resources a generation, commit or cancel locks
+const (
+	LockSemesterOffering = "semester offering"
+	LockSession          = "session"
+)
+
+// LockConflictError is returned when another generation, commit or cancel is
+// already working on the same semester offering or session
+type LockConflictError struct {
+	Resource           string `json:"resource"`
+	ResourceID         uint   `json:"resource_id"`
+	HeldByRunID        uint   `json:"held_by_run_id,omitempty"`         // Schedule run being generated, committed or cancelled
+	HeldBySessionRunID uint   `json:"held_by_session_run_id,omitempty"` // Session-wide generation holding it
+}
+
+func (e *LockConflictError) Error() string {
+	switch {
+	case e.HeldByRunID != 0:
+		return fmt.Sprintf("%s %d is busy with schedule run %d; try again when it finishes", e.Resource, e.ResourceID, e.HeldByRunID)
+	case e.HeldBySessionRunID != 0:
+		return fmt.Sprintf("%s %d is busy with session schedule run %d; try again when it finishes", e.Resource, e.ResourceID, e.HeldBySessionRunID)
+	default:
+		return fmt.Sprintf("%s %d is busy with another request; try again when it finishes", e.Resource, e.ResourceID)
+	}
+}
+
+// lockTarget is one resource to lock
+type lockTarget struct {
+	resource string
+	id       uint
+}
+
+// name is the lock's name in the database, at most 64 characters
+func (t lockTarget) name() string {
+	if t.resource == LockSession {
+		return fmt.Sprintf("icrogen.session.%d", t.id)
+	}
+	return fmt.Sprintf("icrogen.semester-offering.%d", t.id)
+}
+
+// lockHolder is the run a lock was taken for, once it exists
+type lockHolder struct {
+	runID        uint
+	sessionRunID uint
+}
+
+// scheduleLocks serialises the generations, commits and cancels of each
+// semester offering and session. Locks are database advisory locks, so they
+// hold across server processes; when the database fails to take one, that
+// lock serialises only requests to this process, and the next request tries
+// the database again. Holders are only known for locks taken by this process.
+type scheduleLocks struct {
+	repo       repository.ScheduleLockRepository
+	findHolder func(target lockTarget) uint // Run holding a lock taken by another process, or 0
+
+	mu   sync.Mutex
+	held map[string]*lockHolder
+}
+
+func newScheduleLocks(repo repository.ScheduleLockRepository, findHolder func(target lockTarget) uint) *scheduleLocks {
+	return &scheduleLocks{
+		repo:       repo,
+		findHolder: findHolder,
+		held:       make(map[string]*lockHolder),
+	}
+}
+
+// heldLocks are the locks taken for one request
+type heldLocks struct {
+	locks   *scheduleLocks
+	names   []string
+	unlocks []func() error
+	holder  *lockHolder
+}
+
+// acquire takes every target's lock without waiting, or none of them. When
+// one is taken, a LockConflictError names what holds it.
+func (l *scheduleLocks) acquire(targets ...lockTarget) (*heldLocks, error) {
+	held := &heldLocks{locks: l, holder: &lockHolder{}}
+	for _, target := range targets {
+		name := target.name()
+
+		l.mu.Lock()
+		if holder, busy := l.held[name]; busy {
+			conflict := &LockConflictError{Resource: target.resource, ResourceID: target.id,
+				HeldByRunID: holder.runID, HeldBySessionRunID: holder.sessionRunID}
+			l.mu.Unlock()
+			held.release()
+			return nil, conflict
+		}
+		l.held[name] = held.holder
+		l.mu.Unlock()
+		held.names = append(held.names, name)
+
+		unlock, acquired, err := l.repo.TryLock(context.Background(), name)
+		switch {
+		case err != nil:
+			logrus.Warnf("Database advisory lock %s unavailable, locking it in this process only: %v", name, err)
+		case !acquired:
+			held.release()
+			return nil, &LockConflictError{Resource: target.resource, ResourceID: target.id, HeldByRunID: l.findHolder(target)}
+		default:
+			held.unlocks = append(held.unlocks, unlock)
+		}
+	}
+	return held, nil
+}
+
+// setRun records the run the locks were taken for
+func (h *heldLocks) setRun(runID uint, sessionRunID uint) {
+	h.locks.mu.Lock()
+	defer h.locks.mu.Unlock()
+	h.holder.runID = runID
+	h.holder.sessionRunID = sessionRunID
+}
+
+func (h *heldLocks) release() {
+	for _, unlock := range h.unlocks {
+		if err := unlock(); err != nil {
+			logrus.Errorf("Failed to release schedule lock: %v", err)
+		}
+	}
+	h.unlocks = nil
+
+	h.locks.mu.Lock()
+	defer h.locks.mu.Unlock()
+	for _, name := range h.names {
+		if h.locks.held[name] == h.holder {
+			delete(h.locks.held, name)
+		}
+	}
+	h.names = nil
+}
+
+// offeringLock locks one semester offering
+func (s *routineGenerationService) offeringLock(semesterOfferingID uint) (*heldLocks, error) {
+	return s.locks.acquire(lockTarget{LockSemesterOffering, semesterOfferingID})
+}
+
+// runInProgress finds the run being generated for a semester offering by
+// another process, from its QUEUED or RUNNING status
+func (s *routineGenerationService) runInProgress(target lockTarget) uint {
+	if target.resource != LockSemesterOffering {
+		return 0
+	}
+	runs, err := s.scheduleRepo.GetScheduleRunsBySemesterOffering(target.id)
+	if err != nil {
+		return 0
+	}
+	for _, run := range runs {
+		if run.Status == "QUEUED" || run.Status == "RUNNING" {
+			return run.ID
+		}
+	}
+	return 0
+}
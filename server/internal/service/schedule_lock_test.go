@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"icrogen/internal/models"
+)
+
+// newTestLocks returns locks over the repository whose other-process holder
+// is always run 99
+func newTestLocks(repo *fakeLockRepo) *scheduleLocks {
+	return newScheduleLocks(repo, func(target lockTarget) uint { return 99 })
+}
+
+func conflictOf(t *testing.T, err error) *LockConflictError {
+	t.Helper()
+	var conflict *LockConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a LockConflictError, got %v", err)
+	}
+	return conflict
+}
+
+func TestScheduleLocksConflictInProcess(t *testing.T) {
+	locks := newTestLocks(newFakeLockRepo())
+	offering := lockTarget{LockSemesterOffering, 1}
+
+	held, err := locks.acquire(offering)
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+	held.setRun(7, 0)
+
+	_, err = locks.acquire(offering)
+	conflict := conflictOf(t, err)
+	if conflict.Resource != LockSemesterOffering || conflict.ResourceID != 1 || conflict.HeldByRunID != 7 {
+		t.Errorf("conflict names %+v, want semester offering 1 held by run 7", conflict)
+	}
+
+	held.release()
+	again, err := locks.acquire(offering)
+	if err != nil {
+		t.Fatalf("acquire after release failed: %v", err)
+	}
+	again.release()
+}
+
+func TestScheduleLocksConflictAcrossProcesses(t *testing.T) {
+	repo := newFakeLockRepo()
+	first, second := newTestLocks(repo), newTestLocks(repo)
+	session := lockTarget{LockSession, 3}
+
+	held, err := first.acquire(session)
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+
+	_, err = second.acquire(session)
+	conflict := conflictOf(t, err)
+	if conflict.Resource != LockSession || conflict.ResourceID != 3 || conflict.HeldByRunID != 99 {
+		t.Errorf("conflict names %+v, want session 3 held by run 99", conflict)
+	}
+	if len(second.held) != 0 {
+		t.Errorf("refused acquire left in-process locks %v", second.held)
+	}
+
+	held.release()
+	if repo.isHeld(session.name()) {
+		t.Errorf("release left the database lock held")
+	}
+	if len(first.held) != 0 {
+		t.Errorf("release left in-process locks %v", first.held)
+	}
+}
+
+func TestScheduleLocksAllOrNothing(t *testing.T) {
+	repo := newFakeLockRepo()
+	other, locks := newTestLocks(repo), newTestLocks(repo)
+	free, taken := lockTarget{LockSemesterOffering, 1}, lockTarget{LockSemesterOffering, 2}
+
+	held, err := other.acquire(taken)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	defer held.release()
+
+	_, err = locks.acquire(free, taken)
+	conflictOf(t, err)
+	if repo.isHeld(free.name()) {
+		t.Errorf("refused acquire kept the database lock of %s", free.name())
+	}
+	if len(locks.held) != 0 {
+		t.Errorf("refused acquire left in-process locks %v", locks.held)
+	}
+}
+
+func TestScheduleLocksDatabaseErrorIsNotSticky(t *testing.T) {
+	repo := newFakeLockRepo()
+	locks := newTestLocks(repo)
+	offering := lockTarget{LockSemesterOffering, 1}
+
+	repo.setErr(errors.New("connection lost"))
+	held, err := locks.acquire(offering)
+	if err != nil {
+		t.Fatalf("acquire without database locks failed: %v", err)
+	}
+	_, err = locks.acquire(offering)
+	conflictOf(t, err)
+	held.release()
+
+	repo.setErr(nil)
+	calls := repo.calls
+	held, err = locks.acquire(offering)
+	if err != nil {
+		t.Fatalf("acquire after the database recovered failed: %v", err)
+	}
+	if repo.calls != calls+1 || !repo.isHeld(offering.name()) {
+		t.Errorf("acquire after the database recovered did not take the database lock")
+	}
+	held.release()
+	if repo.isHeld(offering.name()) {
+		t.Errorf("release left the database lock held")
+	}
+}
+
+type generationResult struct {
+	run *models.ScheduleRun
+	err error
+}
+
+func TestConcurrentGenerationsOfOneOffering(t *testing.T) {
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.ErrorLevel)
+	defer logrus.SetLevel(level)
+
+	offerings, teachers, rooms := syntheticSession(2)
+	repo := newFakeScheduleRepo()
+	repo.generateGate = newTestGate()
+	service := newFakeGenerationService(repo, offerings, teachers, rooms)
+	opts := GenerationOptions{TimeBudget: time.Minute}
+
+	first, second := make(chan generationResult, 1), make(chan generationResult, 1)
+	go func() {
+		run, err := service.GenerateRoutine(context.Background(), 1, opts)
+		first <- generationResult{run, err}
+	}()
+	go func() {
+		// Start once the first generation holds the offering with its run
+		<-repo.generateGate.entered
+		run, err := service.GenerateRoutine(context.Background(), 1, opts)
+		second <- generationResult{run, err}
+	}()
+
+	refused := <-second
+	close(repo.generateGate.release)
+	generated := <-first
+
+	if generated.err != nil {
+		t.Fatalf("first generation failed: %v", generated.err)
+	}
+	conflict := conflictOf(t, refused.err)
+	if conflict.Resource != LockSemesterOffering || conflict.ResourceID != 1 || conflict.HeldByRunID != generated.run.ID {
+		t.Errorf("conflict names %+v, want semester offering 1 held by run %d", conflict, generated.run.ID)
+	}
+	if len(repo.runs) != 1 {
+		t.Errorf("refused generation created a run: %d runs", len(repo.runs))
+	}
+}
+
+// clashingDrafts stores two draft runs of different semester offerings of
+// session 1 that both have teacher 5 on Monday's first slot
+func clashingDrafts(repo *fakeScheduleRepo) (*models.ScheduleRun, *models.ScheduleRun) {
+	runs := make([]*models.ScheduleRun, 2)
+	for i := range runs {
+		offeringID := uint(i + 1)
+		blockID := uint(100 + i)
+		run := &models.ScheduleRun{
+			SemesterOfferingID: offeringID,
+			Status:             "DRAFT",
+			SemesterOffering:   models.SemesterOffering{ID: offeringID, SessionID: 1},
+		}
+		repo.CreateScheduleRun(run)
+		run.ScheduleEntries = []models.ScheduleEntry{{
+			ScheduleRunID:      run.ID,
+			SemesterOfferingID: offeringID,
+			SessionID:          1,
+			CourseOfferingID:   uint(10 + i),
+			TeacherID:          5,
+			RoomID:             uint(20 + i),
+			DayOfWeek:          1,
+			SlotNumber:         1,
+			BlockID:            &blockID,
+		}}
+		runs[i] = run
+	}
+	return runs[0], runs[1]
+}
+
+func TestConcurrentCommitsInOneSession(t *testing.T) {
+	repo := newFakeScheduleRepo()
+	repo.commitGate = newTestGate()
+	service := newFakeGenerationService(repo, nil, nil, nil)
+	first, second := clashingDrafts(repo)
+
+	committed, refused := make(chan error, 1), make(chan error, 1)
+	go func() { committed <- service.CommitScheduleRun(first.ID) }()
+	go func() {
+		// Start once the first commit holds the session
+		<-repo.commitGate.entered
+		refused <- service.CommitScheduleRun(second.ID)
+	}()
+
+	err := <-refused
+	close(repo.commitGate.release)
+	if err := <-committed; err != nil {
+		t.Fatalf("first commit failed: %v", err)
+	}
+	conflict := conflictOf(t, err)
+	if conflict.Resource != LockSession || conflict.ResourceID != 1 || conflict.HeldByRunID != first.ID {
+		t.Errorf("conflict names %+v, want session 1 held by run %d", conflict, first.ID)
+	}
+
+	// Once the first is committed the second clashes with it
+	var clash *CommitConflictError
+	if err := service.CommitScheduleRun(second.ID); !errors.As(err, &clash) {
+		t.Fatalf("expected a CommitConflictError, got %v", err)
+	}
+	if len(repo.committed) != 1 || repo.committed[0].ScheduleRunID != first.ID {
+		t.Errorf("committed entries %+v, want only run %d's", repo.committed, first.ID)
+	}
+}
+
+func TestConcurrentCommitsNeverOverlap(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		repo := newFakeScheduleRepo()
+		service := newFakeGenerationService(repo, nil, nil, nil)
+		first, second := clashingDrafts(repo)
+
+		start := make(chan struct{})
+		results := make(chan error, 2)
+		for _, run := range []*models.ScheduleRun{first, second} {
+			go func(id uint) {
+				<-start
+				results <- service.CommitScheduleRun(id)
+			}(run.ID)
+		}
+		close(start)
+
+		succeeded := 0
+		for j := 0; j < 2; j++ {
+			err := <-results
+			var conflict *LockConflictError
+			var clash *CommitConflictError
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.As(err, &conflict), errors.As(err, &clash):
+			default:
+				t.Fatalf("unexpected commit error: %v", err)
+			}
+		}
+		if succeeded != 1 {
+			t.Fatalf("%d of two clashing runs committed, want 1", succeeded)
+		}
+		if len(repo.committed) != 1 {
+			t.Fatalf("committed %d entries for teacher 5 on Monday's first slot, want 1", len(repo.committed))
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"icrogen/internal/repository"
+)
+
+// SoftConstraintService interface for soft constraint business logic
+type SoftConstraintService interface {
+	CreateSoftConstraint(constraint *models.SoftConstraint) error
+	GetSoftConstraintByID(id uint) (*models.SoftConstraint, error)
+	GetAllSoftConstraints() ([]models.SoftConstraint, error)
+	GetSoftConstraintsByProgrammeID(programmeID uint) ([]models.SoftConstraint, error)
+	GetSoftConstraintsByDepartmentID(departmentID uint) ([]models.SoftConstraint, error)
+	UpdateSoftConstraint(constraint *models.SoftConstraint) error
+	DeleteSoftConstraint(id uint) error
+	GetSoftConstraintTypes() []SoftConstraintType
+}
+
+type softConstraintService struct {
+	softConstraintRepo repository.SoftConstraintRepository
+	programmeRepo      repository.ProgrammeRepository
+	departmentRepo     repository.DepartmentRepository
+}
+
+// NewSoftConstraintService creates a new soft constraint service
+func NewSoftConstraintService(
+	softConstraintRepo repository.SoftConstraintRepository,
+	programmeRepo repository.ProgrammeRepository,
+	departmentRepo repository.DepartmentRepository,
+) SoftConstraintService {
+	return &softConstraintService{
+		softConstraintRepo: softConstraintRepo,
+		programmeRepo:      programmeRepo,
+		departmentRepo:     departmentRepo,
+	}
+}
+
+func (s *softConstraintService) CreateSoftConstraint(constraint *models.SoftConstraint) error {
+	if err := s.validateSoftConstraint(constraint); err != nil {
+		return err
+	}
+	return s.softConstraintRepo.Create(constraint)
+}
+
+func (s *softConstraintService) GetSoftConstraintByID(id uint) (*models.SoftConstraint, error) {
+	if id == 0 {
+		return nil, errors.New("invalid soft constraint ID")
+	}
+	return s.softConstraintRepo.GetByID(id)
+}
+
+func (s *softConstraintService) GetAllSoftConstraints() ([]models.SoftConstraint, error) {
+	return s.softConstraintRepo.GetAll()
+}
+
+func (s *softConstraintService) GetSoftConstraintsByProgrammeID(programmeID uint) ([]models.SoftConstraint, error) {
+	if programmeID == 0 {
+		return nil, errors.New("invalid programme ID")
+	}
+	return s.softConstraintRepo.GetByProgrammeID(programmeID)
+}
+
+func (s *softConstraintService) GetSoftConstraintsByDepartmentID(departmentID uint) ([]models.SoftConstraint, error) {
+	if departmentID == 0 {
+		return nil, errors.New("invalid department ID")
+	}
+	return s.softConstraintRepo.GetByDepartmentID(departmentID)
+}
+
+func (s *softConstraintService) UpdateSoftConstraint(constraint *models.SoftConstraint) error {
+	if constraint.ID == 0 {
+		return errors.New("soft constraint ID is required for update")
+	}
+	if _, err := s.softConstraintRepo.GetByID(constraint.ID); err != nil {
+		return errors.New("soft constraint not found")
+	}
+	if err := s.validateSoftConstraint(constraint); err != nil {
+		return err
+	}
+	return s.softConstraintRepo.Update(constraint)
+}
+
+func (s *softConstraintService) DeleteSoftConstraint(id uint) error {
+	if id == 0 {
+		return errors.New("invalid soft constraint ID")
+	}
+	return s.softConstraintRepo.Delete(id)
+}
+
+func (s *softConstraintService) GetSoftConstraintTypes() []SoftConstraintType {
+	return SoftConstraintTypes
+}
+
+func (s *softConstraintService) validateSoftConstraint(constraint *models.SoftConstraint) error {
+	constraintType, known := softConstraintType(constraint.Type)
+	if !known {
+		return fmt.Errorf("unknown soft constraint type %q", constraint.Type)
+	}
+	if constraint.Weight < 0 {
+		return errors.New("weight cannot be negative")
+	}
+
+	// Parameters must be integers the constraint knows about
+	if constraint.Params == "" {
+		constraint.Params = "{}"
+	}
+	var params map[string]int
+	if err := json.Unmarshal([]byte(constraint.Params), &params); err != nil {
+		return errors.New("params must be a JSON object of integer values")
+	}
+	for name, value := range params {
+		if _, ok := constraintType.Params[name]; !ok {
+			return fmt.Errorf("%s has no parameter %q", constraint.Type, name)
+		}
+		if value < 0 {
+			return fmt.Errorf("parameter %q cannot be negative", name)
+		}
+	}
+
+	// Exactly one scope: a whole programme or one department
+	if (constraint.ProgrammeID == nil) == (constraint.DepartmentID == nil) {
+		return errors.New("exactly one of programme ID or department ID is required")
+	}
+	var existing []models.SoftConstraint
+	if constraint.ProgrammeID != nil {
+		if _, err := s.programmeRepo.GetByID(*constraint.ProgrammeID); err != nil {
+			return errors.New("invalid programme ID")
+		}
+		found, err := s.softConstraintRepo.GetByProgrammeID(*constraint.ProgrammeID)
+		if err != nil {
+			return err
+		}
+		existing = found
+	} else {
+		if _, err := s.departmentRepo.GetByID(*constraint.DepartmentID); err != nil {
+			return errors.New("invalid department ID")
+		}
+		found, err := s.softConstraintRepo.GetByDepartmentID(*constraint.DepartmentID)
+		if err != nil {
+			return err
+		}
+		existing = found
+	}
+
+	// One setting per constraint type and scope
+	for _, other := range existing {
+		if other.Type == constraint.Type && other.ID != constraint.ID {
+			return fmt.Errorf("%s is already set for this scope (soft constraint %d)", constraint.Type, other.ID)
+		}
+	}
+
+	return nil
+}
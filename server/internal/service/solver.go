@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"sort"
+	"time"
+)
+
+// Solver strategies that can be requested for a generation run
+const (
+	StrategyBacktracking = "backtracking"
+	StrategyPropagation  = "propagation"
+	StrategyLocalSearch  = "local-search"
+)
+
+// Defaults bounding a generation run when the request does not set its own
+const (
+	defaultTimeBudget = 30 * time.Second
+	defaultNodeBudget = 200000
+)
+
+// Reasons a search stopped before it had finished
+const (
+	StopDeadline   = "deadline"
+	StopCancelled  = "cancelled"
+	StopNodeBudget = "node-budget"
+)
+
+// GenerationOptions selects the solver used for a generation run and how much
+// searching it may do
+type GenerationOptions struct {
+	Strategy   string        `json:"strategy"`
+	TimeBudget time.Duration `json:"time_budget"`
+	NodeBudget int           `json:"node_budget"` // Placements a search may try per pass
+}
+
+func (o GenerationOptions) timeBudget() time.Duration {
+	if o.TimeBudget <= 0 {
+		return defaultTimeBudget
+	}
+	return o.TimeBudget
+}
+
+func (o GenerationOptions) nodeBudget() int {
+	if o.NodeBudget <= 0 {
+		return defaultNodeBudget
+	}
+	return o.NodeBudget
+}
+
+// SearchStats describes how much work a solver did
+type SearchStats struct {
+	Nodes      int    `json:"nodes"`                 // Placements tried
+	Backtracks int    `json:"backtracks"`            // Placements undone after a dead end
+	Iterations int    `json:"iterations,omitempty"`  // Local search moves attempted
+	StopReason string `json:"stop_reason,omitempty"` // deadline, cancelled or node-budget when the search was cut short
+	ElapsedMs  int64  `json:"elapsed_ms"`
+}
+
+// stop reports whether the search must end now, recording why
+func (st *SearchStats) stop(ctx context.Context, nodeBudget int) bool {
+	if st.StopReason != "" {
+		return true
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		st.StopReason = StopDeadline
+	case ctx.Err() != nil:
+		st.StopReason = StopCancelled
+	case nodeBudget > 0 && st.Nodes >= nodeBudget:
+		st.StopReason = StopNodeBudget
+	default:
+		return false
+	}
+	return true
+}
+
+// add accumulates the stats of another pass
+func (st *SearchStats) add(other SearchStats) {
+	st.Nodes += other.Nodes
+	st.Backtracks += other.Backtracks
+	st.Iterations += other.Iterations
+	st.ElapsedMs += other.ElapsedMs
+	if other.StopReason != "" {
+		st.StopReason = other.StopReason
+	}
+}
+
+// Solver places class blocks into a generation state. Implementations share the
+// same placement rules and scoring, so their results can be compared on the
+// same offering.
+type Solver interface {
+	// Name is recorded as the schedule run's AlgorithmVersion
+	Name() string
+	// Solve places as many blocks as it can before ctx is done or its budget is
+	// spent, and returns the blocks it could not place
+	Solve(ctx context.Context, blocks []models.ClassBlock, state *generationState) ([]models.ClassBlock, SearchStats)
+}
+
+// newSolver returns the solver for the requested strategy, backtracking by default
+func (s *routineGenerationService) newSolver(opts GenerationOptions) (Solver, error) {
+	switch opts.Strategy {
+	case "", StrategyBacktracking:
+		return &backtrackingSolver{svc: s, nodeBudget: opts.nodeBudget()}, nil
+	case StrategyPropagation:
+		return &propagationSolver{svc: s, nodeBudget: opts.nodeBudget()}, nil
+	case StrategyLocalSearch:
+		return &localSearchSolver{svc: s, nodeBudget: opts.nodeBudget(), seed: 1}, nil
+	default:
+		return nil, fmt.Errorf("unknown solver strategy %q", opts.Strategy)
+	}
+}
+
+// placement is one way of putting a block on the timetable
+type placement struct {
+	block models.ClassBlock // With the teacher and room chosen for this slot
+	day   int
+	slot  int
+	score int
+}
+
+// validPlacements lists every day/slot where the block can go right now, best
+// scored first
+func (s *routineGenerationService) validPlacements(block models.ClassBlock, state *generationState) []placement {
+	grid := state.grids[block.SemesterOfferingID]
+
+	var placements []placement
+	for _, day := range grid.days {
+		for _, slot := range grid.startSlots(day, block) {
+			if chosen, ok := s.chooseResources(block, day, slot, state); ok {
+				placements = append(placements, placement{chosen, day, slot, s.placementScore(chosen, day, slot, state)})
+			}
+		}
+	}
+
+	// Sort by score (higher score = better placement)
+	sort.SliceStable(placements, func(i, j int) bool {
+		return placements[i].score > placements[j].score
+	})
+
+	return placements
+}
+
+// collectPlacements reads back every block currently placed on the timetables
+func (s *routineGenerationService) collectPlacements(state *generationState) []placement {
+	var placements []placement
+
+	keys := make([]groupKey, 0, len(state.timetables))
+	for key := range state.timetables {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].semesterOfferingID != keys[j].semesterOfferingID {
+			return keys[i].semesterOfferingID < keys[j].semesterOfferingID
+		}
+		return keys[i].studentGroupID < keys[j].studentGroupID
+	})
+
+	// Elective blocks are booked for several groups under one pointer
+	seen := make(map[*models.ClassBlock]bool)
+	for _, key := range keys {
+		grid, timetable := state.grids[key.semesterOfferingID], state.timetables[key]
+		for _, day := range grid.days {
+			for _, slot := range grid.slots(day) {
+				slotInfo := timetable[day][slot]
+				if slotInfo.Block == nil {
+					continue
+				}
+				// Multi-slot blocks share one pointer; only count where they start
+				if previous := timetable[day][slot-1]; previous.Block == slotInfo.Block || seen[slotInfo.Block] {
+					continue
+				}
+				seen[slotInfo.Block] = true
+				placements = append(placements, placement{block: *slotInfo.Block, day: day, slot: slot})
+			}
+		}
+	}
+
+	return placements
+}
+
+// sortBlocksByConstraints orders blocks most constrained first
+func (s *routineGenerationService) sortBlocksByConstraints(blocks []models.ClassBlock) {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		// Labs first (more constrained - need 3 consecutive slots)
+		if blocks[i].IsLab && !blocks[j].IsLab {
+			return true
+		}
+		if !blocks[i].IsLab && blocks[j].IsLab {
+			return false
+		}
+
+		// Then by duration (longer blocks first)
+		if blocks[i].DurationSlots != blocks[j].DurationSlots {
+			return blocks[i].DurationSlots > blocks[j].DurationSlots
+		}
+
+		// Then by teacher ID (group by teacher)
+		return blocks[i].TeacherID < blocks[j].TeacherID
+	})
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"icrogen/internal/models"
+)
+
+// backtrackingSolver is a depth-first search that places blocks most
+// constrained first, trying each block's slots best scored first, and undoes
+// placements until every block fits or its budget runs out. In the latter case
+// it keeps the deepest partial assignment it reached and places whatever else
+// still fits around it.
+type backtrackingSolver struct {
+	svc        *routineGenerationService
+	nodeBudget int
+}
+
+func (b *backtrackingSolver) Name() string {
+	return "backtracking-v1.1"
+}
+
+type backtrackSearch struct {
+	svc        *routineGenerationService
+	state      *generationState
+	nodeBudget int
+	stats      SearchStats
+	current    []placement
+	best       []placement
+}
+
+func (b *backtrackingSolver) Solve(ctx context.Context, blocks []models.ClassBlock, state *generationState) ([]models.ClassBlock, SearchStats) {
+	// Sort blocks by constraint priority (most constrained first)
+	b.svc.sortBlocksByConstraints(blocks)
+
+	// A block with no slot at all can never be placed, since placing other
+	// blocks only removes options; searching past it would be wasted work
+	var searchable, unplaced []models.ClassBlock
+	for _, block := range blocks {
+		if len(b.svc.validPlacements(block, state)) == 0 {
+			unplaced = append(unplaced, block)
+		} else {
+			searchable = append(searchable, block)
+		}
+	}
+
+	search := &backtrackSearch{
+		svc:        b.svc,
+		state:      state,
+		nodeBudget: b.nodeBudget,
+	}
+	if search.dfs(ctx, searchable, 0) {
+		return unplaced, search.stats
+	}
+
+	// The search has undone its placements; replay the deepest partial
+	// assignment it reached, then place the remaining blocks where they still fit
+	for _, p := range search.best {
+		b.svc.placeBlock(p.block, p.day, p.slot, state)
+	}
+	for _, block := range searchable[len(search.best):] {
+		if options := b.svc.validPlacements(block, state); len(options) > 0 {
+			b.svc.placeBlock(options[0].block, options[0].day, options[0].slot, state)
+		} else {
+			unplaced = append(unplaced, block)
+		}
+	}
+
+	return unplaced, search.stats
+}
+
+// dfs places blocks[index:] and returns true once all of them are placed
+func (bs *backtrackSearch) dfs(ctx context.Context, blocks []models.ClassBlock, index int) bool {
+	if index > len(bs.best) {
+		bs.best = append(bs.best[:0], bs.current...)
+	}
+
+	// Base case: all blocks placed
+	if index >= len(blocks) {
+		return true
+	}
+	bs.state.progress.searched(bs.stats)
+	if bs.stats.stop(ctx, bs.nodeBudget) {
+		return false
+	}
+
+	// Try placements in order of preference
+	for _, p := range bs.svc.validPlacements(blocks[index], bs.state) {
+		bs.stats.Nodes++
+		bs.svc.placeBlock(p.block, p.day, p.slot, bs.state)
+		bs.current = append(bs.current, p)
+
+		if bs.dfs(ctx, blocks, index+1) {
+			return true
+		}
+
+		// Backtrack
+		bs.current = bs.current[:len(bs.current)-1]
+		bs.svc.removeBlock(p.block, p.day, p.slot, bs.state)
+		bs.stats.Backtracks++
+
+		if bs.stats.stop(ctx, bs.nodeBudget) {
+			return false
+		}
+	}
+
+	// No placement of this block leads to a full solution
+	return false
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"icrogen/internal/models"
+	"math"
+	"math/rand"
+)
+
+// Local search tuning. Scores are in scorePlacement units, so a starting
+// temperature of 10 accepts a move that is 10 points worse about a third of
+// the time.
+const (
+	localSearchMaxIterations    = 20000
+	localSearchStartTemperature = 10.0
+	localSearchCooling          = 0.9995
+	localSearchTabuTenure       = 50  // Iterations a block may not return to a slot it left
+	localSearchRetryInterval    = 100 // Iterations between attempts to place unplaced blocks
+)
+
+// localSearchSolver starts from the backtracking solver's timetable and keeps
+// moving one block at a time to another valid slot. Worse moves are accepted
+// with a probability that cools over the run (simulated annealing), and a block
+// may not move straight back to a slot it just left (tabu list). Blocks left
+// unplaced are retried as the timetable changes around them. Blocks kept from
+// the run being regenerated never move.
+type localSearchSolver struct {
+	svc        *routineGenerationService
+	nodeBudget int // Passed on to the backtracking start
+	seed       int64
+}
+
+func (l *localSearchSolver) Name() string {
+	return "local-search-v1.1"
+}
+
+// tabuMove is a block, by its index in the current placements, returning to a day/slot
+type tabuMove struct {
+	index int
+	day   int
+	slot  int
+}
+
+func (l *localSearchSolver) Solve(ctx context.Context, blocks []models.ClassBlock, state *generationState) ([]models.ClassBlock, SearchStats) {
+	start := &backtrackingSolver{svc: l.svc, nodeBudget: l.nodeBudget}
+	unplaced, stats := start.Solve(ctx, blocks, state)
+
+	rng := rand.New(rand.NewSource(l.seed))
+	var current []placement
+	for _, p := range l.svc.collectPlacements(state) {
+		if !p.block.Fixed {
+			current = append(current, p)
+		}
+	}
+
+	// Scores are tracked relative to the starting timetable
+	total, bestTotal := 0, 0
+	best := append([]placement(nil), current...)
+	tabu := make(map[tabuMove]int)
+	temperature := localSearchStartTemperature
+
+	// Running out of nodes only cuts the backtracking start short; the walk is
+	// bounded by its iterations and ctx
+	startReason := stats.StopReason
+	if startReason == StopNodeBudget {
+		stats.StopReason = ""
+	}
+
+	for iteration := 0; iteration < localSearchMaxIterations; iteration++ {
+		if stats.stop(ctx, 0) {
+			break
+		}
+		stats.Iterations++
+		if iteration%localSearchRetryInterval == 0 && len(unplaced) > 0 {
+			var stillUnplaced []models.ClassBlock
+			for _, block := range unplaced {
+				options := l.svc.validPlacements(block, state)
+				if len(options) == 0 {
+					stillUnplaced = append(stillUnplaced, block)
+					continue
+				}
+				l.svc.placeBlock(options[0].block, options[0].day, options[0].slot, state)
+				current = append(current, options[0])
+				total += options[0].score
+			}
+			if len(stillUnplaced) < len(unplaced) {
+				// Placing a block beats any score, so this is the new best
+				best, bestTotal = append(best[:0], current...), total
+			}
+			unplaced = stillUnplaced
+		}
+
+		if len(current) == 0 {
+			break
+		}
+
+		i := rng.Intn(len(current))
+		old := current[i]
+		l.svc.removeBlock(old.block, old.day, old.slot, state)
+
+		var moves []placement
+		for _, option := range l.svc.validPlacements(old.block, state) {
+			if option.day == old.day && option.slot == old.slot {
+				continue
+			}
+			if until, isTabu := tabu[tabuMove{i, option.day, option.slot}]; isTabu && until > iteration {
+				continue
+			}
+			moves = append(moves, option)
+		}
+
+		accepted := false
+		if len(moves) > 0 {
+			move := moves[rng.Intn(len(moves))]
+			delta := move.score - l.svc.placementScore(old.block, old.day, old.slot, state)
+			if delta >= 0 || rng.Float64() < math.Exp(float64(delta)/temperature) {
+				l.svc.placeBlock(move.block, move.day, move.slot, state)
+				current[i] = move
+				tabu[tabuMove{i, old.day, old.slot}] = iteration + localSearchTabuTenure
+				total += delta
+				accepted = true
+			}
+		}
+		if !accepted {
+			l.svc.placeBlock(old.block, old.day, old.slot, state)
+		}
+
+		if total > bestTotal {
+			best, bestTotal = append(best[:0], current...), total
+		}
+		state.progress.searched(stats)
+		state.progress.scored(bestTotal)
+		temperature *= localSearchCooling
+	}
+
+	if stats.StopReason == "" {
+		stats.StopReason = startReason
+	}
+
+	// Settle on the best timetable seen rather than wherever the walk stopped
+	if total < bestTotal {
+		for _, p := range current {
+			l.svc.removeBlock(p.block, p.day, p.slot, state)
+		}
+		for _, p := range best {
+			l.svc.placeBlock(p.block, p.day, p.slot, state)
+		}
+	}
+
+	return unplaced, stats
+}
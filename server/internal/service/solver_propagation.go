@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"icrogen/internal/models"
+)
+
+// propagationSolver is a constraint-propagation search: after every placement
+// it forward-checks the remaining blocks, pruning as soon as one of them has no
+// slot left, and it always branches on the block with the fewest remaining
+// slots (MRV), breaking ties by how many other blocks it competes with (degree)
+type propagationSolver struct {
+	svc        *routineGenerationService
+	nodeBudget int
+}
+
+func (p *propagationSolver) Name() string {
+	return "propagation-v1.1"
+}
+
+// assignment is a block placed during the search
+type assignment struct {
+	index     int
+	placement placement
+}
+
+type propagationSearch struct {
+	svc        *routineGenerationService
+	state      *generationState
+	blocks     []models.ClassBlock
+	neighbors  [][]int // Blocks sharing a student group or teacher
+	remaining  []bool
+	nodeBudget int
+	stats      SearchStats
+	current    []assignment
+	best       []assignment
+}
+
+func (p *propagationSolver) Solve(ctx context.Context, blocks []models.ClassBlock, state *generationState) ([]models.ClassBlock, SearchStats) {
+	search := &propagationSearch{
+		svc:        p.svc,
+		state:      state,
+		blocks:     blocks,
+		neighbors:  blockNeighbors(blocks),
+		remaining:  make([]bool, len(blocks)),
+		nodeBudget: p.nodeBudget,
+	}
+
+	// Blocks with no slot at all can never be placed, since placing other
+	// blocks only removes options; leave them out of the search
+	var unplaceable []int
+	domains := make(map[int][]placement)
+	var remaining []int
+	for i := range blocks {
+		domain := p.svc.validPlacements(blocks[i], state)
+		if len(domain) == 0 {
+			unplaceable = append(unplaceable, i)
+			continue
+		}
+		domains[i] = domain
+		remaining = append(remaining, i)
+		search.remaining[i] = true
+	}
+
+	if !search.run(ctx, remaining, domains) {
+		// The search has undone its placements; replay the deepest partial
+		// assignment it reached, then place what still fits greedily
+		placed := make(map[int]bool)
+		for _, a := range search.best {
+			p.svc.placeBlock(a.placement.block, a.placement.day, a.placement.slot, state)
+			placed[a.index] = true
+		}
+		for _, i := range remaining {
+			if placed[i] {
+				continue
+			}
+			if options := p.svc.validPlacements(blocks[i], state); len(options) > 0 {
+				p.svc.placeBlock(options[0].block, options[0].day, options[0].slot, state)
+				placed[i] = true
+			}
+		}
+		for _, i := range remaining {
+			if !placed[i] {
+				unplaceable = append(unplaceable, i)
+			}
+		}
+	}
+
+	unplaced := make([]models.ClassBlock, 0, len(unplaceable))
+	for _, i := range unplaceable {
+		unplaced = append(unplaced, blocks[i])
+	}
+	return unplaced, search.stats
+}
+
+// run places the remaining blocks, returning true once all of them are placed.
+// domains holds the placements still open to each remaining block.
+func (ps *propagationSearch) run(ctx context.Context, remaining []int, domains map[int][]placement) bool {
+	if len(ps.current) > len(ps.best) {
+		ps.best = append([]assignment(nil), ps.current...)
+	}
+	if len(remaining) == 0 {
+		return true
+	}
+	ps.state.progress.searched(ps.stats)
+	if ps.stats.stop(ctx, ps.nodeBudget) {
+		return false
+	}
+
+	pick := ps.selectBlock(remaining, domains)
+	rest := make([]int, 0, len(remaining)-1)
+	for _, i := range remaining {
+		if i != pick {
+			rest = append(rest, i)
+		}
+	}
+
+	ps.remaining[pick] = false
+	for _, option := range domains[pick] {
+		ps.stats.Nodes++
+		ps.svc.placeBlock(option.block, option.day, option.slot, ps.state)
+		ps.current = append(ps.current, assignment{pick, option})
+
+		if next, ok := ps.forwardCheck(rest, domains); ok && ps.run(ctx, rest, next) {
+			return true
+		}
+
+		ps.current = ps.current[:len(ps.current)-1]
+		ps.svc.removeBlock(option.block, option.day, option.slot, ps.state)
+		ps.stats.Backtracks++
+
+		if ps.stats.stop(ctx, ps.nodeBudget) {
+			break
+		}
+	}
+	ps.remaining[pick] = true
+
+	return false
+}
+
+// selectBlock picks the remaining block with the fewest placements left,
+// preferring the one that competes with the most other remaining blocks
+func (ps *propagationSearch) selectBlock(remaining []int, domains map[int][]placement) int {
+	pick := remaining[0]
+	pickDegree := ps.degree(pick)
+	for _, i := range remaining[1:] {
+		size, pickSize := len(domains[i]), len(domains[pick])
+		if size > pickSize {
+			continue
+		}
+		degree := ps.degree(i)
+		if size < pickSize || degree > pickDegree {
+			pick, pickDegree = i, degree
+		}
+	}
+	return pick
+}
+
+func (ps *propagationSearch) degree(index int) int {
+	degree := 0
+	for _, neighbor := range ps.neighbors[index] {
+		if ps.remaining[neighbor] {
+			degree++
+		}
+	}
+	return degree
+}
+
+// forwardCheck narrows the domain of every remaining block to the slots still
+// open after the last placement, failing as soon as one domain is empty
+func (ps *propagationSearch) forwardCheck(rest []int, domains map[int][]placement) (map[int][]placement, bool) {
+	next := make(map[int][]placement, len(rest))
+	for _, i := range rest {
+		var domain []placement
+		for _, option := range domains[i] {
+			if chosen, ok := ps.svc.chooseResources(ps.blocks[i], option.day, option.slot, ps.state); ok {
+				score := ps.svc.placementScore(chosen, option.day, option.slot, ps.state)
+				domain = append(domain, placement{chosen, option.day, option.slot, score})
+			}
+		}
+		if len(domain) == 0 {
+			return nil, false
+		}
+		next[i] = domain
+	}
+	return next, true
+}
+
+// blockNeighbors lists, for every block, the other blocks that share its
+// student group or one of its candidate teachers
+func blockNeighbors(blocks []models.ClassBlock) [][]int {
+	neighbors := make([][]int, len(blocks))
+	for i := range blocks {
+		for j := range blocks {
+			if i != j && blocksCompete(blocks[i], blocks[j]) {
+				neighbors[i] = append(neighbors[i], j)
+			}
+		}
+	}
+	return neighbors
+}
+
+// blocksCompete reports whether two blocks may want the same group or teacher;
+// elective blocks compete through any of their parts
+func blocksCompete(a, b models.ClassBlock) bool {
+	for _, partA := range blockParts(a) {
+		for _, partB := range blockParts(b) {
+			if partsCompete(partA, partB) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func partsCompete(a, b models.ClassBlock) bool {
+	if a.SemesterOfferingID == b.SemesterOfferingID {
+		return true
+	}
+	for _, teacherID := range candidatesOrSelf(a.TeacherCandidates, a.TeacherID) {
+		if containsUint(candidatesOrSelf(b.TeacherCandidates, b.TeacherID), teacherID) {
+			return true
+		}
+	}
+	return false
+}
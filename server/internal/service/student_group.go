@@ -0,0 +1,283 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"icrogen/internal/repository"
+	"sort"
+	"strings"
+)
+
+// Kinds of student groups
+const (
+	GroupSection = "SECTION"
+	GroupBatch   = "BATCH" // A lab batch within a section
+)
+
+// StudentGroupService interface for section and batch business logic
+type StudentGroupService interface {
+	CreateGroup(group *models.StudentGroup) error
+	GetGroupByID(id uint) (*models.StudentGroup, error)
+	GetGroupsBySemesterOffering(semesterOfferingID uint) ([]models.StudentGroup, error)
+	UpdateGroup(group *models.StudentGroup) error
+	DeleteGroup(id uint) error
+}
+
+type studentGroupService struct {
+	groupRepo            repository.StudentGroupRepository
+	semesterOfferingRepo repository.SemesterOfferingRepository
+}
+
+// NewStudentGroupService creates a new student group service
+func NewStudentGroupService(
+	groupRepo repository.StudentGroupRepository,
+	semesterOfferingRepo repository.SemesterOfferingRepository,
+) StudentGroupService {
+	return &studentGroupService{
+		groupRepo:            groupRepo,
+		semesterOfferingRepo: semesterOfferingRepo,
+	}
+}
+
+func (s *studentGroupService) CreateGroup(group *models.StudentGroup) error {
+	group.Name = strings.TrimSpace(group.Name)
+	if group.Name == "" {
+		return errors.New("group name is required")
+	}
+	if group.Strength < 0 {
+		return errors.New("strength cannot be negative")
+	}
+	if _, err := s.semesterOfferingRepo.GetByID(group.SemesterOfferingID); err != nil {
+		return errors.New("invalid semester offering ID")
+	}
+
+	switch group.Kind {
+	case GroupSection:
+		if group.ParentID != nil {
+			return errors.New("a section cannot have a parent")
+		}
+	case GroupBatch:
+		if group.ParentID == nil {
+			return errors.New("a batch needs the section it belongs to as parent")
+		}
+		parent, err := s.groupRepo.GetByID(*group.ParentID)
+		if err != nil || parent.SemesterOfferingID != group.SemesterOfferingID {
+			return errors.New("invalid parent section")
+		}
+		if parent.Kind != GroupSection {
+			return errors.New("batches can only belong to a section")
+		}
+	default:
+		return fmt.Errorf("invalid kind %q, expected SECTION or BATCH", group.Kind)
+	}
+
+	if err := s.checkNameFree(group); err != nil {
+		return err
+	}
+	return s.groupRepo.Create(group)
+}
+
+func (s *studentGroupService) GetGroupByID(id uint) (*models.StudentGroup, error) {
+	if id == 0 {
+		return nil, errors.New("invalid group ID")
+	}
+	return s.groupRepo.GetByID(id)
+}
+
+func (s *studentGroupService) GetGroupsBySemesterOffering(semesterOfferingID uint) ([]models.StudentGroup, error) {
+	if semesterOfferingID == 0 {
+		return nil, errors.New("invalid semester offering ID")
+	}
+	return s.groupRepo.GetBySemesterOffering(semesterOfferingID)
+}
+
+// UpdateGroup renames a group or changes its strength; its kind and place in
+// the hierarchy are fixed
+func (s *studentGroupService) UpdateGroup(group *models.StudentGroup) error {
+	if group.ID == 0 {
+		return errors.New("group ID is required for update")
+	}
+	existing, err := s.groupRepo.GetByID(group.ID)
+	if err != nil || existing.SemesterOfferingID != group.SemesterOfferingID {
+		return errors.New("group not found")
+	}
+
+	group.Name = strings.TrimSpace(group.Name)
+	if group.Name == "" {
+		return errors.New("group name is required")
+	}
+	if group.Strength < 0 {
+		return errors.New("strength cannot be negative")
+	}
+	group.Kind = existing.Kind
+	group.ParentID = existing.ParentID
+
+	if err := s.checkNameFree(group); err != nil {
+		return err
+	}
+	return s.groupRepo.Update(group)
+}
+
+// DeleteGroup deletes a group no batch belongs to and no course offering is
+// taught to
+func (s *studentGroupService) DeleteGroup(id uint) error {
+	if id == 0 {
+		return errors.New("invalid group ID")
+	}
+	if count, err := s.groupRepo.CountBatches(id); err != nil || count > 0 {
+		return errors.New("delete the section's batches first")
+	}
+	if count, err := s.groupRepo.CountCourseOfferings(id); err != nil || count > 0 {
+		return errors.New("group is targeted by course offerings")
+	}
+	return s.groupRepo.Delete(id)
+}
+
+// checkNameFree rejects a name already used by another group with the same parent
+func (s *studentGroupService) checkNameFree(group *models.StudentGroup) error {
+	sections, err := s.groupRepo.GetBySemesterOffering(group.SemesterOfferingID)
+	if err != nil {
+		return fmt.Errorf("failed to get groups: %w", err)
+	}
+	siblings := sections
+	if group.ParentID != nil {
+		siblings = nil
+		for _, section := range sections {
+			if section.ID == *group.ParentID {
+				siblings = section.Batches
+			}
+		}
+	}
+	for _, sibling := range siblings {
+		if sibling.ID != group.ID && strings.EqualFold(sibling.Name, group.Name) {
+			return fmt.Errorf("group %s already exists", group.Name)
+		}
+	}
+	return nil
+}
+
+// groupKey identifies a group of students the generator schedules: a whole
+// semester offering (studentGroupID 0) or one of its sections or batches
+type groupKey struct {
+	semesterOfferingID uint
+	studentGroupID     uint
+}
+
+// blockGroup is the group a class block is taught to
+func blockGroup(block models.ClassBlock) groupKey {
+	return groupKey{block.SemesterOfferingID, block.StudentGroupID}
+}
+
+// courseGroupIDs lists, by ID, the groups a course offering is taught to
+// separately, or just 0 (the whole semester offering) when it targets none
+func courseGroupIDs(offering models.CourseOffering) []uint {
+	if len(offering.StudentGroups) == 0 {
+		return []uint{0}
+	}
+	ids := make([]uint, 0, len(offering.StudentGroups))
+	for _, group := range offering.StudentGroups {
+		ids = append(ids, group.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// groupTree is the section and batch hierarchy of the offerings being
+// generated. Students in a group also belong to its parent groups, so two
+// groups clash when one contains the other; siblings never do.
+type groupTree struct {
+	parent   map[groupKey]groupKey
+	children map[groupKey][]groupKey
+	shared   map[groupKey][]groupKey // related, worked out once per group
+	leafSet  map[groupKey][]groupKey // leaves, worked out once per group
+}
+
+func newGroupTree(offerings []models.SemesterOffering) *groupTree {
+	tree := &groupTree{
+		parent:   make(map[groupKey]groupKey),
+		children: make(map[groupKey][]groupKey),
+		shared:   make(map[groupKey][]groupKey),
+		leafSet:  make(map[groupKey][]groupKey),
+	}
+	for _, offering := range offerings {
+		root := groupKey{offering.ID, 0}
+		groups := append([]models.StudentGroup(nil), offering.StudentGroups...)
+		sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+		for _, group := range groups {
+			key := groupKey{offering.ID, group.ID}
+			parent := root
+			if group.ParentID != nil {
+				parent = groupKey{offering.ID, *group.ParentID}
+			}
+			tree.parent[key] = parent
+			tree.children[parent] = append(tree.children[parent], key)
+		}
+	}
+	for _, offering := range offerings {
+		for _, key := range tree.units(offering.ID) {
+			tree.shared[key] = tree.related(key)
+			tree.leafSet[key] = tree.leaves(key)
+		}
+	}
+	return tree
+}
+
+// units lists the whole offering and every group in it, parents first
+func (t *groupTree) units(semesterOfferingID uint) []groupKey {
+	units := []groupKey{{semesterOfferingID, 0}}
+	for i := 0; i < len(units); i++ {
+		units = append(units, t.children[units[i]]...)
+	}
+	return units
+}
+
+// ancestors lists the groups containing the group, nearest first
+func (t *groupTree) ancestors(key groupKey) []groupKey {
+	var result []groupKey
+	for key.studentGroupID != 0 {
+		parent, exists := t.parent[key]
+		if !exists {
+			parent = groupKey{key.semesterOfferingID, 0}
+		}
+		result = append(result, parent)
+		key = parent
+	}
+	return result
+}
+
+// related lists the group itself and every group sharing students with it:
+// those containing it and those within it
+func (t *groupTree) related(key groupKey) []groupKey {
+	if shared, exists := t.shared[key]; exists {
+		return shared
+	}
+	result := append([]groupKey{key}, t.ancestors(key)...)
+	within := []groupKey{key}
+	for i := 0; i < len(within); i++ {
+		for _, child := range t.children[within[i]] {
+			within = append(within, child)
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+// leaves lists the groups within the group that have no groups within them,
+// or the group itself when it has none: the students' actual days
+func (t *groupTree) leaves(key groupKey) []groupKey {
+	if leaves, exists := t.leafSet[key]; exists {
+		return leaves
+	}
+	var result []groupKey
+	pending := []groupKey{key}
+	for len(pending) > 0 {
+		next := pending[0]
+		pending = pending[1:]
+		if len(t.children[next]) == 0 {
+			result = append(result, next)
+		}
+		pending = append(pending, t.children[next]...)
+	}
+	return result
+}
@@ -91,6 +91,12 @@ func (s *subjectService) CreateSubject(subject *models.Subject) error {
 		}
 	}
 	
+	features, err := NormalizeFeatures(subject.RequiredFeatures)
+	if err != nil {
+		return err
+	}
+	subject.RequiredFeatures = features
+
 	return s.subjectRepo.Create(subject)
 }
 
@@ -138,6 +144,15 @@ func (s *subjectService) UpdateSubject(subject *models.Subject) error {
 		return errors.New("class load per week must be positive")
 	}
 	
+	// Empty features were not given and are left as stored
+	if subject.RequiredFeatures != "" {
+		features, err := NormalizeFeatures(subject.RequiredFeatures)
+		if err != nil {
+			return err
+		}
+		subject.RequiredFeatures = features
+	}
+
 	return s.subjectRepo.Update(subject)
 }
 
@@ -176,6 +191,12 @@ func (s *subjectTypeService) CreateSubjectType(subjectType *models.SubjectType)
 		return errors.New("subject type name is required")
 	}
 	
+	features, err := NormalizeFeatures(subjectType.RequiredFeatures)
+	if err != nil {
+		return err
+	}
+	subjectType.RequiredFeatures = features
+
 	return s.subjectTypeRepo.Create(subjectType)
 }
 
@@ -199,6 +220,15 @@ func (s *subjectTypeService) UpdateSubjectType(subjectType *models.SubjectType)
 		return errors.New("subject type name is required")
 	}
 	
+	// Empty features were not given and are left as stored
+	if subjectType.RequiredFeatures != "" {
+		features, err := NormalizeFeatures(subjectType.RequiredFeatures)
+		if err != nil {
+			return err
+		}
+		subjectType.RequiredFeatures = features
+	}
+
 	return s.subjectTypeRepo.Update(subjectType)
 }
 
@@ -2,9 +2,11 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"icrogen/internal/models"
 	"icrogen/internal/repository"
 	"regexp"
+	"strings"
 )
 
 // TeacherService interface for teacher business logic
@@ -56,6 +58,7 @@ func (s *teacherService) CreateTeacher(teacher *models.Teacher) error {
 	if teacher.Initials != nil && *teacher.Initials == "" {
 		teacher.Initials = nil
 	}
+	teacher.Designation = strings.TrimSpace(teacher.Designation)
 	
 	// Validate that department exists
 	_, err := s.departmentRepo.GetByID(teacher.DepartmentID)
@@ -114,6 +117,7 @@ func (s *teacherService) UpdateTeacher(teacher *models.Teacher) error {
 	if teacher.Initials != nil && *teacher.Initials == "" {
 		teacher.Initials = nil
 	}
+	teacher.Designation = strings.TrimSpace(teacher.Designation)
 	
 	return s.teacherRepo.Update(teacher)
 }
@@ -133,11 +137,11 @@ func (s *teacherService) CheckTeacherAvailability(teacherID uint, sessionID uint
 	if teacherID == 0 || sessionID == 0 {
 		return false, errors.New("invalid teacher ID or session ID")
 	}
-	if dayOfWeek < 1 || dayOfWeek > 5 {
-		return false, errors.New("invalid day of week (1-5)")
+	if dayOfWeek < 1 || dayOfWeek > 7 {
+		return false, errors.New("invalid day of week (1-7)")
 	}
-	if slotNumber < 1 || slotNumber > 8 {
-		return false, errors.New("invalid slot number (1-8)")
+	if slotNumber < 1 || slotNumber > maxGridSlots {
+		return false, fmt.Errorf("invalid slot number (1-%d)", maxGridSlots)
 	}
 	
 	return s.teacherRepo.CheckAvailability(teacherID, sessionID, dayOfWeek, slotNumber)
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"icrogen/internal/repository"
+)
+
+// Kinds of teacher availability rows
+const (
+	AvailabilityUnavailable = "UNAVAILABLE"
+	AvailabilityPreferred   = "PREFERRED"
+	AvailabilityAvailable   = "AVAILABLE" // Only meaningful for a session, to lift weekly rows
+)
+
+// TeacherAvailabilityService interface for teacher availability business logic
+type TeacherAvailabilityService interface {
+	CreateAvailability(availability *models.TeacherAvailability) error
+	GetAvailabilityByID(id uint) (*models.TeacherAvailability, error)
+	GetAvailabilityByTeacherID(teacherID uint) ([]models.TeacherAvailability, error)
+	UpdateAvailability(availability *models.TeacherAvailability) error
+	DeleteAvailability(id uint) error
+}
+
+type teacherAvailabilityService struct {
+	availabilityRepo repository.TeacherAvailabilityRepository
+	teacherRepo      repository.TeacherRepository
+	sessionRepo      repository.SessionRepository
+}
+
+// NewTeacherAvailabilityService creates a new teacher availability service
+func NewTeacherAvailabilityService(
+	availabilityRepo repository.TeacherAvailabilityRepository,
+	teacherRepo repository.TeacherRepository,
+	sessionRepo repository.SessionRepository,
+) TeacherAvailabilityService {
+	return &teacherAvailabilityService{
+		availabilityRepo: availabilityRepo,
+		teacherRepo:      teacherRepo,
+		sessionRepo:      sessionRepo,
+	}
+}
+
+func (s *teacherAvailabilityService) CreateAvailability(availability *models.TeacherAvailability) error {
+	if err := s.validateAvailability(availability); err != nil {
+		return err
+	}
+	return s.availabilityRepo.Create(availability)
+}
+
+func (s *teacherAvailabilityService) GetAvailabilityByID(id uint) (*models.TeacherAvailability, error) {
+	if id == 0 {
+		return nil, errors.New("invalid availability ID")
+	}
+	return s.availabilityRepo.GetByID(id)
+}
+
+func (s *teacherAvailabilityService) GetAvailabilityByTeacherID(teacherID uint) ([]models.TeacherAvailability, error) {
+	if teacherID == 0 {
+		return nil, errors.New("invalid teacher ID")
+	}
+	return s.availabilityRepo.GetByTeacherID(teacherID)
+}
+
+func (s *teacherAvailabilityService) UpdateAvailability(availability *models.TeacherAvailability) error {
+	if availability.ID == 0 {
+		return errors.New("availability ID is required for update")
+	}
+	existing, err := s.availabilityRepo.GetByID(availability.ID)
+	if err != nil || existing.TeacherID != availability.TeacherID {
+		return errors.New("availability not found")
+	}
+	if err := s.validateAvailability(availability); err != nil {
+		return err
+	}
+	return s.availabilityRepo.Update(availability)
+}
+
+func (s *teacherAvailabilityService) DeleteAvailability(id uint) error {
+	if id == 0 {
+		return errors.New("invalid availability ID")
+	}
+	return s.availabilityRepo.Delete(id)
+}
+
+func (s *teacherAvailabilityService) validateAvailability(availability *models.TeacherAvailability) error {
+	if _, err := s.teacherRepo.GetByID(availability.TeacherID); err != nil {
+		return errors.New("invalid teacher ID")
+	}
+	if availability.SessionID != nil {
+		if _, err := s.sessionRepo.GetByID(*availability.SessionID); err != nil {
+			return errors.New("invalid session ID")
+		}
+	}
+
+	switch availability.Kind {
+	case AvailabilityUnavailable, AvailabilityPreferred:
+	case AvailabilityAvailable:
+		if availability.SessionID == nil {
+			return errors.New("AVAILABLE only overrides weekly rows, so it needs a session ID")
+		}
+	default:
+		return fmt.Errorf("invalid kind %q, expected UNAVAILABLE, PREFERRED or AVAILABLE", availability.Kind)
+	}
+
+	if availability.DayOfWeek < 1 || availability.DayOfWeek > 7 {
+		return errors.New("invalid day of week (1-7)")
+	}
+	if availability.SlotStart < 1 || availability.SlotEnd > maxGridSlots {
+		return fmt.Errorf("invalid slot range (1-%d)", maxGridSlots)
+	}
+	if availability.SlotEnd < availability.SlotStart {
+		return errors.New("slot end cannot be before slot start")
+	}
+
+	return nil
+}
+
+// teacherAvailability is the resolved availability of every teacher for one
+// session, as occupancy bits the generator can test placements against
+type teacherAvailability struct {
+	unavailable map[uint]*slotBitset
+	preferred   map[uint]*slotBitset
+}
+
+// newTeacherAvailability resolves the weekly rows and a session's overrides.
+// Rows must come weekly first, as GetForSession returns them.
+func newTeacherAvailability(rows []models.TeacherAvailability) *teacherAvailability {
+	availability := &teacherAvailability{
+		unavailable: make(map[uint]*slotBitset),
+		preferred:   make(map[uint]*slotBitset),
+	}
+
+	for _, row := range rows {
+		if row.DayOfWeek <= 0 || row.DayOfWeek >= maxGridDays || row.SlotStart < 1 || row.SlotEnd > maxGridSlots || row.SlotEnd < row.SlotStart {
+			continue
+		}
+		length := row.SlotEnd - row.SlotStart + 1
+
+		// A session row replaces whatever the weekly rows said about its slots
+		if row.SessionID != nil {
+			bitsetFor(availability.unavailable, row.TeacherID).clear(row.DayOfWeek, row.SlotStart, length)
+			bitsetFor(availability.preferred, row.TeacherID).clear(row.DayOfWeek, row.SlotStart, length)
+		}
+
+		switch row.Kind {
+		case AvailabilityUnavailable:
+			bitsetFor(availability.unavailable, row.TeacherID).set(row.DayOfWeek, row.SlotStart, length)
+		case AvailabilityPreferred:
+			bitsetFor(availability.preferred, row.TeacherID).set(row.DayOfWeek, row.SlotStart, length)
+		}
+	}
+
+	return availability
+}
+
+// blocks reports whether the teacher is unavailable anywhere in the span
+func (a *teacherAvailability) blocks(teacherID uint, day int, startSlot int, length int) bool {
+	return a != nil && a.unavailable[teacherID].overlaps(day, startSlot, length)
+}
+
+// outsidePreferred counts the slots of the span outside a teacher's preferred
+// slots, or 0 for a teacher without preferences
+func (a *teacherAvailability) outsidePreferred(teacherID uint, day int, startSlot int, length int) int {
+	if a == nil {
+		return 0
+	}
+	bits := a.preferred[teacherID]
+	if bits == nil || *bits == (slotBitset{}) {
+		return 0
+	}
+	outside := 0
+	for i := 0; i < length; i++ {
+		if !bits.overlaps(day, startSlot+i, 1) {
+			outside++
+		}
+	}
+	return outside
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"icrogen/internal/repository"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// Workload limits a teacher's load can exceed
+const (
+	LimitPerDay      = "MAX_PER_DAY"
+	LimitPerWeek     = "MAX_PER_WEEK"
+	LimitConsecutive = "MAX_CONSECUTIVE"
+	LimitFreeDays    = "MIN_FREE_DAYS"
+)
+
+// TeacherWorkloadService interface for teacher workload limit business logic
+type TeacherWorkloadService interface {
+	CreateLimit(limit *models.TeacherWorkloadLimit) error
+	GetLimitByID(id uint) (*models.TeacherWorkloadLimit, error)
+	GetAllLimits() ([]models.TeacherWorkloadLimit, error)
+	UpdateLimit(limit *models.TeacherWorkloadLimit) error
+	DeleteLimit(id uint) error
+}
+
+type teacherWorkloadService struct {
+	workloadRepo repository.TeacherWorkloadRepository
+	teacherRepo  repository.TeacherRepository
+}
+
+// NewTeacherWorkloadService creates a new teacher workload service
+func NewTeacherWorkloadService(
+	workloadRepo repository.TeacherWorkloadRepository,
+	teacherRepo repository.TeacherRepository,
+) TeacherWorkloadService {
+	return &teacherWorkloadService{
+		workloadRepo: workloadRepo,
+		teacherRepo:  teacherRepo,
+	}
+}
+
+func (s *teacherWorkloadService) CreateLimit(limit *models.TeacherWorkloadLimit) error {
+	if err := s.validateLimit(limit); err != nil {
+		return err
+	}
+	return s.workloadRepo.Create(limit)
+}
+
+func (s *teacherWorkloadService) GetLimitByID(id uint) (*models.TeacherWorkloadLimit, error) {
+	if id == 0 {
+		return nil, errors.New("invalid workload limit ID")
+	}
+	return s.workloadRepo.GetByID(id)
+}
+
+func (s *teacherWorkloadService) GetAllLimits() ([]models.TeacherWorkloadLimit, error) {
+	return s.workloadRepo.GetAll()
+}
+
+func (s *teacherWorkloadService) UpdateLimit(limit *models.TeacherWorkloadLimit) error {
+	if limit.ID == 0 {
+		return errors.New("workload limit ID is required for update")
+	}
+	if _, err := s.workloadRepo.GetByID(limit.ID); err != nil {
+		return errors.New("workload limit not found")
+	}
+	if err := s.validateLimit(limit); err != nil {
+		return err
+	}
+	return s.workloadRepo.Update(limit)
+}
+
+func (s *teacherWorkloadService) DeleteLimit(id uint) error {
+	if id == 0 {
+		return errors.New("invalid workload limit ID")
+	}
+	return s.workloadRepo.Delete(id)
+}
+
+// validateLimit checks that the row is for either one teacher or one
+// designation, and that no other row already is
+func (s *teacherWorkloadService) validateLimit(limit *models.TeacherWorkloadLimit) error {
+	limit.Designation = strings.TrimSpace(limit.Designation)
+	if (limit.TeacherID == nil) == (limit.Designation == "") {
+		return errors.New("set either a teacher ID or a designation")
+	}
+	if limit.TeacherID != nil {
+		if _, err := s.teacherRepo.GetByID(*limit.TeacherID); err != nil {
+			return errors.New("invalid teacher ID")
+		}
+	}
+
+	if limit.MaxPerDay < 0 || limit.MaxPerWeek < 0 || limit.MaxConsecutive < 0 || limit.MinFreeDays < 0 {
+		return errors.New("limits cannot be negative")
+	}
+	if limit.MinFreeDays >= maxGridDays-1 {
+		return fmt.Errorf("at most %d free days can be required", maxGridDays-2)
+	}
+	if limit.MaxPerDay > 0 && limit.MaxConsecutive > limit.MaxPerDay {
+		return errors.New("max consecutive cannot exceed max per day")
+	}
+
+	existing, err := s.workloadRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to get workload limits: %w", err)
+	}
+	for _, other := range existing {
+		if other.ID == limit.ID {
+			continue
+		}
+		if limit.TeacherID != nil && other.TeacherID != nil && *other.TeacherID == *limit.TeacherID {
+			return fmt.Errorf("teacher %d already has workload limits", *limit.TeacherID)
+		}
+		if limit.Designation != "" && strings.EqualFold(other.Designation, limit.Designation) {
+			return fmt.Errorf("designation %s already has workload limits", limit.Designation)
+		}
+	}
+
+	return nil
+}
+
+// WorkloadLimits are the caps that apply to one teacher; 0 means no cap
+type WorkloadLimits struct {
+	MaxPerDay      int `json:"max_per_day,omitempty"`
+	MaxPerWeek     int `json:"max_per_week,omitempty"`
+	MaxConsecutive int `json:"max_consecutive,omitempty"`
+	MinFreeDays    int `json:"min_free_days,omitempty"`
+}
+
+// TeacherLoad is what a teacher teaches in the session, counting committed
+// runs and this run's placements, against their workload limits
+type TeacherLoad struct {
+	TeacherID      uint            `json:"teacher_id"`
+	TeacherName    string          `json:"teacher_name"`
+	PeriodsByDay   map[int]int     `json:"periods_by_day"` // Keyed by day of week, teaching days only
+	PeriodsPerWeek int             `json:"periods_per_week"`
+	MaxConsecutive int             `json:"max_consecutive"` // Longest run of back-to-back periods
+	FreeDays       int             `json:"free_days"`       // Days of the grid without a class
+	Limits         *WorkloadLimits `json:"limits,omitempty"`
+	Exceeded       []string        `json:"exceeded,omitempty"` // Limits the load breaks, e.g. committed before they were set
+}
+
+// teacherWorkload is the limits that apply to every teacher for one
+// generation, a teacher's own row taking precedence over their designation's
+type teacherWorkload struct {
+	limits map[uint]WorkloadLimits
+	names  map[uint]string
+}
+
+func newTeacherWorkload(teachers []models.Teacher, rows []models.TeacherWorkloadLimit) *teacherWorkload {
+	workload := &teacherWorkload{
+		limits: make(map[uint]WorkloadLimits),
+		names:  make(map[uint]string),
+	}
+
+	toLimits := func(row models.TeacherWorkloadLimit) WorkloadLimits {
+		return WorkloadLimits{
+			MaxPerDay:      row.MaxPerDay,
+			MaxPerWeek:     row.MaxPerWeek,
+			MaxConsecutive: row.MaxConsecutive,
+			MinFreeDays:    row.MinFreeDays,
+		}
+	}
+	byDesignation := make(map[string]WorkloadLimits)
+	own := make(map[uint]WorkloadLimits)
+	for _, row := range rows {
+		if row.TeacherID != nil {
+			own[*row.TeacherID] = toLimits(row)
+		} else {
+			byDesignation[strings.ToLower(row.Designation)] = toLimits(row)
+		}
+	}
+
+	for _, teacher := range teachers {
+		workload.names[teacher.ID] = teacher.Name
+		if limits, exists := own[teacher.ID]; exists {
+			workload.limits[teacher.ID] = limits
+		} else if limits, exists := byDesignation[strings.ToLower(teacher.Designation)]; exists && teacher.Designation != "" {
+			workload.limits[teacher.ID] = limits
+		}
+	}
+
+	return workload
+}
+
+// allows reports whether the teacher can take on length more periods from
+// startSlot without breaking a limit, given what they already teach. Only
+// what the new periods change is checked, so a load already over a limit on
+// another day does not block this one.
+func (w *teacherWorkload) allows(teacherID uint, busy *slotBitset, grid *timeGrid, day int, startSlot int, length int) bool {
+	if w == nil {
+		return true
+	}
+	limits, exists := w.limits[teacherID]
+	if !exists {
+		return true
+	}
+
+	var before slotBitset
+	if busy != nil {
+		before = *busy
+	}
+	after := before
+	after.set(day, startSlot, length)
+
+	if limits.MaxPerDay > 0 && bits.OnesCount64(after[day]) > limits.MaxPerDay {
+		return false
+	}
+	if limits.MaxPerWeek > 0 && weeklyPeriods(&after) > limits.MaxPerWeek {
+		return false
+	}
+	if limits.MaxConsecutive > 0 && longestRun(grid, day, after[day]) > limits.MaxConsecutive {
+		return false
+	}
+	if limits.MinFreeDays > 0 && before[day] == 0 && freeDays(grid, &after) < limits.MinFreeDays {
+		return false
+	}
+	return true
+}
+
+// load reports the teacher's load and the limits it breaks. Runs and free days
+// are counted on the given grid.
+func (w *teacherWorkload) load(teacherID uint, busy *slotBitset, grid *timeGrid) TeacherLoad {
+	var week slotBitset
+	if busy != nil {
+		week = *busy
+	}
+	load := TeacherLoad{
+		TeacherID:      teacherID,
+		PeriodsByDay:   make(map[int]int),
+		PeriodsPerWeek: weeklyPeriods(&week),
+		FreeDays:       freeDays(grid, &week),
+	}
+	for day := 1; day < maxGridDays; day++ {
+		if week[day] == 0 {
+			continue
+		}
+		load.PeriodsByDay[day] = bits.OnesCount64(week[day])
+		if run := longestRun(grid, day, week[day]); run > load.MaxConsecutive {
+			load.MaxConsecutive = run
+		}
+	}
+	if w == nil {
+		return load
+	}
+
+	load.TeacherName = w.names[teacherID]
+	limits, exists := w.limits[teacherID]
+	if !exists {
+		return load
+	}
+	load.Limits = &limits
+	for _, periods := range load.PeriodsByDay {
+		if limits.MaxPerDay > 0 && periods > limits.MaxPerDay {
+			load.Exceeded = append(load.Exceeded, LimitPerDay)
+			break
+		}
+	}
+	if limits.MaxPerWeek > 0 && load.PeriodsPerWeek > limits.MaxPerWeek {
+		load.Exceeded = append(load.Exceeded, LimitPerWeek)
+	}
+	if limits.MaxConsecutive > 0 && load.MaxConsecutive > limits.MaxConsecutive {
+		load.Exceeded = append(load.Exceeded, LimitConsecutive)
+	}
+	if load.FreeDays < limits.MinFreeDays {
+		load.Exceeded = append(load.Exceeded, LimitFreeDays)
+	}
+	return load
+}
+
+// weeklyPeriods counts the periods taught over the week
+func weeklyPeriods(week *slotBitset) int {
+	total := 0
+	for day := 1; day < maxGridDays; day++ {
+		total += bits.OnesCount64(week[day])
+	}
+	return total
+}
+
+// longestRun is the most periods taught back to back on a day; a break ends
+// a run
+func longestRun(grid *timeGrid, day int, dayBits uint64) int {
+	longest, run := 0, 0
+	for _, slot := range grid.slots(day) {
+		if dayBits&spanMask(slot, 1) != 0 {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+		if grid.endsSession(day, slot) {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// freeDays counts the grid's days without a class
+func freeDays(grid *timeGrid, week *slotBitset) int {
+	free := 0
+	for _, day := range grid.days {
+		if week[day] == 0 {
+			free++
+		}
+	}
+	return free
+}
+
+// teacherLoads lists the load of every teacher placed in the given semester
+// offerings, by teacher ID. A teacher's runs and free days are counted on the
+// grid of the first offering (by ID) they teach.
+func (s *routineGenerationService) teacherLoads(state *generationState, semesterOfferingIDs []uint) []TeacherLoad {
+	included := make(map[uint]bool)
+	for _, id := range semesterOfferingIDs {
+		included[id] = true
+	}
+
+	gridOf := make(map[uint]uint)
+	for _, p := range s.collectPlacements(state) {
+		for _, part := range blockParts(p.block) {
+			if !included[part.SemesterOfferingID] {
+				continue
+			}
+			if current, seen := gridOf[part.TeacherID]; !seen || part.SemesterOfferingID < current {
+				gridOf[part.TeacherID] = part.SemesterOfferingID
+			}
+		}
+	}
+
+	loads := make([]TeacherLoad, 0, len(gridOf))
+	for teacherID, semesterOfferingID := range gridOf {
+		loads = append(loads, state.workload.load(teacherID, state.index.teachers[teacherID], state.grids[semesterOfferingID]))
+	}
+	sort.Slice(loads, func(i, j int) bool { return loads[i].TeacherID < loads[j].TeacherID })
+	return loads
+}
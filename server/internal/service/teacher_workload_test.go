@@ -0,0 +1,156 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"icrogen/internal/models"
+)
+
+// busyWeek marks spans of slots as taught, each given as day, start and length
+func busyWeek(spans ...[3]int) *slotBitset {
+	var week slotBitset
+	for _, span := range spans {
+		week.set(span[0], span[1], span[2])
+	}
+	return &week
+}
+
+func TestNewTeacherWorkload(t *testing.T) {
+	own := uint(1)
+	teachers := []models.Teacher{
+		{ID: 1, Name: "A", Designation: "Professor"},
+		{ID: 2, Name: "B", Designation: "professor"},
+		{ID: 3, Name: "C", Designation: "Lecturer"},
+		{ID: 4, Name: "D"},
+	}
+	rows := []models.TeacherWorkloadLimit{
+		{Designation: "PROFESSOR", MaxPerDay: 4},
+		{TeacherID: &own, MaxPerWeek: 12},
+	}
+	workload := newTeacherWorkload(teachers, rows)
+
+	tests := []struct {
+		teacherID uint
+		want      *WorkloadLimits
+	}{
+		{teacherID: 1, want: &WorkloadLimits{MaxPerWeek: 12}},
+		{teacherID: 2, want: &WorkloadLimits{MaxPerDay: 4}},
+		{teacherID: 3},
+		{teacherID: 4},
+	}
+	for _, tt := range tests {
+		limits, exists := workload.limits[tt.teacherID]
+		switch {
+		case tt.want == nil && exists:
+			t.Errorf("teacher %d has limits %+v, want none", tt.teacherID, limits)
+		case tt.want != nil && limits != *tt.want:
+			t.Errorf("teacher %d has limits %+v, want %+v", tt.teacherID, limits, *tt.want)
+		}
+	}
+}
+
+func TestTeacherWorkloadAllows(t *testing.T) {
+	grid := defaultTimeGrid()
+	tests := []struct {
+		name      string
+		limits    WorkloadLimits
+		busy      *slotBitset
+		day       int
+		startSlot int
+		length    int
+		want      bool
+	}{
+		{name: "no limits", busy: busyWeek([3]int{1, 1, 7}), day: 1, startSlot: 1, length: 1, want: true},
+		{name: "within the day", limits: WorkloadLimits{MaxPerDay: 3}, busy: busyWeek([3]int{1, 1, 2}), day: 1, startSlot: 5, length: 1, want: true},
+		{name: "over the day", limits: WorkloadLimits{MaxPerDay: 3}, busy: busyWeek([3]int{1, 1, 2}), day: 1, startSlot: 5, length: 2},
+		{name: "over the week", limits: WorkloadLimits{MaxPerWeek: 4}, busy: busyWeek([3]int{1, 1, 2}, [3]int{2, 1, 2}), day: 3, startSlot: 1, length: 1},
+		{name: "too many in a row", limits: WorkloadLimits{MaxConsecutive: 2}, busy: busyWeek([3]int{1, 1, 2}), day: 1, startSlot: 3, length: 1},
+		{name: "lunch ends the run", limits: WorkloadLimits{MaxConsecutive: 2}, busy: busyWeek([3]int{1, 3, 2}), day: 1, startSlot: 5, length: 2, want: true},
+		{name: "takes a free day", limits: WorkloadLimits{MinFreeDays: 3}, busy: busyWeek([3]int{1, 1, 1}, [3]int{2, 1, 1}), day: 3, startSlot: 1, length: 1},
+		{name: "teaching day already taken", limits: WorkloadLimits{MinFreeDays: 3}, busy: busyWeek([3]int{1, 1, 1}, [3]int{2, 1, 1}), day: 2, startSlot: 5, length: 1, want: true},
+		{name: "nothing taught yet", limits: WorkloadLimits{MaxPerDay: 2}, day: 1, startSlot: 1, length: 2, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workload := &teacherWorkload{limits: map[uint]WorkloadLimits{}}
+			if tt.limits != (WorkloadLimits{}) {
+				workload.limits[1] = tt.limits
+			}
+			if got := workload.allows(1, tt.busy, grid, tt.day, tt.startSlot, tt.length); got != tt.want {
+				t.Errorf("allows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var none *teacherWorkload
+	if !none.allows(1, busyWeek([3]int{1, 1, 7}), grid, 1, 1, 1) {
+		t.Errorf("a generation without workload limits refused a placement")
+	}
+}
+
+func TestTeacherWorkloadLoad(t *testing.T) {
+	grid := defaultTimeGrid()
+	busy := busyWeek([3]int{1, 1, 4}, [3]int{2, 1, 1})
+
+	tests := []struct {
+		name         string
+		limits       *WorkloadLimits
+		wantExceeded []string
+	}{
+		{name: "no limits"},
+		{name: "within every limit", limits: &WorkloadLimits{MaxPerDay: 4, MaxPerWeek: 5, MaxConsecutive: 4, MinFreeDays: 3}},
+		{
+			name:         "over every limit",
+			limits:       &WorkloadLimits{MaxPerDay: 3, MaxPerWeek: 4, MaxConsecutive: 3, MinFreeDays: 4},
+			wantExceeded: []string{LimitPerDay, LimitPerWeek, LimitConsecutive, LimitFreeDays},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workload := &teacherWorkload{limits: map[uint]WorkloadLimits{}, names: map[uint]string{1: "A"}}
+			if tt.limits != nil {
+				workload.limits[1] = *tt.limits
+			}
+			load := workload.load(1, busy, grid)
+			if load.TeacherName != "A" || load.PeriodsPerWeek != 5 || load.MaxConsecutive != 4 || load.FreeDays != 3 {
+				t.Errorf("load = %+v, want A teaching 5 periods, 4 in a row, with 3 free days", load)
+			}
+			if !reflect.DeepEqual(load.PeriodsByDay, map[int]int{1: 4, 2: 1}) {
+				t.Errorf("periods by day = %v, want map[1:4 2:1]", load.PeriodsByDay)
+			}
+			if !reflect.DeepEqual(load.Exceeded, tt.wantExceeded) {
+				t.Errorf("exceeded = %v, want %v", load.Exceeded, tt.wantExceeded)
+			}
+		})
+	}
+}
+
+func TestValidateWorkloadLimitRejects(t *testing.T) {
+	service := &teacherWorkloadService{
+		workloadRepo: &fakeWorkloadRepo{limits: []models.TeacherWorkloadLimit{{ID: 1, Designation: "Professor", MaxPerDay: 4}}},
+		teacherRepo:  &fakeTeacherRepo{},
+	}
+	tests := []struct {
+		name    string
+		limit   models.TeacherWorkloadLimit
+		wantErr bool
+	}{
+		{name: "designation", limit: models.TeacherWorkloadLimit{Designation: " Lecturer ", MaxPerDay: 4, MaxConsecutive: 3}},
+		{name: "same row updated", limit: models.TeacherWorkloadLimit{ID: 1, Designation: "Professor", MaxPerDay: 5}},
+		{name: "no scope", limit: models.TeacherWorkloadLimit{MaxPerDay: 4}, wantErr: true},
+		{name: "negative limit", limit: models.TeacherWorkloadLimit{Designation: "Lecturer", MaxPerWeek: -1}, wantErr: true},
+		{name: "no teaching day left", limit: models.TeacherWorkloadLimit{Designation: "Lecturer", MinFreeDays: 7}, wantErr: true},
+		{name: "longer run than a day", limit: models.TeacherWorkloadLimit{Designation: "Lecturer", MaxPerDay: 2, MaxConsecutive: 3}, wantErr: true},
+		{name: "designation taken", limit: models.TeacherWorkloadLimit{Designation: "professor", MaxPerDay: 3}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := tt.limit
+			err := service.validateLimit(&limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateLimit error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"icrogen/internal/models"
+	"icrogen/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// TimeGridService interface for time grid business logic
+type TimeGridService interface {
+	CreateTimeGrid(grid *models.TimeGrid) error
+	GetTimeGridByID(id uint) (*models.TimeGrid, error)
+	GetAllTimeGrids() ([]models.TimeGrid, error)
+	UpdateTimeGrid(grid *models.TimeGrid) error
+	DeleteTimeGrid(id uint) error
+}
+
+type timeGridService struct {
+	timeGridRepo  repository.TimeGridRepository
+	programmeRepo repository.ProgrammeRepository
+}
+
+// NewTimeGridService creates a new time grid service
+func NewTimeGridService(timeGridRepo repository.TimeGridRepository, programmeRepo repository.ProgrammeRepository) TimeGridService {
+	return &timeGridService{
+		timeGridRepo:  timeGridRepo,
+		programmeRepo: programmeRepo,
+	}
+}
+
+func (s *timeGridService) CreateTimeGrid(grid *models.TimeGrid) error {
+	if err := s.validateTimeGrid(grid); err != nil {
+		return err
+	}
+	return s.timeGridRepo.Create(grid)
+}
+
+func (s *timeGridService) GetTimeGridByID(id uint) (*models.TimeGrid, error) {
+	if id == 0 {
+		return nil, errors.New("invalid time grid ID")
+	}
+	return s.timeGridRepo.GetByID(id)
+}
+
+func (s *timeGridService) GetAllTimeGrids() ([]models.TimeGrid, error) {
+	return s.timeGridRepo.GetAll()
+}
+
+func (s *timeGridService) UpdateTimeGrid(grid *models.TimeGrid) error {
+	if grid.ID == 0 {
+		return errors.New("time grid ID is required for update")
+	}
+	if _, err := s.timeGridRepo.GetByID(grid.ID); err != nil {
+		return errors.New("time grid not found")
+	}
+	if err := s.validateTimeGrid(grid); err != nil {
+		return err
+	}
+	return s.timeGridRepo.Update(grid)
+}
+
+func (s *timeGridService) DeleteTimeGrid(id uint) error {
+	if id == 0 {
+		return errors.New("invalid time grid ID")
+	}
+	return s.timeGridRepo.Delete(id)
+}
+
+func (s *timeGridService) validateTimeGrid(grid *models.TimeGrid) error {
+	if grid.Name == "" {
+		return errors.New("time grid name is required")
+	}
+	if err := ValidateTimeSlots(grid.TimeSlots); err != nil {
+		return fmt.Errorf("invalid time slots: %w", err)
+	}
+
+	if grid.ProgrammeID != nil {
+		if _, err := s.programmeRepo.GetByID(*grid.ProgrammeID); err != nil {
+			return errors.New("invalid programme ID")
+		}
+
+		// A programme is scheduled on at most one active grid
+		if grid.IsActive {
+			existing, err := s.timeGridRepo.GetByProgrammeID(*grid.ProgrammeID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if existing != nil && existing.ID != grid.ID {
+				return fmt.Errorf("programme already uses time grid %q", existing.Name)
+			}
+		}
+	}
+
+	return nil
+}
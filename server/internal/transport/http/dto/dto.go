@@ -32,6 +32,7 @@ type CreateTeacherRequest struct {
 	Name         string `json:"name" binding:"required"`
 	Initials     string `json:"initials"`
 	Email        string `json:"email" binding:"required,email"`
+	Designation  string `json:"designation"`
 	DepartmentID uint   `json:"department_id" binding:"required"`
 }
 
@@ -39,6 +40,7 @@ type UpdateTeacherRequest struct {
 	Name         string `json:"name" binding:"required"`
 	Initials     string `json:"initials"`
 	Email        string `json:"email" binding:"required,email"`
+	Designation  string `json:"designation"`
 	DepartmentID uint   `json:"department_id" binding:"required"`
 	IsActive     bool   `json:"is_active"`
 }
@@ -47,12 +49,14 @@ type CreateSubjectTypeRequest struct {
 	Name                        string `json:"name" binding:"required"`
 	IsLab                       bool   `json:"is_lab"`
 	DefaultConsecutivePreferred bool   `json:"default_consecutive_preferred"`
+	RequiredFeatures            map[string]int `json:"required_features"` // Room features needed, e.g. {"pc": 60}
 }
 
 type UpdateSubjectTypeRequest struct {
 	Name                        string `json:"name" binding:"required"`
 	IsLab                       bool   `json:"is_lab"`
 	DefaultConsecutivePreferred bool   `json:"default_consecutive_preferred"`
+	RequiredFeatures            map[string]int `json:"required_features"` // Room features needed, e.g. {"pc": 60}
 }
 
 type CreateSubjectRequest struct {
@@ -63,6 +67,7 @@ type CreateSubjectRequest struct {
 	ProgrammeID      uint   `json:"programme_id" binding:"required"`
 	DepartmentID     uint   `json:"department_id" binding:"required"`
 	SubjectTypeID    uint   `json:"subject_type_id" binding:"required"`
+	RequiredFeatures map[string]int `json:"required_features"` // Overrides the subject type's requirements
 }
 
 type UpdateSubjectRequest struct {
@@ -72,6 +77,7 @@ type UpdateSubjectRequest struct {
 	ClassLoadPerWeek int    `json:"class_load_per_week" binding:"required,min=1"`
 	SubjectTypeID    uint   `json:"subject_type_id"`
 	IsActive         bool   `json:"is_active"`
+	RequiredFeatures map[string]int `json:"required_features"`
 }
 
 type CreateRoomRequest struct {
@@ -80,6 +86,7 @@ type CreateRoomRequest struct {
 	Capacity     int    `json:"capacity" binding:"min=0"`
 	Type         string `json:"type" binding:"required,oneof=THEORY LAB OTHER"`
 	DepartmentID *uint  `json:"department_id"`
+	Features     map[string]int `json:"features"` // Feature quantities, e.g. {"projector": 1, "pc": 60}
 }
 
 type UpdateRoomRequest struct {
@@ -89,6 +96,7 @@ type UpdateRoomRequest struct {
 	Type         string `json:"type" binding:"required,oneof=THEORY LAB OTHER"`
 	DepartmentID *uint  `json:"department_id"`
 	IsActive     *bool  `json:"is_active"`
+	Features     map[string]int `json:"features"`
 }
 
 type CreateSessionRequest struct {
@@ -120,10 +128,36 @@ type CreateCourseOfferingRequest struct {
 	WeeklyRequiredSlots int    `json:"weekly_required_slots" binding:"required,min=1"`
 	RequiredPattern     string `json:"required_pattern"`
 	PreferredRoomID     *uint  `json:"preferred_room_id"`
+	MaxRoomSplit        int    `json:"max_room_split" binding:"omitempty,min=1,max=4"` // Rooms a large group may be split across, default 1
 	TeacherIDs          []uint `json:"teacher_ids"`
+	StudentGroupIDs     []uint `json:"student_group_ids"` // Sections or batches taught separately, default the whole offering
 	Notes               string `json:"notes"`
 }
 
+type StudentGroupRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Kind     string `json:"kind" binding:"omitempty,oneof=SECTION BATCH"` // Ignored on update
+	ParentID *uint  `json:"parent_id"`                                     // The section a batch belongs to; ignored on update
+	Strength int    `json:"strength" binding:"min=0"`
+}
+
+type SetStudentGroupsRequest struct {
+	StudentGroupIDs []uint `json:"student_group_ids"`
+}
+
+type ElectiveGroupRequest struct {
+	SessionID         uint   `json:"session_id"` // Required on create, ignored on update
+	Name              string `json:"name" binding:"required"`
+	Description       string `json:"description"`
+	CourseOfferingIDs []uint `json:"course_offering_ids" binding:"required,min=2"`
+}
+
+type CombinedClassRequest struct {
+	SessionID         uint   `json:"session_id"` // Required on create, ignored on update
+	Name              string `json:"name" binding:"required"`
+	CourseOfferingIDs []uint `json:"course_offering_ids" binding:"required,min=2"`
+}
+
 type AssignTeacherRequest struct {
 	TeacherID uint `json:"teacher_id" binding:"required"`
 	Weight    int  `json:"weight" binding:"min=1"`
@@ -134,8 +168,103 @@ type AssignRoomRequest struct {
 	Priority int  `json:"priority" binding:"min=1"`
 }
 
+type TimeGridSlotRequest struct {
+	DayOfWeek  int    `json:"day_of_week" binding:"required,min=1,max=7"`
+	SlotNumber int    `json:"slot_number" binding:"required,min=1,max=63"`
+	StartTime  string `json:"start_time" binding:"required"` // HH:MM
+	EndTime    string `json:"end_time" binding:"required"`   // HH:MM
+	IsLabStart bool   `json:"is_lab_start"`
+}
+
+type CreateTimeGridRequest struct {
+	Name        string                `json:"name" binding:"required"`
+	Description string                `json:"description"`
+	ProgrammeID *uint                 `json:"programme_id"`
+	Slots       []TimeGridSlotRequest `json:"slots" binding:"required,min=1,dive"`
+}
+
+type UpdateTimeGridRequest struct {
+	Name        string                `json:"name" binding:"required"`
+	Description string                `json:"description"`
+	ProgrammeID *uint                 `json:"programme_id"`
+	IsActive    *bool                 `json:"is_active"`
+	Slots       []TimeGridSlotRequest `json:"slots" binding:"required,min=1,dive"`
+}
+
+type CreateSoftConstraintRequest struct {
+	Type         string         `json:"type" binding:"required"`
+	Weight       int            `json:"weight" binding:"min=0"`
+	Params       map[string]int `json:"params"`
+	ProgrammeID  *uint          `json:"programme_id"`
+	DepartmentID *uint          `json:"department_id"`
+}
+
+type UpdateSoftConstraintRequest struct {
+	Type         string         `json:"type" binding:"required"`
+	Weight       int            `json:"weight" binding:"min=0"`
+	Params       map[string]int `json:"params"`
+	ProgrammeID  *uint          `json:"programme_id"`
+	DepartmentID *uint          `json:"department_id"`
+	IsActive     *bool          `json:"is_active"`
+}
+
+type TeacherAvailabilityRequest struct {
+	SessionID *uint  `json:"session_id"`
+	DayOfWeek int    `json:"day_of_week" binding:"required,min=1,max=7"`
+	SlotStart int    `json:"slot_start" binding:"required,min=1,max=63"`
+	SlotEnd   int    `json:"slot_end" binding:"required,min=1,max=63"`
+	Kind      string `json:"kind" binding:"required,oneof=UNAVAILABLE PREFERRED AVAILABLE"`
+	Reason    string `json:"reason"`
+}
+
+type TeacherWorkloadLimitRequest struct {
+	TeacherID      *uint  `json:"teacher_id"`
+	Designation    string `json:"designation"`
+	MaxPerDay      int    `json:"max_per_day" binding:"min=0"`
+	MaxPerWeek     int    `json:"max_per_week" binding:"min=0"`
+	MaxConsecutive int    `json:"max_consecutive" binding:"min=0"`
+	MinFreeDays    int    `json:"min_free_days" binding:"min=0,max=6"`
+}
+
 type GenerateRoutineRequest struct {
-	SemesterOfferingID uint `json:"semester_offering_id" binding:"required"`
+	SemesterOfferingID uint   `json:"semester_offering_id" binding:"required"`
+	Strategy           string `json:"strategy" binding:"omitempty,oneof=backtracking propagation local-search"`
+	TimeBudgetSeconds  int    `json:"time_budget_seconds" binding:"omitempty,min=1,max=600"`
+	NodeBudget         int    `json:"node_budget" binding:"omitempty,min=1"`
+}
+
+type MoveScheduleBlockRequest struct {
+	DayOfWeek int  `json:"day_of_week" binding:"required,min=1,max=7"`
+	SlotStart int  `json:"slot_start" binding:"required,min=1,max=63"`
+	TeacherID uint `json:"teacher_id"` // Optional, keeps the block's teacher when 0
+	RoomID    uint `json:"room_id"`    // Optional, keeps the block's rooms when 0
+}
+
+type SwapScheduleBlocksRequest struct {
+	OtherBlockID uint `json:"other_block_id" binding:"required"`
+}
+
+type PinScheduleBlockRequest struct {
+	Pinned *bool `json:"pinned" binding:"required"`
+}
+
+type RegenerateRoutineRequest struct {
+	Strategy          string `json:"strategy" binding:"omitempty,oneof=backtracking propagation local-search"`
+	TimeBudgetSeconds int    `json:"time_budget_seconds" binding:"omitempty,min=1,max=600"`
+	NodeBudget        int    `json:"node_budget" binding:"omitempty,min=1"`
+	Repair            bool   `json:"repair"`              // Keep every block except those of the listed course offerings and teachers
+	CourseOfferingIDs []uint `json:"course_offering_ids"` // Repair mode: course offerings to solve again
+	TeacherIDs        []uint `json:"teacher_ids"`         // Repair mode: teachers whose blocks to solve again
+}
+
+type GenerateSessionRoutineRequest struct {
+	SessionID         uint   `json:"session_id" binding:"required"`
+	ProgrammeIDs      []uint `json:"programme_ids"`
+	DepartmentIDs     []uint `json:"department_ids"`
+	SemesterNumbers   []int  `json:"semester_numbers"`
+	Strategy          string `json:"strategy" binding:"omitempty,oneof=backtracking propagation local-search"`
+	TimeBudgetSeconds int    `json:"time_budget_seconds" binding:"omitempty,min=1,max=600"`
+	NodeBudget        int    `json:"node_budget" binding:"omitempty,min=1"`
 }
 
 // Response DTOs
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"icrogen/internal/models"
+	"icrogen/internal/service"
+	"icrogen/internal/transport/http/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CombinedClassHandler struct {
+	combinedClassService service.CombinedClassService
+}
+
+func NewCombinedClassHandler(combinedClassService service.CombinedClassService) *CombinedClassHandler {
+	return &CombinedClassHandler{
+		combinedClassService: combinedClassService,
+	}
+}
+
+func (h *CombinedClassHandler) CreateCombinedClass(c *gin.Context) {
+	var req dto.CombinedClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	class := &models.CombinedClass{
+		SessionID: req.SessionID,
+		Name:      req.Name,
+	}
+
+	if err := h.combinedClassService.CreateCombinedClass(class, req.CourseOfferingIDs); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// Return the class with its course offerings
+	if created, err := h.combinedClassService.GetCombinedClassByID(class.ID); err == nil {
+		class = created
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse{
+		Success: true,
+		Data:    class,
+	})
+}
+
+func (h *CombinedClassHandler) GetAllCombinedClasses(c *gin.Context) {
+	var classes []models.CombinedClass
+	var err error
+
+	if sessionIDStr := c.Query("session_id"); sessionIDStr != "" {
+		sessionID, parseErr := strconv.ParseUint(sessionIDStr, 10, 32)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   "Invalid session ID",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		classes, err = h.combinedClassService.GetCombinedClassesBySession(uint(sessionID))
+	} else {
+		classes, err = h.combinedClassService.GetAllCombinedClasses()
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    classes,
+	})
+}
+
+func (h *CombinedClassHandler) GetCombinedClass(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid combined class ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	class, err := h.combinedClassService.GetCombinedClassByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Success: false,
+			Error:   "Combined class not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    class,
+	})
+}
+
+func (h *CombinedClassHandler) UpdateCombinedClass(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid combined class ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.CombinedClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	class := &models.CombinedClass{
+		ID:   uint(id),
+		Name: req.Name,
+	}
+
+	if err := h.combinedClassService.UpdateCombinedClass(class, req.CourseOfferingIDs); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if updated, err := h.combinedClassService.GetCombinedClassByID(class.ID); err == nil {
+		class = updated
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    class,
+	})
+}
+
+func (h *CombinedClassHandler) DeleteCombinedClass(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid combined class ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.combinedClassService.DeleteCombinedClass(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Message: "Combined class deleted successfully",
+	})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"icrogen/internal/models"
+	"icrogen/internal/service"
+	"icrogen/internal/transport/http/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ElectiveGroupHandler struct {
+	electiveGroupService service.ElectiveGroupService
+}
+
+func NewElectiveGroupHandler(electiveGroupService service.ElectiveGroupService) *ElectiveGroupHandler {
+	return &ElectiveGroupHandler{
+		electiveGroupService: electiveGroupService,
+	}
+}
+
+func (h *ElectiveGroupHandler) CreateElectiveGroup(c *gin.Context) {
+	var req dto.ElectiveGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	group := &models.ElectiveGroup{
+		SessionID:   req.SessionID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := h.electiveGroupService.CreateElectiveGroup(group, req.CourseOfferingIDs); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// Return the group with its course offerings
+	if created, err := h.electiveGroupService.GetElectiveGroupByID(group.ID); err == nil {
+		group = created
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse{
+		Success: true,
+		Data:    group,
+	})
+}
+
+func (h *ElectiveGroupHandler) GetAllElectiveGroups(c *gin.Context) {
+	var groups []models.ElectiveGroup
+	var err error
+
+	if sessionIDStr := c.Query("session_id"); sessionIDStr != "" {
+		sessionID, parseErr := strconv.ParseUint(sessionIDStr, 10, 32)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   "Invalid session ID",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		groups, err = h.electiveGroupService.GetElectiveGroupsBySession(uint(sessionID))
+	} else {
+		groups, err = h.electiveGroupService.GetAllElectiveGroups()
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    groups,
+	})
+}
+
+func (h *ElectiveGroupHandler) GetElectiveGroup(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid elective group ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	group, err := h.electiveGroupService.GetElectiveGroupByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Success: false,
+			Error:   "Elective group not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    group,
+	})
+}
+
+func (h *ElectiveGroupHandler) UpdateElectiveGroup(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid elective group ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.ElectiveGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	group := &models.ElectiveGroup{
+		ID:          uint(id),
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := h.electiveGroupService.UpdateElectiveGroup(group, req.CourseOfferingIDs); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if updated, err := h.electiveGroupService.GetElectiveGroupByID(group.ID); err == nil {
+		group = updated
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    group,
+	})
+}
+
+func (h *ElectiveGroupHandler) DeleteElectiveGroup(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid elective group ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.electiveGroupService.DeleteElectiveGroup(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Message: "Elective group deleted successfully",
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"icrogen/internal/transport/http/dto"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jobEventInterval is how often a job's progress is sent to event streams
+const jobEventInterval = 500 * time.Millisecond
+
+// parseJobID reads the generation job ID, answering the request itself when
+// it is invalid
+func parseJobID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid generation job ID",
+			Code:    http.StatusBadRequest,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetGenerationJob reports the status and progress of a background generation
+func (h *RoutineHandler) GetGenerationJob(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.routineService.GetGenerationJob(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// StreamGenerationJob sends a background generation's progress as
+// Server-Sent Events until it finishes or the client goes away
+func (h *RoutineHandler) StreamGenerationJob(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+	if _, err := h.routineService.GetGenerationJob(id); err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	ticker := time.NewTicker(jobEventInterval)
+	defer ticker.Stop()
+	c.Stream(func(w io.Writer) bool {
+		job, err := h.routineService.GetGenerationJob(id)
+		if err != nil {
+			c.SSEvent("error", err.Error())
+			return false
+		}
+		if job.Finished {
+			c.SSEvent("done", job)
+			return false
+		}
+		c.SSEvent("progress", job)
+
+		select {
+		case <-ticker.C:
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// CancelGenerationJob stops a queued or running background generation
+func (h *RoutineHandler) CancelGenerationJob(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.routineService.CancelGenerationJob(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.APIResponse{
+		Success: true,
+		Message: "Generation job is being cancelled",
+		Data:    job,
+	})
+}
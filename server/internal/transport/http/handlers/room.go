@@ -38,6 +38,7 @@ func (h *RoomHandler) CreateRoom(c *gin.Context) {
 		Type:         req.Type,
 		DepartmentID: req.DepartmentID,
 		IsActive:     true,
+		Features:     service.EncodeFeatures(req.Features),
 	}
 
 	if err := h.roomService.CreateRoom(room); err != nil {
@@ -186,6 +187,10 @@ func (h *RoomHandler) UpdateRoom(c *gin.Context) {
 		DepartmentID: req.DepartmentID,
 		IsActive:     *req.IsActive,
 	}
+	// Features left out of the request keep their stored value
+	if req.Features != nil {
+		room.Features = service.EncodeFeatures(req.Features)
+	}
 
 	if err := h.roomService.UpdateRoom(room); err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"icrogen/internal/service"
 	"icrogen/internal/transport/http/dto"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +21,23 @@ func NewRoutineHandler(routineService service.RoutineGenerationService) *Routine
 	}
 }
 
-// GenerateRoutine generates a routine for a semester offering
+// respondLockConflict answers with 409 Conflict when another generation,
+// commit or cancel holds the semester offering or session, and reports
+// whether it did
+func respondLockConflict(c *gin.Context, err error) bool {
+	var conflict *service.LockConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	c.JSON(http.StatusConflict, dto.APIResponse{
+		Success: false,
+		Error:   err.Error(),
+		Data:    conflict,
+	})
+	return true
+}
+
+// GenerateRoutine starts a background generation for a semester offering
 func (h *RoutineHandler) GenerateRoutine(c *gin.Context) {
 	var req dto.GenerateRoutineRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -31,8 +49,61 @@ func (h *RoutineHandler) GenerateRoutine(c *gin.Context) {
 		return
 	}
 
-	scheduleRun, err := h.routineService.GenerateRoutine(req.SemesterOfferingID)
+	opts := service.GenerationOptions{
+		Strategy:   req.Strategy,
+		TimeBudget: time.Duration(req.TimeBudgetSeconds) * time.Second,
+		NodeBudget: req.NodeBudget,
+	}
+
+	job, err := h.routineService.StartGenerationJob(req.SemesterOfferingID, opts)
+	if err != nil {
+		if respondLockConflict(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.APIResponse{
+		Success: true,
+		Message: "Routine generation queued",
+		Data:    job,
+	})
+}
+
+// GenerateSessionRoutine generates routines for all matching semester offerings of a session
+func (h *RoutineHandler) GenerateSessionRoutine(c *gin.Context) {
+	var req dto.GenerateSessionRoutineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	filters := service.SessionGenerationFilters{
+		ProgrammeIDs:    req.ProgrammeIDs,
+		DepartmentIDs:   req.DepartmentIDs,
+		SemesterNumbers: req.SemesterNumbers,
+	}
+
+	opts := service.GenerationOptions{
+		Strategy:   req.Strategy,
+		TimeBudget: time.Duration(req.TimeBudgetSeconds) * time.Second,
+		NodeBudget: req.NodeBudget,
+	}
+
+	sessionRun, err := h.routineService.GenerateSessionRoutine(c.Request.Context(), req.SessionID, filters, opts)
 	if err != nil {
+		if respondLockConflict(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Success: false,
 			Error:   err.Error(),
@@ -43,11 +114,94 @@ func (h *RoutineHandler) GenerateRoutine(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dto.APIResponse{
 		Success: true,
-		Message: "Routine generated successfully",
+		Message: "Session routine generated successfully",
+		Data:    sessionRun,
+	})
+}
+
+// RegenerateRoutine generates a new run from a draft or failed one, keeping its
+// pinned blocks, or in repair mode as many of its blocks as it can
+func (h *RoutineHandler) RegenerateRoutine(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid schedule run ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.RegenerateRoutineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	opts := service.GenerationOptions{
+		Strategy:   req.Strategy,
+		TimeBudget: time.Duration(req.TimeBudgetSeconds) * time.Second,
+		NodeBudget: req.NodeBudget,
+	}
+	scope := service.RegenerationScope{
+		Repair:            req.Repair,
+		CourseOfferingIDs: req.CourseOfferingIDs,
+		TeacherIDs:        req.TeacherIDs,
+	}
+
+	scheduleRun, err := h.routineService.RegenerateRoutine(c.Request.Context(), uint(id), opts, scope)
+	if err != nil {
+		if respondLockConflict(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Message: "Routine regenerated successfully",
 		Data:    scheduleRun,
 	})
 }
 
+// GetSessionScheduleRun gets a session-wide schedule run with its per-offering runs
+func (h *RoutineHandler) GetSessionScheduleRun(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid session schedule run ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	sessionRun, err := h.routineService.GetSessionScheduleRun(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    sessionRun,
+	})
+}
+
 // GetScheduleRun gets a schedule run by ID
 func (h *RoutineHandler) GetScheduleRun(c *gin.Context) {
 	idStr := c.Param("id")
@@ -77,6 +231,48 @@ func (h *RoutineHandler) GetScheduleRun(c *gin.Context) {
 	})
 }
 
+// DiffScheduleRuns compares a schedule run with another run of the same
+// semester offering
+func (h *RoutineHandler) DiffScheduleRuns(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid schedule run ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	otherID, err := strconv.ParseUint(c.Param("other_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid schedule run ID to compare with",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	diff, err := h.routineService.DiffScheduleRuns(uint(id), uint(otherID))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrScheduleRunNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    diff,
+	})
+}
+
 // GetScheduleRunsBySemesterOffering gets schedule runs by semester offering ID
 func (h *RoutineHandler) GetScheduleRunsBySemesterOffering(c *gin.Context) {
 	semesterOfferingIDStr := c.Param("semester_offering_id")
@@ -120,6 +316,18 @@ func (h *RoutineHandler) CommitScheduleRun(c *gin.Context) {
 	}
 
 	if err := h.routineService.CommitScheduleRun(uint(id)); err != nil {
+		if respondLockConflict(c, err) {
+			return
+		}
+		var conflict *service.CommitConflictError
+		if errors.As(err, &conflict) {
+			c.JSON(http.StatusConflict, dto.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+				Data:    conflict.Conflicts,
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
 			Success: false,
 			Error:   err.Error(),
@@ -148,6 +356,9 @@ func (h *RoutineHandler) CancelScheduleRun(c *gin.Context) {
 	}
 
 	if err := h.routineService.CancelScheduleRun(uint(id)); err != nil {
+		if respondLockConflict(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
 			Success: false,
 			Error:   err.Error(),
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"icrogen/internal/service"
+	"icrogen/internal/transport/http/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseRunBlock reads the schedule run and block IDs of a block edit,
+// answering the request itself when either is invalid
+func parseRunBlock(c *gin.Context) (uint, uint, bool) {
+	runID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid schedule run ID",
+			Code:    http.StatusBadRequest,
+		})
+		return 0, 0, false
+	}
+	blockID, err := strconv.ParseUint(c.Param("block_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid schedule block ID",
+			Code:    http.StatusBadRequest,
+		})
+		return 0, 0, false
+	}
+	return uint(runID), uint(blockID), true
+}
+
+// respondBlockEdit answers an edit that could be refused for conflicts with
+// the conflict list, or with the updated run
+func (h *RoutineHandler) respondBlockEdit(c *gin.Context, runID uint, conflicts []service.SlotConflict, err error) {
+	if respondLockConflict(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, dto.APIResponse{
+			Success: false,
+			Error:   "The edit conflicts with other classes",
+			Data:    conflicts,
+		})
+		return
+	}
+
+	scheduleRun, err := h.routineService.GetScheduleRun(runID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Message: "Schedule block updated successfully",
+		Data:    scheduleRun,
+	})
+}
+
+// MoveScheduleBlock moves a block of a draft run to another slot
+func (h *RoutineHandler) MoveScheduleBlock(c *gin.Context) {
+	runID, blockID, ok := parseRunBlock(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MoveScheduleBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	conflicts, err := h.routineService.MoveScheduleBlock(runID, blockID, service.BlockMove{
+		DayOfWeek: req.DayOfWeek,
+		SlotStart: req.SlotStart,
+		TeacherID: req.TeacherID,
+		RoomID:    req.RoomID,
+	})
+	h.respondBlockEdit(c, runID, conflicts, err)
+}
+
+// SwapScheduleBlocks exchanges the slots of two blocks of a draft run
+func (h *RoutineHandler) SwapScheduleBlocks(c *gin.Context) {
+	runID, blockID, ok := parseRunBlock(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SwapScheduleBlocksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	conflicts, err := h.routineService.SwapScheduleBlocks(runID, blockID, req.OtherBlockID)
+	h.respondBlockEdit(c, runID, conflicts, err)
+}
+
+// PinScheduleBlock pins or unpins a block of a draft run
+func (h *RoutineHandler) PinScheduleBlock(c *gin.Context) {
+	runID, blockID, ok := parseRunBlock(c)
+	if !ok {
+		return
+	}
+
+	var req dto.PinScheduleBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	err := h.routineService.PinScheduleBlock(runID, blockID, *req.Pinned)
+	h.respondBlockEdit(c, runID, nil, err)
+}
+
+// DeleteScheduleBlock removes a block from a draft run
+func (h *RoutineHandler) DeleteScheduleBlock(c *gin.Context) {
+	runID, blockID, ok := parseRunBlock(c)
+	if !ok {
+		return
+	}
+
+	if err := h.routineService.DeleteScheduleBlock(runID, blockID); err != nil {
+		if respondLockConflict(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Message: "Schedule block deleted successfully",
+	})
+}
@@ -239,6 +239,7 @@ func (h *SemesterOfferingHandler) AddCourseOffering(c *gin.Context) {
 		WeeklyRequiredSlots: req.WeeklyRequiredSlots,
 		RequiredPattern:     req.RequiredPattern,
 		PreferredRoomID:     req.PreferredRoomID,
+		MaxRoomSplit:        req.MaxRoomSplit,
 		Notes:               req.Notes,
 	}
 
@@ -252,6 +253,19 @@ func (h *SemesterOfferingHandler) AddCourseOffering(c *gin.Context) {
 		return
 	}
 
+	if len(req.StudentGroupIDs) > 0 {
+		withGroups, err := h.courseOfferingService.SetStudentGroups(courseOffering.ID, req.StudentGroupIDs)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		courseOffering = withGroups
+	}
+
 	c.JSON(http.StatusCreated, dto.SuccessResponse{
 		Success: true,
 		Data:    courseOffering,
@@ -483,4 +497,73 @@ func (h *SemesterOfferingHandler) RemoveRoomFromCourse(c *gin.Context) {
 		Success: true,
 		Message: "Room removed successfully",
 	})
-}
\ No newline at end of file
+}
+// List room assignments whose room is too small for the group
+func (h *SemesterOfferingHandler) GetUnderCapacityRoomAssignments(c *gin.Context) {
+	var semesterOfferingID uint64
+	if idStr := c.Query("semester_offering_id"); idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   "Invalid semester offering ID",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		semesterOfferingID = id
+	}
+
+	issues, err := h.courseOfferingService.GetUnderCapacityRoomAssignments(uint(semesterOfferingID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    issues,
+	})
+}
+
+// SetCourseStudentGroups sets the sections or batches a course offering is taught to
+func (h *SemesterOfferingHandler) SetCourseStudentGroups(c *gin.Context) {
+	courseOfferingID, err := strconv.ParseUint(c.Param("course_offering_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid course offering ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.SetStudentGroupsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	offering, err := h.courseOfferingService.SetStudentGroups(uint(courseOfferingID), req.StudentGroupIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    offering,
+	})
+}
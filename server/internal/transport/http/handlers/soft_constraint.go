@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"encoding/json"
+	"icrogen/internal/models"
+	"icrogen/internal/service"
+	"icrogen/internal/transport/http/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SoftConstraintHandler struct {
+	softConstraintService service.SoftConstraintService
+}
+
+func NewSoftConstraintHandler(softConstraintService service.SoftConstraintService) *SoftConstraintHandler {
+	return &SoftConstraintHandler{
+		softConstraintService: softConstraintService,
+	}
+}
+
+// encodeParams stores constraint parameters as a JSON object
+func encodeParams(params map[string]int) string {
+	if len(params) == 0 {
+		return "{}"
+	}
+	encoded, _ := json.Marshal(params)
+	return string(encoded)
+}
+
+func (h *SoftConstraintHandler) CreateSoftConstraint(c *gin.Context) {
+	var req dto.CreateSoftConstraintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	constraint := &models.SoftConstraint{
+		Type:         req.Type,
+		Weight:       req.Weight,
+		Params:       encodeParams(req.Params),
+		ProgrammeID:  req.ProgrammeID,
+		DepartmentID: req.DepartmentID,
+		IsActive:     true,
+	}
+
+	if err := h.softConstraintService.CreateSoftConstraint(constraint); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse{
+		Success: true,
+		Data:    constraint,
+	})
+}
+
+func (h *SoftConstraintHandler) GetAllSoftConstraints(c *gin.Context) {
+	var constraints []models.SoftConstraint
+	var err error
+
+	if programmeIDStr := c.Query("programme_id"); programmeIDStr != "" {
+		programmeID, parseErr := strconv.ParseUint(programmeIDStr, 10, 32)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   "Invalid programme ID",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		constraints, err = h.softConstraintService.GetSoftConstraintsByProgrammeID(uint(programmeID))
+	} else if departmentIDStr := c.Query("department_id"); departmentIDStr != "" {
+		departmentID, parseErr := strconv.ParseUint(departmentIDStr, 10, 32)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   "Invalid department ID",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		constraints, err = h.softConstraintService.GetSoftConstraintsByDepartmentID(uint(departmentID))
+	} else {
+		constraints, err = h.softConstraintService.GetAllSoftConstraints()
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    constraints,
+	})
+}
+
+func (h *SoftConstraintHandler) GetSoftConstraintTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    h.softConstraintService.GetSoftConstraintTypes(),
+	})
+}
+
+func (h *SoftConstraintHandler) GetSoftConstraint(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid soft constraint ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	constraint, err := h.softConstraintService.GetSoftConstraintByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Success: false,
+			Error:   "Soft constraint not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    constraint,
+	})
+}
+
+func (h *SoftConstraintHandler) UpdateSoftConstraint(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid soft constraint ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.UpdateSoftConstraintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	constraint := &models.SoftConstraint{
+		ID:           uint(id),
+		Type:         req.Type,
+		Weight:       req.Weight,
+		Params:       encodeParams(req.Params),
+		ProgrammeID:  req.ProgrammeID,
+		DepartmentID: req.DepartmentID,
+		IsActive:     true,
+	}
+	if req.IsActive != nil {
+		constraint.IsActive = *req.IsActive
+	}
+
+	if err := h.softConstraintService.UpdateSoftConstraint(constraint); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    constraint,
+	})
+}
+
+func (h *SoftConstraintHandler) DeleteSoftConstraint(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid soft constraint ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.softConstraintService.DeleteSoftConstraint(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Message: "Soft constraint deleted successfully",
+	})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"icrogen/internal/models"
+	"icrogen/internal/service"
+	"icrogen/internal/transport/http/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StudentGroupHandler struct {
+	groupService service.StudentGroupService
+}
+
+func NewStudentGroupHandler(groupService service.StudentGroupService) *StudentGroupHandler {
+	return &StudentGroupHandler{
+		groupService: groupService,
+	}
+}
+
+func toStudentGroup(semesterOfferingID uint, req dto.StudentGroupRequest) *models.StudentGroup {
+	return &models.StudentGroup{
+		SemesterOfferingID: semesterOfferingID,
+		ParentID:           req.ParentID,
+		Name:               req.Name,
+		Kind:               req.Kind,
+		Strength:           req.Strength,
+	}
+}
+
+// GetStudentGroups lists a semester offering's sections with their batches
+func (h *StudentGroupHandler) GetStudentGroups(c *gin.Context) {
+	semesterOfferingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid semester offering ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	groups, err := h.groupService.GetGroupsBySemesterOffering(uint(semesterOfferingID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    groups,
+	})
+}
+
+func (h *StudentGroupHandler) CreateStudentGroup(c *gin.Context) {
+	semesterOfferingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid semester offering ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.StudentGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	group := toStudentGroup(uint(semesterOfferingID), req)
+	if group.Kind == "" {
+		group.Kind = service.GroupSection
+		if group.ParentID != nil {
+			group.Kind = service.GroupBatch
+		}
+	}
+	if err := h.groupService.CreateGroup(group); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse{
+		Success: true,
+		Data:    group,
+	})
+}
+
+func (h *StudentGroupHandler) UpdateStudentGroup(c *gin.Context) {
+	semesterOfferingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid semester offering ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	groupID, err := strconv.ParseUint(c.Param("group_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid group ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.StudentGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	group := toStudentGroup(uint(semesterOfferingID), req)
+	group.ID = uint(groupID)
+	if err := h.groupService.UpdateGroup(group); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    group,
+	})
+}
+
+func (h *StudentGroupHandler) DeleteStudentGroup(c *gin.Context) {
+	semesterOfferingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid semester offering ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	groupID, err := strconv.ParseUint(c.Param("group_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid group ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	group, err := h.groupService.GetGroupByID(uint(groupID))
+	if err != nil || group.SemesterOfferingID != uint(semesterOfferingID) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Success: false,
+			Error:   "Group not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	if err := h.groupService.DeleteGroup(group.ID); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Message: "Group deleted successfully",
+	})
+}
@@ -40,6 +40,7 @@ func (h *SubjectHandler) CreateSubject(c *gin.Context) {
 		DepartmentID:     req.DepartmentID,
 		SubjectTypeID:    req.SubjectTypeID,
 		IsActive:         true,
+		RequiredFeatures: service.EncodeFeatures(req.RequiredFeatures),
 	}
 
 	if err := h.subjectService.CreateSubject(subject); err != nil {
@@ -215,6 +216,9 @@ func (h *SubjectHandler) UpdateSubject(c *gin.Context) {
 	existing.IsActive = req.IsActive
 	
 	// Only update SubjectTypeID if provided
+	if req.RequiredFeatures != nil {
+		existing.RequiredFeatures = service.EncodeFeatures(req.RequiredFeatures)
+	}
 	if req.SubjectTypeID != 0 {
 		existing.SubjectTypeID = req.SubjectTypeID
 	}
@@ -283,8 +287,9 @@ func (h *SubjectTypeHandler) CreateSubjectType(c *gin.Context) {
 	}
 
 	subjectType := &models.SubjectType{
-		Name:  req.Name,
-		IsLab: req.IsLab,
+		Name:             req.Name,
+		IsLab:            req.IsLab,
+		RequiredFeatures: service.EncodeFeatures(req.RequiredFeatures),
 	}
 
 	if err := h.subjectTypeService.CreateSubjectType(subjectType); err != nil {
@@ -371,8 +376,12 @@ func (h *SubjectTypeHandler) UpdateSubjectType(c *gin.Context) {
 
 	subjectType := &models.SubjectType{
 		ID:    uint(id),
-		Name:  req.Name,
-		IsLab: req.IsLab,
+		Name:             req.Name,
+		IsLab:            req.IsLab,
+	}
+	// Features left out of the request keep their stored value
+	if req.RequiredFeatures != nil {
+		subjectType.RequiredFeatures = service.EncodeFeatures(req.RequiredFeatures)
 	}
 
 	if err := h.subjectTypeService.UpdateSubjectType(subjectType); err != nil {
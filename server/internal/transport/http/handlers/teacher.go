@@ -34,6 +34,7 @@ func (h *TeacherHandler) CreateTeacher(c *gin.Context) {
 	teacher := &models.Teacher{
 		Name:         req.Name,
 		Email:        req.Email,
+		Designation:  req.Designation,
 		DepartmentID: req.DepartmentID,
 		IsActive:     true,
 	}
@@ -161,6 +162,7 @@ func (h *TeacherHandler) UpdateTeacher(c *gin.Context) {
 		ID:           uint(id),
 		Name:         req.Name,
 		Email:        req.Email,
+		Designation:  req.Designation,
 		DepartmentID: req.DepartmentID,
 		IsActive:     req.IsActive,
 	}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"icrogen/internal/models"
+	"icrogen/internal/service"
+	"icrogen/internal/transport/http/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeacherAvailabilityHandler struct {
+	availabilityService service.TeacherAvailabilityService
+}
+
+func NewTeacherAvailabilityHandler(availabilityService service.TeacherAvailabilityService) *TeacherAvailabilityHandler {
+	return &TeacherAvailabilityHandler{
+		availabilityService: availabilityService,
+	}
+}
+
+func toTeacherAvailability(teacherID uint, req dto.TeacherAvailabilityRequest) *models.TeacherAvailability {
+	return &models.TeacherAvailability{
+		TeacherID: teacherID,
+		SessionID: req.SessionID,
+		DayOfWeek: req.DayOfWeek,
+		SlotStart: req.SlotStart,
+		SlotEnd:   req.SlotEnd,
+		Kind:      req.Kind,
+		Reason:    req.Reason,
+	}
+}
+
+func (h *TeacherAvailabilityHandler) GetTeacherAvailability(c *gin.Context) {
+	teacherID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid teacher ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	availability, err := h.availabilityService.GetAvailabilityByTeacherID(uint(teacherID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    availability,
+	})
+}
+
+func (h *TeacherAvailabilityHandler) CreateTeacherAvailability(c *gin.Context) {
+	teacherID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid teacher ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.TeacherAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	availability := toTeacherAvailability(uint(teacherID), req)
+	if err := h.availabilityService.CreateAvailability(availability); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse{
+		Success: true,
+		Data:    availability,
+	})
+}
+
+func (h *TeacherAvailabilityHandler) UpdateTeacherAvailability(c *gin.Context) {
+	teacherID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid teacher ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	availabilityID, err := strconv.ParseUint(c.Param("availability_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid availability ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.TeacherAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	availability := toTeacherAvailability(uint(teacherID), req)
+	availability.ID = uint(availabilityID)
+	if err := h.availabilityService.UpdateAvailability(availability); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    availability,
+	})
+}
+
+func (h *TeacherAvailabilityHandler) DeleteTeacherAvailability(c *gin.Context) {
+	teacherID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid teacher ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	availabilityID, err := strconv.ParseUint(c.Param("availability_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid availability ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	availability, err := h.availabilityService.GetAvailabilityByID(uint(availabilityID))
+	if err != nil || availability.TeacherID != uint(teacherID) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Success: false,
+			Error:   "Availability not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	if err := h.availabilityService.DeleteAvailability(availability.ID); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Message: "Availability deleted successfully",
+	})
+}
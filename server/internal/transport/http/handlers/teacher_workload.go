@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"icrogen/internal/models"
+	"icrogen/internal/service"
+	"icrogen/internal/transport/http/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeacherWorkloadHandler struct {
+	workloadService service.TeacherWorkloadService
+}
+
+func NewTeacherWorkloadHandler(workloadService service.TeacherWorkloadService) *TeacherWorkloadHandler {
+	return &TeacherWorkloadHandler{
+		workloadService: workloadService,
+	}
+}
+
+func toWorkloadLimit(req dto.TeacherWorkloadLimitRequest) *models.TeacherWorkloadLimit {
+	return &models.TeacherWorkloadLimit{
+		TeacherID:      req.TeacherID,
+		Designation:    req.Designation,
+		MaxPerDay:      req.MaxPerDay,
+		MaxPerWeek:     req.MaxPerWeek,
+		MaxConsecutive: req.MaxConsecutive,
+		MinFreeDays:    req.MinFreeDays,
+	}
+}
+
+func (h *TeacherWorkloadHandler) CreateWorkloadLimit(c *gin.Context) {
+	var req dto.TeacherWorkloadLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	limit := toWorkloadLimit(req)
+	if err := h.workloadService.CreateLimit(limit); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse{
+		Success: true,
+		Data:    limit,
+	})
+}
+
+func (h *TeacherWorkloadHandler) GetAllWorkloadLimits(c *gin.Context) {
+	limits, err := h.workloadService.GetAllLimits()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    limits,
+	})
+}
+
+func (h *TeacherWorkloadHandler) GetWorkloadLimit(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid workload limit ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	limit, err := h.workloadService.GetLimitByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Success: false,
+			Error:   "Workload limit not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    limit,
+	})
+}
+
+func (h *TeacherWorkloadHandler) UpdateWorkloadLimit(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid workload limit ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.TeacherWorkloadLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	limit := toWorkloadLimit(req)
+	limit.ID = uint(id)
+	if err := h.workloadService.UpdateLimit(limit); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    limit,
+	})
+}
+
+func (h *TeacherWorkloadHandler) DeleteWorkloadLimit(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid workload limit ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.workloadService.DeleteLimit(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Message: "Workload limit deleted successfully",
+	})
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"icrogen/internal/models"
+	"icrogen/internal/service"
+	"icrogen/internal/transport/http/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TimeGridHandler struct {
+	timeGridService service.TimeGridService
+}
+
+func NewTimeGridHandler(timeGridService service.TimeGridService) *TimeGridHandler {
+	return &TimeGridHandler{
+		timeGridService: timeGridService,
+	}
+}
+
+func toTimeSlots(slots []dto.TimeGridSlotRequest) []models.TimeSlot {
+	timeSlots := make([]models.TimeSlot, len(slots))
+	for i, slot := range slots {
+		timeSlots[i] = models.TimeSlot{
+			DayOfWeek:  slot.DayOfWeek,
+			SlotNumber: slot.SlotNumber,
+			StartTime:  slot.StartTime,
+			EndTime:    slot.EndTime,
+			IsLabStart: slot.IsLabStart,
+		}
+	}
+	return timeSlots
+}
+
+func (h *TimeGridHandler) CreateTimeGrid(c *gin.Context) {
+	var req dto.CreateTimeGridRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	grid := &models.TimeGrid{
+		Name:        req.Name,
+		Description: req.Description,
+		ProgrammeID: req.ProgrammeID,
+		IsActive:    true,
+		TimeSlots:   toTimeSlots(req.Slots),
+	}
+
+	if err := h.timeGridService.CreateTimeGrid(grid); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse{
+		Success: true,
+		Data:    grid,
+	})
+}
+
+func (h *TimeGridHandler) GetAllTimeGrids(c *gin.Context) {
+	grids, err := h.timeGridService.GetAllTimeGrids()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    grids,
+	})
+}
+
+func (h *TimeGridHandler) GetTimeGrid(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid time grid ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	grid, err := h.timeGridService.GetTimeGridByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Success: false,
+			Error:   "Time grid not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    grid,
+	})
+}
+
+func (h *TimeGridHandler) UpdateTimeGrid(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid time grid ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.UpdateTimeGridRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	grid := &models.TimeGrid{
+		ID:          uint(id),
+		Name:        req.Name,
+		Description: req.Description,
+		ProgrammeID: req.ProgrammeID,
+		IsActive:    true,
+		TimeSlots:   toTimeSlots(req.Slots),
+	}
+	if req.IsActive != nil {
+		grid.IsActive = *req.IsActive
+	}
+
+	if err := h.timeGridService.UpdateTimeGrid(grid); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Data:    grid,
+	})
+}
+
+func (h *TimeGridHandler) DeleteTimeGrid(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid time grid ID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.timeGridService.DeleteTimeGrid(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Message: "Time grid deleted successfully",
+	})
+}
@@ -36,6 +36,14 @@ func (s *Server) setupRoutes() {
 	semesterOfferingRepo := repository.NewSemesterOfferingRepository(s.db)
 	courseOfferingRepo := repository.NewCourseOfferingRepository(s.db)
 	scheduleRepo := repository.NewScheduleRepository(s.db)
+	timeGridRepo := repository.NewTimeGridRepository(s.db)
+	softConstraintRepo := repository.NewSoftConstraintRepository(s.db)
+	teacherAvailabilityRepo := repository.NewTeacherAvailabilityRepository(s.db)
+	teacherWorkloadRepo := repository.NewTeacherWorkloadRepository(s.db)
+	scheduleLockRepo := repository.NewScheduleLockRepository(s.db)
+	studentGroupRepo := repository.NewStudentGroupRepository(s.db)
+	electiveGroupRepo := repository.NewElectiveGroupRepository(s.db)
+	combinedClassRepo := repository.NewCombinedClassRepository(s.db)
 
 	// Initialize services
 	programmeService := service.NewProgrammeService(programmeRepo, departmentRepo)
@@ -46,8 +54,15 @@ func (s *Server) setupRoutes() {
 	roomService := service.NewRoomService(roomRepo, departmentRepo)
 	sessionService := service.NewSessionService(sessionRepo)
 	semesterOfferingService := service.NewSemesterOfferingService(semesterOfferingRepo, programmeRepo, departmentRepo, sessionRepo)
-	courseOfferingService := service.NewCourseOfferingService(courseOfferingRepo, subjectRepo, teacherRepo, roomRepo)
-	routineService := service.NewRoutineGenerationService(scheduleRepo, semesterOfferingRepo, courseOfferingRepo, teacherRepo, roomRepo)
+	courseOfferingService := service.NewCourseOfferingService(courseOfferingRepo, subjectRepo, teacherRepo, roomRepo, studentGroupRepo)
+	routineService := service.NewRoutineGenerationService(scheduleRepo, semesterOfferingRepo, courseOfferingRepo, teacherRepo, roomRepo, timeGridRepo, softConstraintRepo, teacherAvailabilityRepo, teacherWorkloadRepo, scheduleLockRepo)
+	timeGridService := service.NewTimeGridService(timeGridRepo, programmeRepo)
+	softConstraintService := service.NewSoftConstraintService(softConstraintRepo, programmeRepo, departmentRepo)
+	teacherAvailabilityService := service.NewTeacherAvailabilityService(teacherAvailabilityRepo, teacherRepo, sessionRepo)
+	teacherWorkloadService := service.NewTeacherWorkloadService(teacherWorkloadRepo, teacherRepo)
+	studentGroupService := service.NewStudentGroupService(studentGroupRepo, semesterOfferingRepo)
+	electiveGroupService := service.NewElectiveGroupService(electiveGroupRepo, courseOfferingRepo, sessionRepo)
+	combinedClassService := service.NewCombinedClassService(combinedClassRepo, courseOfferingRepo, sessionRepo)
 
 	// Initialize handlers
 	programmeHandler := handlers.NewProgrammeHandler(programmeService)
@@ -59,6 +74,13 @@ func (s *Server) setupRoutes() {
 	sessionHandler := handlers.NewSessionHandler(sessionService)
 	semesterOfferingHandler := handlers.NewSemesterOfferingHandler(semesterOfferingService, courseOfferingService)
 	routineHandler := handlers.NewRoutineHandler(routineService)
+	timeGridHandler := handlers.NewTimeGridHandler(timeGridService)
+	softConstraintHandler := handlers.NewSoftConstraintHandler(softConstraintService)
+	teacherAvailabilityHandler := handlers.NewTeacherAvailabilityHandler(teacherAvailabilityService)
+	teacherWorkloadHandler := handlers.NewTeacherWorkloadHandler(teacherWorkloadService)
+	studentGroupHandler := handlers.NewStudentGroupHandler(studentGroupService)
+	electiveGroupHandler := handlers.NewElectiveGroupHandler(electiveGroupService)
+	combinedClassHandler := handlers.NewCombinedClassHandler(combinedClassService)
 
 	// Setup middleware
 	s.router.Use(middleware.LoggerMiddleware())
@@ -100,6 +122,10 @@ func (s *Server) setupRoutes() {
 			teachers.PUT("/:id", teacherHandler.UpdateTeacher)
 			teachers.DELETE("/:id", teacherHandler.DeleteTeacher)
 			teachers.GET("/department/:department_id", teacherHandler.GetTeachersByDepartment)
+			teachers.GET("/:id/availability", teacherAvailabilityHandler.GetTeacherAvailability)
+			teachers.POST("/:id/availability", teacherAvailabilityHandler.CreateTeacherAvailability)
+			teachers.PUT("/:id/availability/:availability_id", teacherAvailabilityHandler.UpdateTeacherAvailability)
+			teachers.DELETE("/:id/availability/:availability_id", teacherAvailabilityHandler.DeleteTeacherAvailability)
 		}
 
 		// Subject routes
@@ -135,6 +161,38 @@ func (s *Server) setupRoutes() {
 			rooms.GET("/type", roomHandler.GetRoomsByType)
 			rooms.GET("/department/:department_id", roomHandler.GetRoomsByDepartment)
 			rooms.GET("/availability", roomHandler.CheckRoomAvailability)
+			rooms.GET("/capacity-check", semesterOfferingHandler.GetUnderCapacityRoomAssignments)
+		}
+
+		// Time grid routes
+		timeGrids := api.Group("/time-grids")
+		{
+			timeGrids.POST("", timeGridHandler.CreateTimeGrid)
+			timeGrids.GET("", timeGridHandler.GetAllTimeGrids)
+			timeGrids.GET("/:id", timeGridHandler.GetTimeGrid)
+			timeGrids.PUT("/:id", timeGridHandler.UpdateTimeGrid)
+			timeGrids.DELETE("/:id", timeGridHandler.DeleteTimeGrid)
+		}
+
+		// Soft constraint routes
+		softConstraints := api.Group("/soft-constraints")
+		{
+			softConstraints.POST("", softConstraintHandler.CreateSoftConstraint)
+			softConstraints.GET("", softConstraintHandler.GetAllSoftConstraints)
+			softConstraints.GET("/types", softConstraintHandler.GetSoftConstraintTypes)
+			softConstraints.GET("/:id", softConstraintHandler.GetSoftConstraint)
+			softConstraints.PUT("/:id", softConstraintHandler.UpdateSoftConstraint)
+			softConstraints.DELETE("/:id", softConstraintHandler.DeleteSoftConstraint)
+		}
+
+		// Teacher workload limit routes
+		workloadLimits := api.Group("/workload-limits")
+		{
+			workloadLimits.POST("", teacherWorkloadHandler.CreateWorkloadLimit)
+			workloadLimits.GET("", teacherWorkloadHandler.GetAllWorkloadLimits)
+			workloadLimits.GET("/:id", teacherWorkloadHandler.GetWorkloadLimit)
+			workloadLimits.PUT("/:id", teacherWorkloadHandler.UpdateWorkloadLimit)
+			workloadLimits.DELETE("/:id", teacherWorkloadHandler.DeleteWorkloadLimit)
 		}
 
 		// Session routes
@@ -168,16 +226,54 @@ func (s *Server) setupRoutes() {
 			semesterOfferings.DELETE("/:id/course-offerings/:course_offering_id/teachers/:teacher_id", semesterOfferingHandler.RemoveTeacherFromCourse)
 			semesterOfferings.POST("/:id/course-offerings/:course_offering_id/rooms", semesterOfferingHandler.AssignRoomToCourse)
 			semesterOfferings.DELETE("/:id/course-offerings/:course_offering_id/rooms/:room_id", semesterOfferingHandler.RemoveRoomFromCourse)
+			semesterOfferings.PUT("/:id/course-offerings/:course_offering_id/groups", semesterOfferingHandler.SetCourseStudentGroups)
+
+			// Sections and lab batches
+			semesterOfferings.GET("/:id/groups", studentGroupHandler.GetStudentGroups)
+			semesterOfferings.POST("/:id/groups", studentGroupHandler.CreateStudentGroup)
+			semesterOfferings.PUT("/:id/groups/:group_id", studentGroupHandler.UpdateStudentGroup)
+			semesterOfferings.DELETE("/:id/groups/:group_id", studentGroupHandler.DeleteStudentGroup)
+		}
+
+		// Elective group routes
+		electiveGroups := api.Group("/elective-groups")
+		{
+			electiveGroups.POST("", electiveGroupHandler.CreateElectiveGroup)
+			electiveGroups.GET("", electiveGroupHandler.GetAllElectiveGroups)
+			electiveGroups.GET("/:id", electiveGroupHandler.GetElectiveGroup)
+			electiveGroups.PUT("/:id", electiveGroupHandler.UpdateElectiveGroup)
+			electiveGroups.DELETE("/:id", electiveGroupHandler.DeleteElectiveGroup)
+		}
+
+		// Combined class routes
+		combinedClasses := api.Group("/combined-classes")
+		{
+			combinedClasses.POST("", combinedClassHandler.CreateCombinedClass)
+			combinedClasses.GET("", combinedClassHandler.GetAllCombinedClasses)
+			combinedClasses.GET("/:id", combinedClassHandler.GetCombinedClass)
+			combinedClasses.PUT("/:id", combinedClassHandler.UpdateCombinedClass)
+			combinedClasses.DELETE("/:id", combinedClassHandler.DeleteCombinedClass)
 		}
 
 		// Routine generation routes
 		routines := api.Group("/routines")
 		{
 			routines.POST("/generate", routineHandler.GenerateRoutine)
+			routines.GET("/jobs/:id", routineHandler.GetGenerationJob)
+			routines.GET("/jobs/:id/events", routineHandler.StreamGenerationJob)
+			routines.POST("/jobs/:id/cancel", routineHandler.CancelGenerationJob)
+			routines.POST("/generate-session", routineHandler.GenerateSessionRoutine)
+			routines.GET("/session-runs/:id", routineHandler.GetSessionScheduleRun)
 			routines.GET("/:id", routineHandler.GetScheduleRun)
 			routines.GET("/semester-offering/:semester_offering_id", routineHandler.GetScheduleRunsBySemesterOffering)
 			routines.POST("/:id/commit", routineHandler.CommitScheduleRun)
 			routines.POST("/:id/cancel", routineHandler.CancelScheduleRun)
+			routines.POST("/:id/regenerate", routineHandler.RegenerateRoutine)
+			routines.GET("/:id/diff/:other_id", routineHandler.DiffScheduleRuns)
+			routines.POST("/:id/blocks/:block_id/move", routineHandler.MoveScheduleBlock)
+			routines.POST("/:id/blocks/:block_id/swap", routineHandler.SwapScheduleBlocks)
+			routines.POST("/:id/blocks/:block_id/pin", routineHandler.PinScheduleBlock)
+			routines.DELETE("/:id/blocks/:block_id", routineHandler.DeleteScheduleBlock)
 		}
 
 		// Health check